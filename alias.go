@@ -0,0 +1,75 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import "math/bits"
+
+// aliasTable implements Vose's alias method for sampling from a fixed
+// discrete distribution in constant time, using a single 128-bit value
+// per sample: the low word picks a column, and the high word decides
+// between the column and its alias.
+type aliasTable struct {
+	prob  []float64
+	alias []int
+}
+
+// newAliasTable builds an alias table for the given weights, which must
+// be non-negative and not all zero. They need not sum to 1.
+func newAliasTable(weights []float64) *aliasTable {
+	n := len(weights)
+	a := &aliasTable{prob: make([]float64, n), alias: make([]int, n)}
+	total := 0.0
+	for _, w := range weights {
+		total += w
+	}
+	scaled := make([]float64, n)
+	small := make([]int, 0, n)
+	large := make([]int, 0, n)
+	for i, w := range weights {
+		scaled[i] = w * float64(n) / total
+		if scaled[i] < 1 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+	for len(small) > 0 && len(large) > 0 {
+		s, l := small[len(small)-1], large[len(large)-1]
+		small = small[:len(small)-1]
+		a.prob[s], a.alias[s] = scaled[s], l
+		scaled[l] -= 1 - scaled[s]
+		if scaled[l] < 1 {
+			large = large[:len(large)-1]
+			small = append(small, l)
+		}
+	}
+	// Anything left over is 1, give or take rounding error.
+	for _, i := range large {
+		a.prob[i], a.alias[i] = 1, i
+	}
+	for _, i := range small {
+		a.prob[i], a.alias[i] = 1, i
+	}
+	return a
+}
+
+// sample picks an index using the provided random bits.
+func (a *aliasTable) sample(u Uint128) int {
+	col, _ := bits.Mul64(u.Lo, uint64(len(a.prob)))
+	if unitFloat64(u.Hi) < a.prob[col] {
+		return int(col)
+	}
+	return a.alias[col]
+}
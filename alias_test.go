@@ -0,0 +1,55 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_AliasTable(t *testing.T) {
+	weights := []float64{1, 0, 3, 0.5, 5.5}
+	total := 10.0
+	a := newAliasTable(weights)
+	// The implied probability of each index is the sum of its own
+	// column's probability and any aliases pointing at it.
+	implied := make([]float64, len(weights))
+	for col := range weights {
+		implied[col] += a.prob[col]
+		implied[a.alias[col]] += 1 - a.prob[col]
+	}
+	for i, w := range weights {
+		exp := w / total
+		got := implied[i] / float64(len(weights))
+		if math.Abs(got-exp) > 1e-12 {
+			t.Errorf("index %d: expected probability %g, got %g", i, exp, got)
+		}
+	}
+	src := NewSequence(0)
+	counts := make([]int, len(weights))
+	const samples = 100000
+	for i := uint64(0); i < samples; i++ {
+		counts[a.sample(src.BitsAt(OffsetFor(SequenceUser1, 0, 0, i)))]++
+	}
+	if counts[1] != 0 {
+		t.Errorf("zero-weight index sampled %d times", counts[1])
+	}
+	for i, w := range weights {
+		exp := w / total * samples
+		if math.Abs(float64(counts[i])-exp) > 5*math.Sqrt(exp)+1 {
+			t.Errorf("index %d: expected about %.0f samples, got %d", i, exp, counts[i])
+		}
+	}
+}
@@ -27,19 +27,24 @@ import (
 // Sequence represents a specific deterministic but pseudo-random-ish series
 // of bits. A Sequence can be used as a `rand.Source` or `rand.Source64`
 // for `math/rand`.
+//
+// BitsSliceAt(start, dst) fills dst with the bits for len(dst)
+// consecutive offsets, starting at start, so dst[i] holds what
+// BitsAt(start+i) would return. It saves the overhead of a call per
+// value, and lets implementations work on several blocks at once.
 type Sequence interface {
 	rand.Source64
 	Seek(Uint128) Uint128
 	BitsAt(Uint128) Uint128
+	BitsSliceAt(start Uint128, dst []Uint128)
 }
 
 // aesSequence128 implements Sequence on top of an AES block cipher.
 type aesSequence128 struct {
-	key                   [16]byte
-	cipher                cipher.Block
-	plainText, cipherText [16]byte
-	offset                Uint128
-	err                   error
+	key    [16]byte
+	cipher cipher.Block
+	offset Uint128
+	err    error
 }
 
 // NewSequence generates a sequence initialized with the given seed.
@@ -52,10 +57,29 @@ func NewSequence(seed int64) Sequence {
 	return &s
 }
 
+// newAESSequence generates a sequence using the given AES key directly.
+func newAESSequence(key [16]byte) *aesSequence128 {
+	s := aesSequence128{offset: OffsetFor(SequenceRandSource, 0, 0, 0)}
+	s.setKey(key)
+	if s.err != nil {
+		panic("impossible error: " + s.err.Error())
+	}
+	return &s
+}
+
 // Seed sets the generator to a known state.
 func (s *aesSequence128) Seed(seed int64) {
 	var newKey [16]byte
 	binary.LittleEndian.PutUint64(newKey[:8], uint64(seed))
+	s.setKey(newKey)
+	if s.err != nil {
+		return
+	}
+	s.offset.Lo = 0
+}
+
+// setKey sets the AES key.
+func (s *aesSequence128) setKey(newKey [16]byte) {
 	newCipher, err := aes.NewCipher(newKey[:])
 	if err != nil {
 		// we can't return an error, because Seed() can't fail. also
@@ -65,7 +89,6 @@ func (s *aesSequence128) Seed(seed int64) {
 	}
 	copy(s.key[:], newKey[:])
 	s.cipher = newCipher
-	s.offset.Lo = 0
 }
 
 // Int63 returns a value in 0..(1<<63)-1.
@@ -105,6 +128,92 @@ const (
 	SequenceUser1
 	// SequenceUser2 is reserved for non-apophenia package usage.
 	SequenceUser2
+	// SequenceBenford is the random numbers for Benford's law digits.
+	SequenceBenford
+	// SequenceYuleSimon is the random numbers for the Yule-Simon
+	// distribution.
+	SequenceYuleSimon
+	// SequencePowerLaw is the random numbers for continuous power-law
+	// values.
+	SequencePowerLaw
+	// SequenceHash is the starting offsets for hashing keys.
+	SequenceHash
+	// SequenceDynamicWeighted is the random numbers for sampling from
+	// DynamicWeighted.
+	SequenceDynamicWeighted
+	// SequenceCouponCollector is the starting indexes for coupon
+	// collector trials.
+	SequenceCouponCollector
+	// SequencePoisson is the random numbers for the Poisson
+	// distribution.
+	SequencePoisson
+	// SequenceMarkov is the random numbers for Markov chain state
+	// transitions.
+	SequenceMarkov
+	// SequenceMMPP is the random numbers for the event counts of a
+	// Markov-modulated Poisson process.
+	SequenceMMPP
+	// SequenceHalton is the random numbers for scrambling Halton
+	// sequences.
+	SequenceHalton
+	// SequenceSobol is the random numbers for scrambling Sobol
+	// sequences.
+	SequenceSobol
+	// SequenceLatinHypercube is the random numbers for positions within
+	// Latin hypercube strata.
+	SequenceLatinHypercube
+	// SequenceBipartite is the random bits for edges of bipartite
+	// graphs.
+	SequenceBipartite
+	// SequenceRandomTree is the random numbers for Prüfer sequences of
+	// random trees.
+	SequenceRandomTree
+	// SequencePoissonProcess is the random numbers for arrival times of
+	// Poisson processes.
+	SequencePoissonProcess
+	// SequenceExponential is the random numbers for the exponential
+	// distribution.
+	SequenceExponential
+	// SequenceRenewal is the starting indexes for renewal processes.
+	SequenceRenewal
+	// SequenceStratified is the random numbers for stratified sampling.
+	SequenceStratified
+	// SequenceMixture is the random numbers for choosing components of
+	// mixture distributions.
+	SequenceMixture
+	// SequenceStreamKey is the keys for the streams of a MultiStream.
+	SequenceStreamKey
+	// SequenceBernoulli is the weighted bits for batches of Bernoulli
+	// decisions.
+	SequenceBernoulli
+	// SequenceRunLength is the uniforms for run-length segments.
+	SequenceRunLength
+	// SequencePoissonCluster is the sizes and centroids of Poisson
+	// clusters.
+	SequencePoissonCluster
+	// SequenceUint64 is the values for Uint64At.
+	SequenceUint64
+	// SequenceAvalanche is the inputs for avalanche testing.
+	SequenceAvalanche
+	// SequenceParetoFraction is the uniforms for ParetoFraction values.
+	SequenceParetoFraction
+	// SequenceBigPermutation is the keys and round functions for
+	// BigPermutation.
+	SequenceBigPermutation
+	// SequenceZipfTable is the uniforms for table-based Zipf values.
+	SequenceZipfTable
+	// SequenceBytesPermutation is the round functions for
+	// BytesPermutation.
+	SequenceBytesPermutation
+	// SequenceFixedPoints is the positions sampled by
+	// EstimateFixedPoints.
+	SequenceFixedPoints
+	// SequenceOrderStatistic is the gamma variates for order
+	// statistics of uniform values.
+	SequenceOrderStatistic
+	// SequenceGaussian is the normal variates for the discrete Gaussian
+	// distribution.
+	SequenceGaussian
 )
 
 // OffsetFor determines the Uint128 offset for a given class/seed/iteration/id.
@@ -113,6 +222,19 @@ func OffsetFor(class SequenceClass, seed uint32, iter uint32, id uint64) Uint128
 		Lo: id}
 }
 
+// Uint64At returns a value for the given index from s, for one-shot use
+// without building an offset. It's BitsAt(OffsetFor(SequenceUint64, 0, 0,
+// index)).Lo. (Sequence is an interface, and already has a Uint64
+// method from rand.Source64, so this can't be a method.)
+func Uint64At(s Sequence, index uint64) uint64 {
+	return s.BitsAt(OffsetFor(SequenceUint64, 0, 0, index)).Lo
+}
+
+// unitFloat64 converts the low-order 53 bits of x to a float64 in [0,1).
+func unitFloat64(x uint64) float64 {
+	return float64(x&(1<<53-1)) / (1 << 53)
+}
+
 // Seek seeks to the specified offset, yielding the previous offset. This
 // sets the stream to a specific point in its cycle, affecting future calls
 // to Int63 or Uint64.
@@ -122,10 +244,22 @@ func (s *aesSequence128) Seek(offset Uint128) (old Uint128) {
 }
 
 // BitsAt yields the sequence of bits at the provided offset into the stream.
+// It uses its own buffers, and cipher.Block's Encrypt is safe for
+// concurrent use, so BitsAt and BitsSliceAt can be called concurrently.
 func (s *aesSequence128) BitsAt(offset Uint128) (out Uint128) {
-	binary.LittleEndian.PutUint64(s.plainText[:8], offset.Lo)
-	binary.LittleEndian.PutUint64(s.plainText[8:], offset.Hi)
-	s.cipher.Encrypt(s.cipherText[:], s.plainText[:])
-	out.Lo, out.Hi = binary.LittleEndian.Uint64(s.cipherText[:8]), binary.LittleEndian.Uint64(s.cipherText[8:])
+	// Encrypt in place, so there's only the one buffer.
+	var block [16]byte
+	binary.LittleEndian.PutUint64(block[:8], offset.Lo)
+	binary.LittleEndian.PutUint64(block[8:], offset.Hi)
+	s.cipher.Encrypt(block[:], block[:])
+	out.Lo, out.Hi = binary.LittleEndian.Uint64(block[:8]), binary.LittleEndian.Uint64(block[8:])
 	return out
 }
+
+// BitsSliceAt fills dst with the bits at consecutive offsets from start.
+func (s *aesSequence128) BitsSliceAt(start Uint128, dst []Uint128) {
+	for i := range dst {
+		dst[i] = s.BitsAt(start)
+		start.Inc()
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+)
+
+// TestSequenceNoCycle uses Floyd's cycle detection to check that the
+// outputs of BitsAt, fed back in as offsets, don't fall into a short
+// cycle.
+func TestSequenceNoCycle(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping cycle detection in short mode")
+	}
+	const steps = 1 << 20
+	src := NewSequence(0)
+	tortoise := src.BitsAt(Uint128{})
+	hare := src.BitsAt(tortoise)
+	for i := 1; i <= steps; i++ {
+		if tortoise == hare {
+			t.Fatalf("iterated BitsAt entered a cycle after %d steps (%s)", i, tortoise)
+		}
+		tortoise = src.BitsAt(tortoise)
+		hare = src.BitsAt(src.BitsAt(hare))
+	}
+}
+
+func TestBitsAtConcurrent(t *testing.T) {
+	src := NewSequence(5)
+	expected := make([]Uint128, 256)
+	src.BitsSliceAt(Uint128{}, expected)
+	var wg sync.WaitGroup
+	errs := make(chan string, 8)
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			got := make([]Uint128, 16)
+			for i := 0; i < 1000; i++ {
+				n := uint64(i*(g+1)) % 240
+				if v := src.BitsAt(Uint128{Lo: n}); v != expected[n] {
+					errs <- "BitsAt gave a wrong value for " + Uint128{Lo: n}.String()
+					return
+				}
+				src.BitsSliceAt(Uint128{Lo: n}, got)
+				if got[15] != expected[n+15] {
+					errs <- "BitsSliceAt gave a wrong value from " + Uint128{Lo: n}.String()
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for e := range errs {
+		t.Error(e)
+	}
+}
+
+func TestUint64At(t *testing.T) {
+	src := NewSequence(3)
+	seen := make(map[uint64]bool)
+	for i := uint64(0); i < 1000; i++ {
+		got := Uint64At(src, i)
+		if exp := src.BitsAt(OffsetFor(SequenceUint64, 0, 0, i)).Lo; got != exp {
+			t.Fatalf("index %d: expected %#x, got %#x", i, exp, got)
+		}
+		if seen[got] {
+			t.Fatalf("index %d: repeated value %#x", i, got)
+		}
+		seen[got] = true
+	}
+}
+
+func TestBitsSliceAt(t *testing.T) {
+	hmacSeq, err := NewHMACSHA256Sequence([]byte("key"))
+	if err != nil {
+		t.Fatalf("creating HMAC sequence: %v", err)
+	}
+	logged, err := NewLoggingSequence(NewSequence(4), io.Discard)
+	if err != nil {
+		t.Fatalf("creating logging sequence: %v", err)
+	}
+	sequences := map[string]Sequence{
+		"AES":     NewSequence(1),
+		"HMAC":    hmacSeq,
+		"XOR":     NewXORSequence(NewSequence(2), NewSequence(3)),
+		"logging": logged,
+	}
+	// start just short of a carry into the high word.
+	start := Uint128{Lo: ^uint64(0) - 2, Hi: 7}
+	for name, s := range sequences {
+		for _, n := range []int{0, 1, 5, 100} {
+			dst := make([]Uint128, n)
+			s.BitsSliceAt(start, dst)
+			off := start
+			for i, got := range dst {
+				if exp := s.BitsAt(off); got != exp {
+					t.Fatalf("%s: entry %d of %d: expected %s, got %s", name, i, n, exp, got)
+				}
+				off.Inc()
+			}
+		}
+	}
+	// a ReaderSequence just reads sequentially either way.
+	data := make([]byte, 64)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	r, _ := NewReaderSequence(bytes.NewReader(data))
+	dst := make([]Uint128, 3)
+	r.BitsSliceAt(start, dst)
+	if exp := (Uint128{Lo: 0x0706050403020100, Hi: 0x0f0e0d0c0b0a0908}); dst[0] != exp {
+		t.Fatalf("reader: expected %s, got %s", exp, dst[0])
+	}
+	if got, exp := r.BitsAt(start), (Uint128{Lo: 0x3736353433323130, Hi: 0x3f3e3d3c3b3a3938}); got != exp {
+		t.Fatalf("reader: BitsAt after BitsSliceAt: expected %s, got %s", exp, got)
+	}
+}
+
+func BenchmarkBitsSliceAt(b *testing.B) {
+	s := NewSequence(0)
+	dst := make([]Uint128, 1024)
+	b.Run("BitsAt", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			off := Uint128{Hi: uint64(i)}
+			for j := range dst {
+				dst[j] = s.BitsAt(off)
+				off.Inc()
+			}
+		}
+	})
+	b.Run("BitsSliceAt", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			s.BitsSliceAt(Uint128{Hi: uint64(i)}, dst)
+		}
+	})
+}
@@ -0,0 +1,46 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import "sync/atomic"
+
+// AtomicZipf is a Zipf whose Next can be called from multiple goroutines
+// at once. Each call atomically claims the next index, so every index is
+// used exactly once, though which goroutine gets which index depends on
+// scheduling. Nothing takes a lock: claiming an index is atomic, and
+// computing its value only uses the Sequence's BitsAt, which is safe for
+// concurrent use for Sequences from NewSequence. Other Sequences need to
+// be similarly safe.
+type AtomicZipf struct {
+	idx  uint64 // first, so it's 64-bit aligned for sync/atomic
+	zipf Zipf
+}
+
+// NewAtomicZipf returns an AtomicZipf producing the same values as z,
+// starting from the index z's Next would use. It doesn't modify z, and
+// shares z's Sequence.
+func NewAtomicZipf(z *Zipf) *AtomicZipf {
+	return &AtomicZipf{idx: z.idx, zipf: *z}
+}
+
+// Next returns the value for the next unclaimed index.
+func (a *AtomicZipf) Next() uint64 {
+	return a.zipf.NthStateless(atomic.AddUint64(&a.idx, 1) - 1)
+}
+
+// Nth returns the value for the given index, without affecting Next.
+func (a *AtomicZipf) Nth(index uint64) uint64 {
+	return a.zipf.NthStateless(index)
+}
@@ -0,0 +1,64 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"sync"
+	"testing"
+)
+
+func Test_AtomicZipf(t *testing.T) {
+	const workers, perWorker = 8, 2000
+	z, err := NewZipf(1.2, 2, 1000, 3, NewSequence(0))
+	if err != nil {
+		t.Fatalf("creating Zipf: %v", err)
+	}
+	// start partway through, to check that z's position carries over.
+	z.Nth(99)
+	a := NewAtomicZipf(z)
+	// the workers share z's Sequence with no lock, which go test -race
+	// checks.
+	results := make([][]uint64, workers)
+	var wg sync.WaitGroup
+	for w := range results {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				results[w] = append(results[w], a.Next())
+			}
+		}(w)
+	}
+	wg.Wait()
+	// Values repeat, but if each index was claimed exactly once, the
+	// values produced are exactly those for indexes 100 through 100+n-1.
+	counts := make(map[uint64]int)
+	for _, r := range results {
+		for _, v := range r {
+			counts[v]++
+		}
+	}
+	for i := uint64(100); i < 100+workers*perWorker; i++ {
+		counts[z.Nth(i)]--
+	}
+	for v, c := range counts {
+		if c != 0 {
+			t.Errorf("value %d: count off by %d from sequential results", v, c)
+		}
+	}
+	if got, exp := a.Next(), z.Nth(100+workers*perWorker); got != exp {
+		t.Errorf("next value after concurrent use: expected %d, got %d", exp, got)
+	}
+}
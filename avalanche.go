@@ -0,0 +1,53 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import "math/bits"
+
+// AvalancheScore measures how well p mixes its inputs, by the avalanche
+// criterion: flipping one bit of a position should flip each bit of the
+// resulting value with probability 1/2. For each of trials pseudo-random
+// positions, it flips each bit of the position in turn, and measures the
+// fraction of the value's bits which change, counting only the bits
+// needed to represent max-1. Flips which would produce a position of max
+// or more are skipped. The result is the average fraction; a score near
+// 0.5 indicates good mixing, and lower scores indicate that nearby
+// positions produce related values.
+//
+// The positions are derived from p's seed, so the score is repeatable.
+// If max is 1, or trials is less than 1, the score is 0.
+func AvalancheScore(p *Permutation, trials int) float64 {
+	width := bits.Len64(uint64(p.max - 1))
+	if width == 0 || trials < 1 {
+		return 0
+	}
+	var flipped, total uint64
+	for i := 0; i < trials; i++ {
+		in, _ := bits.Mul64(p.bitsAt(OffsetFor(SequenceAvalanche, p.permSeed, 0, uint64(i))).Lo, uint64(p.max))
+		out := p.permute(int64(in))
+		for b := 0; b < width; b++ {
+			in2 := in ^ 1<<b
+			if in2 >= uint64(p.max) {
+				continue
+			}
+			flipped += uint64(bits.OnesCount64(uint64(out ^ p.permute(int64(in2)))))
+			total += uint64(width)
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(flipped) / float64(total)
+}
@@ -0,0 +1,42 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import "testing"
+
+func Test_AvalancheScore(t *testing.T) {
+	src := NewSequence(0)
+	for _, max := range []int64{1 << 10, 1000000, 1 << 40} {
+		p, err := NewPermutation(max, 1, src)
+		if err != nil {
+			t.Fatalf("creating permutation: %v", err)
+		}
+		if score := AvalancheScore(p, 500); score <= 0.45 || score > 0.55 {
+			t.Errorf("max %d: avalanche score %.3f, expected about 0.5", max, score)
+		}
+		// a single round mixes very little.
+		weak, err := NewPermutationWithRoundFunc(max, 1, 1, src, nil)
+		if err != nil {
+			t.Fatalf("creating one-round permutation: %v", err)
+		}
+		if score := AvalancheScore(weak, 500); score > 0.45 {
+			t.Errorf("max %d: one-round avalanche score %.3f, expected much less than 0.5", max, score)
+		}
+	}
+	p, _ := NewPermutation(1, 0, src)
+	if score := AvalancheScore(p, 10); score != 0 {
+		t.Errorf("max 1: expected score 0, got %g", score)
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import "math"
+
+// Benford produces leading digits following Benford's law, under which
+// the probability of a leading digit d is log10(1 + 1/d). About 30% of
+// values are 1, and under 5% are 9. Many naturally occurring data sets,
+// such as populations, prices, or file sizes, have leading digits
+// distributed this way.
+type Benford struct {
+	src   Sequence
+	seed  uint32
+	table *aliasTable
+}
+
+// benfordTable is shared by all Benford instances, since the
+// probabilities never change.
+var benfordTable = func() *aliasTable {
+	weights := make([]float64, 9)
+	for d := range weights {
+		weights[d] = math.Log10(1 + 1/float64(d+1))
+	}
+	return newAliasTable(weights)
+}()
+
+// NewBenford returns a Benford which yields digits based on the given
+// seed and Sequence.
+func NewBenford(seed uint32, src Sequence) *Benford {
+	return &Benford{src: src, seed: seed, table: benfordTable}
+}
+
+// Nth returns the digit, in [1,9], for the given index.
+func (b *Benford) Nth(index uint64) int {
+	return b.table.sample(b.src.BitsAt(OffsetFor(SequenceBenford, b.seed, 0, index))) + 1
+}
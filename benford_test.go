@@ -0,0 +1,58 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_Benford(t *testing.T) {
+	const samples = 10000
+	// chi-squared critical value for 8 degrees of freedom, p = 0.001
+	const critical = 26.12
+	b := NewBenford(0, NewSequence(0))
+	counts := make([]int, 10)
+	for i := uint64(0); i < samples; i++ {
+		d := b.Nth(i)
+		if d < 1 || d > 9 {
+			t.Fatalf("index %d: digit %d out of range", i, d)
+		}
+		counts[d]++
+	}
+	chi2 := 0.0
+	for d := 1; d <= 9; d++ {
+		exp := math.Log10(1+1/float64(d)) * samples
+		diff := float64(counts[d]) - exp
+		chi2 += diff * diff / exp
+	}
+	if chi2 > critical {
+		t.Errorf("chi-squared %.2f exceeds %.2f, counts %v", chi2, critical, counts[1:])
+	}
+	if frac := float64(counts[1]) / samples; math.Abs(frac-0.301) > 0.02 {
+		t.Errorf("digit 1 appeared %.1f%% of the time, expected about 30%%", frac*100)
+	}
+	// different seeds should give different digits
+	b2 := NewBenford(1, NewSequence(0))
+	same := 0
+	for i := uint64(0); i < 1000; i++ {
+		if b.Nth(i) == b2.Nth(i) {
+			same++
+		}
+	}
+	if same > 400 {
+		t.Errorf("seeds 0 and 1 agreed on %d of 1000 digits", same)
+	}
+}
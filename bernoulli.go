@@ -0,0 +1,63 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import "math"
+
+// bernoulliScale is the scale used for Bernoulli densities; p is rounded
+// to the nearest multiple of 1/2^32.
+const bernoulliScale = 1 << 32
+
+// BernoulliBatch returns n independent decisions, each true with
+// probability p, for the indexes starting at startIndex. The decision for
+// a given seed and index is the same regardless of how it's batched.
+//
+// Decisions are the bits of a Weighted, which computes 128 of them at a
+// time from 32 values of src, rather than using a value of src for
+// each. The probability is rounded to a multiple of 1/2^32, and clamped
+// to [0,1]; NaN is treated as 0.
+func BernoulliBatch(p float64, seed uint32, startIndex uint64, n int, src Sequence) []bool {
+	dst := make([]bool, n)
+	BernoulliBatchInto(p, seed, startIndex, dst, src)
+	return dst
+}
+
+// BernoulliBatchInto is BernoulliBatch, writing len(dst) decisions into
+// dst rather than allocating a new slice.
+func BernoulliBatchInto(p float64, seed uint32, startIndex uint64, dst []bool, src Sequence) {
+	density := bernoulliDensity(p)
+	w := Weighted{src: src}
+	index := startIndex
+	for len(dst) > 0 {
+		batch := w.Bits(OffsetFor(SequenceBernoulli, seed, 0, index), density, bernoulliScale)
+		for bit := index & 127; bit < 128 && len(dst) > 0; bit++ {
+			dst[0] = batch.Bit(bit) != 0
+			dst = dst[1:]
+			index++
+		}
+	}
+}
+
+// bernoulliDensity converts p to a density for Weighted, with a scale of
+// bernoulliScale.
+func bernoulliDensity(p float64) uint64 {
+	switch {
+	case p >= 1:
+		return bernoulliScale
+	case p > 0:
+		return uint64(math.Round(p * bernoulliScale))
+	}
+	return 0
+}
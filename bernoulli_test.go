@@ -0,0 +1,86 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_BernoulliBatch(t *testing.T) {
+	src := NewSequence(0)
+	const n = 1000000
+	for _, p := range []float64{0.001, 0.1, 0.5, 0.75} {
+		out := BernoulliBatch(p, 0, 0, n, src)
+		trues := 0
+		for _, b := range out {
+			if b {
+				trues++
+			}
+		}
+		// 3 sigma, per the request; these are fixed outputs, so this
+		// won't flake.
+		sigma := math.Sqrt(n * p * (1 - p))
+		if math.Abs(float64(trues)-n*p) > 3*sigma {
+			t.Errorf("p %g: expected about %.0f trues, got %d", p, n*p, trues)
+		}
+	}
+	for _, p := range []float64{0, -1, math.NaN()} {
+		for i, b := range BernoulliBatch(p, 0, 0, 1000, src) {
+			if b {
+				t.Fatalf("p %g: decision %d is true", p, i)
+			}
+		}
+	}
+	for i, b := range BernoulliBatch(1, 0, 0, 1000, src) {
+		if !b {
+			t.Fatalf("p 1: decision %d is false", i)
+		}
+	}
+	// decisions don't depend on batching.
+	all := BernoulliBatch(0.3, 5, 1000, 500, src)
+	dst := make([]bool, 37)
+	for start := 0; start < len(all); start += len(dst) {
+		part := dst
+		if len(all)-start < len(part) {
+			part = part[:len(all)-start]
+		}
+		BernoulliBatchInto(0.3, 5, uint64(1000+start), part, src)
+		for i, b := range part {
+			if b != all[start+i] {
+				t.Fatalf("decision %d differs when batched differently", 1000+start+i)
+			}
+		}
+	}
+}
+
+func BenchmarkBernoulli(b *testing.B) {
+	const n = 4096
+	src := NewSequence(0)
+	dst := make([]bool, n)
+	b.Run("Batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			BernoulliBatchInto(0.3, 0, uint64(i)*n, dst, src)
+		}
+	})
+	b.Run("Individual", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for j := range dst {
+				u := unitFloat64(src.BitsAt(OffsetFor(SequenceBernoulli, 0, 0, uint64(i)*n+uint64(j))).Lo)
+				dst[j] = u < 0.3
+			}
+		}
+	})
+}
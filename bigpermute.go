@@ -0,0 +1,121 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"errors"
+	"math/big"
+)
+
+// bigPermutationIterK is the first iteration used for round keys; round
+// functions use iterations from 0, one per 128 rounds.
+const bigPermutationIterK = 1 << 23
+
+// BigPermutation is a Permutation over [0,modulus) for an arbitrary
+// positive modulus, not just one which fits in an int64, for uses such
+// as format-preserving encryption of large numbers. It uses the same
+// swap-or-not shuffle as Permutation, with 6 log2(modulus) rounds; each
+// round's key is a uniform value in [0,modulus), and the round function
+// for a value is one bit of the Sequence's bits at an offset derived
+// from a hash of the value.
+//
+// It's much slower than Permutation, because it uses math/big and hashes
+// a value each round.
+type BigPermutation struct {
+	src     Sequence
+	seed    uint32
+	modulus *big.Int
+	k       []*big.Int
+	width   int // bytes needed for values
+}
+
+// NewPermutationModN creates a BigPermutation of [0,modulus). The modulus
+// must be positive; it's copied, so the caller can reuse it.
+func NewPermutationModN(modulus *big.Int, seed uint32, src Sequence) (*BigPermutation, error) {
+	if modulus == nil || modulus.Sign() <= 0 {
+		return nil, errors.New("BigPermutation modulus must be positive")
+	}
+	if src == nil {
+		return nil, errors.New("need a usable PRNG apophenia.Sequence")
+	}
+	bitLen := modulus.BitLen()
+	p := &BigPermutation{
+		src:     src,
+		seed:    seed,
+		modulus: new(big.Int).Set(modulus),
+		k:       make([]*big.Int, 6*bitLen),
+		width:   (bitLen + 7) / 8,
+	}
+	// Keys are bitLen-bit values, rejected if they're not under the
+	// modulus, which happens less than half the time.
+	buf := make([]byte, (bitLen+127)/128*16)
+	for r := range p.k {
+		for attempt := uint32(0); ; attempt++ {
+			for chunk := 0; chunk < len(buf)/16; chunk++ {
+				bits := src.BitsAt(OffsetFor(SequenceBigPermutation, seed, bigPermutationIterK+attempt, uint64(r)<<16|uint64(chunk)))
+				putUint128BE(buf[chunk*16:], bits)
+			}
+			k := new(big.Int).SetBytes(buf)
+			k.Rsh(k, uint(len(buf)*8-bitLen))
+			if k.Cmp(p.modulus) < 0 {
+				p.k[r] = k
+				break
+			}
+		}
+	}
+	return p, nil
+}
+
+// putUint128BE writes u to the first 16 bytes of b, big-endian.
+func putUint128BE(b []byte, u Uint128) {
+	for i := 0; i < 8; i++ {
+		b[i] = byte(u.Hi >> (56 - 8*i))
+		b[8+i] = byte(u.Lo >> (56 - 8*i))
+	}
+}
+
+// Modulus returns a copy of the modulus.
+func (p *BigPermutation) Modulus() *big.Int {
+	return new(big.Int).Set(p.modulus)
+}
+
+// Nth returns the value at position n, which must be in [0,modulus), as
+// a new big.Int. It panics if n is out of range.
+func (p *BigPermutation) Nth(n *big.Int) *big.Int {
+	if n.Sign() < 0 || n.Cmp(p.modulus) >= 0 {
+		panic("BigPermutation position " + n.String() + " out of range")
+	}
+	x := new(big.Int).Set(n)
+	xPrime := new(big.Int)
+	key := make([]byte, p.width)
+	for r, k := range p.k {
+		// xPrime = (k - x) mod modulus
+		xPrime.Sub(k, x)
+		if xPrime.Sign() < 0 {
+			xPrime.Add(xPrime, p.modulus)
+		}
+		xCaret := x
+		if xPrime.Cmp(x) > 0 {
+			xCaret = xPrime
+		}
+		xCaret.FillBytes(key)
+		h := Hash64(key, p.seed, p.src)
+		bits := p.src.BitsAt(OffsetFor(SequenceBigPermutation, p.seed, uint32(r>>7), h))
+		if bits.Bit(uint64(r&127)) != 0 {
+			x, xPrime = xPrime, x
+		}
+	}
+	return x
+}
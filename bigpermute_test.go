@@ -0,0 +1,79 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math/big"
+	"testing"
+)
+
+func Test_BigPermutation(t *testing.T) {
+	src := NewSequence(0)
+	p, err := NewPermutationModN(big.NewInt(7), 3, src)
+	if err != nil {
+		t.Fatalf("creating permutation: %v", err)
+	}
+	seen := make(map[int64]bool)
+	moved := false
+	for i := int64(0); i < 7; i++ {
+		v := p.Nth(big.NewInt(i))
+		if v.Sign() < 0 || v.Cmp(big.NewInt(7)) >= 0 {
+			t.Fatalf("position %d: value %s out of range", i, v)
+		}
+		if seen[v.Int64()] {
+			t.Fatalf("position %d: value %s repeated", i, v)
+		}
+		seen[v.Int64()] = true
+		if v.Int64() != i {
+			moved = true
+		}
+	}
+	if !moved {
+		t.Errorf("modulus 7: every value in place")
+	}
+
+	modulus, _ := new(big.Int).SetString("1234567890123456789012345678901234567890123456789012345678901234567890123456789012345678901234567891", 10)
+	if len(modulus.String()) != 100 {
+		t.Fatalf("expected a 100-digit modulus, got %d digits", len(modulus.String()))
+	}
+	p, err = NewPermutationModN(modulus, 3, src)
+	if err != nil {
+		t.Fatalf("creating permutation: %v", err)
+	}
+	again, _ := NewPermutationModN(modulus, 3, src)
+	values := make(map[string]bool)
+	n := new(big.Int).Sub(modulus, big.NewInt(50))
+	for i := 0; i < 100; i++ {
+		v := p.Nth(n)
+		if v.Sign() < 0 || v.Cmp(modulus) >= 0 {
+			t.Fatalf("position %s: value %s out of range", n, v)
+		}
+		if values[v.String()] {
+			t.Fatalf("position %s: value %s repeated", n, v)
+		}
+		values[v.String()] = true
+		if w := again.Nth(n); w.Cmp(v) != 0 {
+			t.Fatalf("position %s: same seed gave %s and %s", n, v, w)
+		}
+		// walk from the top of the range around to the bottom.
+		n.Add(n, big.NewInt(1))
+		n.Mod(n, modulus)
+	}
+	for _, bad := range []*big.Int{nil, big.NewInt(0), big.NewInt(-5)} {
+		if _, err := NewPermutationModN(bad, 0, src); err == nil {
+			t.Errorf("modulus %v: expected error", bad)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// bipartiteScale is the scale used for edge densities; edge
+// probabilities are rounded to the nearest multiple of 1/2^32.
+const bipartiteScale = 1 << 32
+
+// BipartiteGraph is a random bipartite graph, with left nodes
+// [0,leftN) and right nodes [0,rightN), in which each possible edge
+// between a left node and a right node is present with probability
+// edgeProb, independently of the others. No edges are stored; each edge
+// is computed from its own bit of a Weighted, so the graph takes no
+// memory beyond its parameters.
+//
+// Edge (l, r) uses the bit at offset l*rightN + r, so the edges of a
+// left node are consecutive bits and LeftNeighbors is cheap, while
+// RightNeighbors has to compute a separate batch of bits for each left
+// node.
+type BipartiteGraph struct {
+	w             *Weighted
+	seed          uint32
+	leftN, rightN int64
+	density       uint64
+}
+
+// NewBipartiteGraph returns a BipartiteGraph with the given sizes and
+// edge probability.
+func NewBipartiteGraph(leftN, rightN int64, edgeProb float64, seed uint32, src Sequence) (*BipartiteGraph, error) {
+	if leftN < 1 || rightN < 1 {
+		return nil, fmt.Errorf("need at least one node on each side, got %d left and %d right", leftN, rightN)
+	}
+	if hi, _ := bits.Mul64(uint64(leftN), uint64(rightN)); hi != 0 {
+		return nil, fmt.Errorf("too many possible edges for %d left and %d right nodes", leftN, rightN)
+	}
+	if !(edgeProb >= 0 && edgeProb <= 1) {
+		return nil, fmt.Errorf("edge probability must be in [0,1], got %g", edgeProb)
+	}
+	w, err := NewWeighted(src)
+	if err != nil {
+		return nil, err
+	}
+	return &BipartiteGraph{
+		w:       w,
+		seed:    seed,
+		leftN:   leftN,
+		rightN:  rightN,
+		density: uint64(math.Round(edgeProb * bipartiteScale)),
+	}, nil
+}
+
+// HasEdge reports whether there's an edge between left node l and right
+// node r.
+func (g *BipartiteGraph) HasEdge(l, r int64) bool {
+	if l < 0 || l >= g.leftN || r < 0 || r >= g.rightN {
+		return false
+	}
+	offset := OffsetFor(SequenceBipartite, g.seed, 0, uint64(l)*uint64(g.rightN)+uint64(r))
+	return g.w.Bit(offset, g.density, bipartiteScale) != 0
+}
+
+// LeftNeighbors returns the right nodes connected to left node v, in
+// order.
+func (g *BipartiteGraph) LeftNeighbors(v int64) []int64 {
+	var out []int64
+	if v < 0 || v >= g.leftN {
+		return out
+	}
+	for r := int64(0); r < g.rightN; r++ {
+		if g.HasEdge(v, r) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// RightNeighbors returns the left nodes connected to right node v, in
+// order.
+func (g *BipartiteGraph) RightNeighbors(v int64) []int64 {
+	var out []int64
+	if v < 0 || v >= g.rightN {
+		return out
+	}
+	for l := int64(0); l < g.leftN; l++ {
+		if g.HasEdge(l, v) {
+			out = append(out, l)
+		}
+	}
+	return out
+}
@@ -0,0 +1,98 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_BipartiteGraph(t *testing.T) {
+	const leftN, rightN, p = 200, 300, 0.05
+	g, err := NewBipartiteGraph(leftN, rightN, p, 0, NewSequence(0))
+	if err != nil {
+		t.Fatalf("creating graph: %v", err)
+	}
+	edges := 0
+	fromLeft := make(map[[2]int64]bool)
+	for l := int64(0); l < leftN; l++ {
+		for _, r := range g.LeftNeighbors(l) {
+			if r < 0 || r >= rightN {
+				t.Fatalf("left node %d has neighbor %d, not a right node", l, r)
+			}
+			fromLeft[[2]int64{l, r}] = true
+			edges++
+		}
+	}
+	for r := int64(0); r < rightN; r++ {
+		for _, l := range g.RightNeighbors(r) {
+			if l < 0 || l >= leftN {
+				t.Fatalf("right node %d has neighbor %d, not a left node", r, l)
+			}
+			if !fromLeft[[2]int64{l, r}] {
+				t.Fatalf("edge %d-%d seen from right node but not left node", l, r)
+			}
+			delete(fromLeft, [2]int64{l, r})
+		}
+	}
+	if len(fromLeft) != 0 {
+		t.Fatalf("%d edges seen from left nodes but not right nodes", len(fromLeft))
+	}
+	n := float64(leftN * rightN)
+	mean, sd := n*p, math.Sqrt(n*p*(1-p))
+	if math.Abs(float64(edges)-mean) > 5*sd {
+		t.Errorf("expected about %.0f edges, got %d", mean, edges)
+	}
+	// same seed, same graph; different seed, different graph.
+	same, _ := NewBipartiteGraph(leftN, rightN, p, 0, NewSequence(0))
+	other, _ := NewBipartiteGraph(leftN, rightN, p, 1, NewSequence(0))
+	differ := 0
+	for l := int64(0); l < leftN; l++ {
+		for r := int64(0); r < rightN; r++ {
+			if same.HasEdge(l, r) != g.HasEdge(l, r) {
+				t.Fatalf("edge %d-%d differs for the same seed", l, r)
+			}
+			if other.HasEdge(l, r) != g.HasEdge(l, r) {
+				differ++
+			}
+		}
+	}
+	if differ == 0 {
+		t.Errorf("seeds 0 and 1 gave the same graph")
+	}
+}
+
+func Test_BipartiteGraphInvalid(t *testing.T) {
+	src := NewSequence(0)
+	cases := []struct {
+		leftN, rightN int64
+		p             float64
+	}{
+		{0, 1, 0.5}, {1, 0, 0.5}, {1, 1, -0.1}, {1, 1, 1.1}, {1, 1, math.NaN()},
+		{1 << 40, 1 << 40, 0.5},
+	}
+	for _, c := range cases {
+		if _, err := NewBipartiteGraph(c.leftN, c.rightN, c.p, 0, src); err == nil {
+			t.Errorf("%d x %d, p %g: expected error", c.leftN, c.rightN, c.p)
+		}
+	}
+	if _, err := NewBipartiteGraph(1, 1, 0.5, 0, nil); err == nil {
+		t.Errorf("expected error for nil Sequence")
+	}
+	full, _ := NewBipartiteGraph(3, 4, 1, 0, src)
+	if n := len(full.LeftNeighbors(2)); n != 4 {
+		t.Errorf("complete graph: expected 4 neighbors, got %d", n)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// BitVecGen generates bitvectors of a fixed length with exactly a given
+// number of bits set, such as rows of a sparse bitmap. Each index yields
+// a different placement of the set bits, chosen by a Permutation of the
+// bit positions; the set bits are the first popcount values of the
+// permutation for that index.
+type BitVecGen struct {
+	src      Sequence
+	seed     uint32
+	length   uint64
+	popcount uint64
+}
+
+// NewBitVecGen creates a BitVecGen producing bitvectors of length bits,
+// with popcount of them set. The length must be at least 1 and fit in an
+// int64, and popcount can't exceed it.
+func NewBitVecGen(length, popcount uint64, seed uint32, src Sequence) (*BitVecGen, error) {
+	if length < 1 || length > math.MaxInt64 {
+		return nil, fmt.Errorf("need bitvector length in [1,%d], got %d", uint64(math.MaxInt64), length)
+	}
+	if popcount > length {
+		return nil, fmt.Errorf("popcount %d exceeds bitvector length %d", popcount, length)
+	}
+	if src == nil {
+		return nil, errors.New("need a usable PRNG apophenia.Sequence")
+	}
+	return &BitVecGen{src: src, seed: seed, length: length, popcount: popcount}, nil
+}
+
+// Nth returns the bitvector for the given index, as (length+63)/64 words,
+// with bit i of the vector in bit i%64 of word i/64. Bits past length in
+// the last word are always clear.
+//
+// The cost is proportional to the smaller of popcount and
+// length-popcount, times the number of rounds of a Permutation over
+// length values, plus the cost of creating a Permutation for the index.
+func (b *BitVecGen) Nth(index uint64) []uint64 {
+	words := make([]uint64, (b.length+63)/64)
+	// Every (seed, index) pair gets a distinct shuffle.
+	perm, err := NewPermutationU128(int64(b.length), Uint128{Lo: index, Hi: uint64(b.seed)}, b.src)
+	if err != nil {
+		panic("impossible error: " + err.Error())
+	}
+	count, invert := b.popcount, false
+	// For dense vectors, it's cheaper to pick the bits to clear.
+	if count > b.length/2 {
+		count, invert = b.length-count, true
+		for i := range words {
+			words[i] = ^uint64(0)
+		}
+		if tail := b.length % 64; tail != 0 {
+			words[len(words)-1] = 1<<tail - 1
+		}
+	}
+	for i := uint64(0); i < count; i++ {
+		bit := uint64(perm.Next())
+		if invert {
+			words[bit/64] &^= 1 << (bit % 64)
+		} else {
+			words[bit/64] |= 1 << (bit % 64)
+		}
+	}
+	return words
+}
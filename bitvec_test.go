@@ -0,0 +1,66 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"fmt"
+	"math/bits"
+	"testing"
+)
+
+func Test_BitVecGen(t *testing.T) {
+	src := NewSequence(0)
+	cases := []struct{ length, popcount uint64 }{
+		{1, 0}, {1, 1}, {64, 10}, {100, 0}, {100, 37}, {100, 80}, {100, 100}, {5000, 250},
+	}
+	for _, c := range cases {
+		g, err := NewBitVecGen(c.length, c.popcount, 7, src)
+		if err != nil {
+			t.Fatalf("creating generator (%d/%d): %v", c.popcount, c.length, err)
+		}
+		seen := make(map[string]bool)
+		for index := uint64(0); index < 20; index++ {
+			words := g.Nth(index)
+			if uint64(len(words)) != (c.length+63)/64 {
+				t.Fatalf("%d/%d: expected %d words, got %d", c.popcount, c.length, (c.length+63)/64, len(words))
+			}
+			total := 0
+			for _, w := range words {
+				total += bits.OnesCount64(w)
+			}
+			if uint64(total) != c.popcount {
+				t.Fatalf("%d/%d, index %d: got %d bits set", c.popcount, c.length, index, total)
+			}
+			if tail := c.length % 64; tail != 0 && words[len(words)-1]>>tail != 0 {
+				t.Fatalf("%d/%d, index %d: bits set past length", c.popcount, c.length, index)
+			}
+			seen[fmt.Sprint(words)] = true
+		}
+		// with a nontrivial number of possible placements, different
+		// indexes should give different placements.
+		if c.popcount > 1 && c.length-c.popcount > 1 && len(seen) != 20 {
+			t.Errorf("%d/%d: only %d distinct placements for 20 indexes", c.popcount, c.length, len(seen))
+		}
+	}
+	if _, err := NewBitVecGen(0, 0, 0, src); err == nil {
+		t.Errorf("expected error for zero length")
+	}
+	if _, err := NewBitVecGen(10, 11, 0, src); err == nil {
+		t.Errorf("expected error for popcount over length")
+	}
+	if _, err := NewBitVecGen(10, 1, 0, nil); err == nil {
+		t.Errorf("expected error for nil source")
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// bytesPermutationRounds is the number of Feistel rounds a
+// BytesPermutation uses, the same as FF1's.
+const bytesPermutationRounds = 10
+
+// BytesPermutation is a format-preserving permutation of the strings of a
+// given length over an alphabet, for obfuscating IDs without changing
+// what they look like. It maps each of the len(alphabet)^length possible
+// strings to a distinct one, using a Feistel network in the style of
+// FF1: the string is split into two halves, treated as numbers in base
+// len(alphabet), and each round adds a pseudo-random function of one half
+// to the other, modulo the size of its half.
+//
+// The halves must fit in a uint64, so length can be at most about twice
+// 64/log2(len(alphabet)); for instance, 38 for decimal digits. Very short
+// strings don't get much mixing, since a half of one character doesn't
+// have much to work with.
+type BytesPermutation struct {
+	src        Sequence
+	seed       uint32
+	alphabet   []byte
+	digits     [256]int16 // digit for each byte, or -1
+	length     int
+	u, v       int    // digits in the left and right halves
+	modU, modV uint64 // len(alphabet)^u, len(alphabet)^v
+}
+
+// NewBytesPermutation creates a BytesPermutation of strings of the given
+// length over alphabet, whose bytes must all be distinct. The alphabet is
+// copied, so the caller can reuse it.
+func NewBytesPermutation(alphabet []byte, length int, seed uint32, src Sequence) (*BytesPermutation, error) {
+	if src == nil {
+		return nil, errors.New("need a usable PRNG apophenia.Sequence")
+	}
+	if len(alphabet) < 2 {
+		return nil, fmt.Errorf("BytesPermutation alphabet needs at least 2 bytes, got %d", len(alphabet))
+	}
+	if length < 1 {
+		return nil, fmt.Errorf("BytesPermutation length must be at least 1, got %d", length)
+	}
+	p := &BytesPermutation{src: src, seed: seed, alphabet: append([]byte(nil), alphabet...), length: length}
+	for i := range p.digits {
+		p.digits[i] = -1
+	}
+	for i, b := range alphabet {
+		if p.digits[b] >= 0 {
+			return nil, fmt.Errorf("BytesPermutation alphabet has %q more than once", b)
+		}
+		p.digits[b] = int16(i)
+	}
+	p.u = length / 2
+	p.v = length - p.u
+	radix := uint64(len(alphabet))
+	p.modU, p.modV = 1, 1
+	for i := 0; i < p.v; i++ {
+		hi, lo := bits.Mul64(p.modV, radix)
+		if hi != 0 {
+			return nil, fmt.Errorf("BytesPermutation length %d too long for %d-byte alphabet", length, len(alphabet))
+		}
+		p.modV = lo
+		if i < p.u {
+			p.modU = lo
+		}
+	}
+	return p, nil
+}
+
+// Nth returns the string the permutation maps in to. in must have the
+// BytesPermutation's length, and contain only bytes from its alphabet;
+// the result always does.
+func (p *BytesPermutation) Nth(in []byte) ([]byte, error) {
+	a, b, err := p.split(in)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < bytesPermutationRounds; i++ {
+		mod := p.roundMod(i)
+		a, b = b, addMod(a, p.round(i, b, mod), mod)
+	}
+	return p.join(a, b), nil
+}
+
+// Inverse returns the string which Nth maps to out, undoing Nth.
+func (p *BytesPermutation) Inverse(out []byte) ([]byte, error) {
+	a, b, err := p.split(out)
+	if err != nil {
+		return nil, err
+	}
+	for i := bytesPermutationRounds - 1; i >= 0; i-- {
+		mod := p.roundMod(i)
+		a, b = addMod(b, mod-p.round(i, a, mod), mod), a
+	}
+	return p.join(a, b), nil
+}
+
+// roundMod is the modulus for the half which round i changes. Rounds
+// alternate halves, so with an even number of rounds, each half ends up
+// the size it started.
+func (p *BytesPermutation) roundMod(i int) uint64 {
+	if i%2 == 0 {
+		return p.modU
+	}
+	return p.modV
+}
+
+// round computes the round function for round i of the other half x, as
+// a value in [0,mod).
+func (p *BytesPermutation) round(i int, x uint64, mod uint64) uint64 {
+	y := p.src.BitsAt(OffsetFor(SequenceBytesPermutation, p.seed, uint32(i), x))
+	hi, _ := bits.Mul64(y.Lo, mod)
+	return hi
+}
+
+// addMod returns (x+y)%mod, for x less than mod and y no more than mod,
+// even if x+y doesn't fit in a uint64.
+func addMod(x, y, mod uint64) uint64 {
+	sum, carry := bits.Add64(x, y, 0)
+	if carry != 0 || sum >= mod {
+		sum -= mod
+	}
+	return sum
+}
+
+// split converts s to the numeric values of its two halves.
+func (p *BytesPermutation) split(s []byte) (a, b uint64, err error) {
+	if len(s) != p.length {
+		return 0, 0, fmt.Errorf("BytesPermutation input must be %d bytes, got %d", p.length, len(s))
+	}
+	radix := uint64(len(p.alphabet))
+	for i, c := range s {
+		d := p.digits[c]
+		if d < 0 {
+			return 0, 0, fmt.Errorf("BytesPermutation input has %q at %d, which isn't in the alphabet", c, i)
+		}
+		if i < p.u {
+			a = a*radix + uint64(d)
+		} else {
+			b = b*radix + uint64(d)
+		}
+	}
+	return a, b, nil
+}
+
+// join converts the numeric values of two halves back to a string.
+func (p *BytesPermutation) join(a, b uint64) []byte {
+	out := make([]byte, p.length)
+	radix := uint64(len(p.alphabet))
+	for i := p.length - 1; i >= p.u; i-- {
+		out[i] = p.alphabet[b%radix]
+		b /= radix
+	}
+	for i := p.u - 1; i >= 0; i-- {
+		out[i] = p.alphabet[a%radix]
+		a /= radix
+	}
+	return out
+}
@@ -0,0 +1,127 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"bytes"
+	"testing"
+)
+
+// allStrings returns every string of the given length over alphabet, in
+// order.
+func allStrings(alphabet []byte, length int) [][]byte {
+	out := [][]byte{{}}
+	for i := 0; i < length; i++ {
+		var next [][]byte
+		for _, prefix := range out {
+			for _, c := range alphabet {
+				next = append(next, append(append([]byte(nil), prefix...), c))
+			}
+		}
+		out = next
+	}
+	return out
+}
+
+func Test_BytesPermutation(t *testing.T) {
+	for _, tc := range []struct {
+		alphabet string
+		length   int
+	}{{"ab", 10}, {"0123456789", 5}, {"xyz", 1}, {"abcdefghijklmnopqrstuvwxyz", 3}} {
+		alphabet := []byte(tc.alphabet)
+		p, err := NewBytesPermutation(alphabet, tc.length, 1, NewSequence(0))
+		if err != nil {
+			t.Fatalf("%q, length %d: %v", tc.alphabet, tc.length, err)
+		}
+		seen := make(map[string]bool)
+		unchanged := 0
+		for _, in := range allStrings(alphabet, tc.length) {
+			out, err := p.Nth(in)
+			if err != nil {
+				t.Fatalf("%q, length %d: Nth(%q): %v", tc.alphabet, tc.length, in, err)
+			}
+			if len(out) != len(in) {
+				t.Fatalf("%q, length %d: Nth(%q) = %q, wrong length", tc.alphabet, tc.length, in, out)
+			}
+			for _, c := range out {
+				if bytes.IndexByte(alphabet, c) < 0 {
+					t.Fatalf("%q, length %d: Nth(%q) = %q, not in alphabet", tc.alphabet, tc.length, in, out)
+				}
+			}
+			if seen[string(out)] {
+				t.Fatalf("%q, length %d: Nth(%q) = %q, already produced", tc.alphabet, tc.length, in, out)
+			}
+			seen[string(out)] = true
+			if bytes.Equal(in, out) {
+				unchanged++
+			}
+			back, err := p.Inverse(out)
+			if err != nil || !bytes.Equal(back, in) {
+				t.Fatalf("%q, length %d: Inverse(%q): expected %q, got %q, %v", tc.alphabet, tc.length, out, in, back, err)
+			}
+		}
+		// a random permutation has about one fixed point.
+		if tc.length > 1 && unchanged > 10 {
+			t.Errorf("%q, length %d: %d strings unchanged", tc.alphabet, tc.length, unchanged)
+		}
+	}
+}
+
+func Test_BytesPermutationSeeds(t *testing.T) {
+	alphabet := []byte("0123456789")
+	a, _ := NewBytesPermutation(alphabet, 12, 1, NewSequence(0))
+	b, _ := NewBytesPermutation(alphabet, 12, 2, NewSequence(0))
+	in := []byte("000000012345")
+	outA, _ := a.Nth(in)
+	outB, _ := b.Nth(in)
+	if bytes.Equal(outA, outB) {
+		t.Errorf("seeds 1 and 2 both gave %q for %q", outA, in)
+	}
+	// the longest decimal length whose halves fit in a uint64.
+	long, err := NewBytesPermutation(alphabet, 38, 0, NewSequence(0))
+	if err != nil {
+		t.Fatalf("length 38: %v", err)
+	}
+	in = []byte("99999999999999999999999999999999999999")
+	out, err := long.Nth(in)
+	if err != nil {
+		t.Fatalf("length 38: %v", err)
+	}
+	if back, _ := long.Inverse(out); !bytes.Equal(back, in) {
+		t.Errorf("length 38: Inverse(%q): expected %q, got %q", out, in, back)
+	}
+}
+
+func Test_BytesPermutationInvalid(t *testing.T) {
+	src := NewSequence(0)
+	for _, tc := range []struct {
+		alphabet string
+		length   int
+		src      Sequence
+	}{{"a", 4, src}, {"aba", 4, src}, {"ab", 0, src}, {"ab", 4, nil}, {"0123456789", 40, src}} {
+		if _, err := NewBytesPermutation([]byte(tc.alphabet), tc.length, 0, tc.src); err == nil {
+			t.Errorf("%q, length %d: expected error", tc.alphabet, tc.length)
+		}
+	}
+	p, _ := NewBytesPermutation([]byte("abc"), 4, 0, src)
+	for _, bad := range []string{"abc", "abcab", "abcd"} {
+		if _, err := p.Nth([]byte(bad)); err == nil {
+			t.Errorf("Nth(%q): expected error", bad)
+		}
+		if _, err := p.Inverse([]byte(bad)); err == nil {
+			t.Errorf("Inverse(%q): expected error", bad)
+		}
+	}
+}
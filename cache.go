@@ -0,0 +1,146 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+)
+
+// CachedSequence is a Sequence which remembers the most recent results
+// from another Sequence's BitsAt, so asking for the same offset
+// repeatedly only computes it once. It's only useful for Sequences, like
+// the ones from NewSequence, whose BitsAt results depend only on the
+// offset; a ReaderSequence, for instance, can't be cached.
+//
+// A CachedSequence is safe for concurrent use, because every call goes
+// through its lock, including calls to the underlying Sequence.
+type CachedSequence struct {
+	mu           sync.Mutex
+	inner        Sequence
+	size         int
+	entries      map[Uint128]*list.Element
+	lru          list.List // of cacheEntry, most recently used first
+	offset       Uint128
+	hits, misses uint64
+}
+
+// cacheEntry is one cached BitsAt result.
+type cacheEntry struct {
+	offset, bits Uint128
+}
+
+// NewCachedSequence returns a Sequence wrapping inner with a
+// least-recently-used cache of cacheSize BitsAt results. Its Uint64
+// values start from the SequenceRandSource offsets, as NewSequence's do,
+// rather than from inner's current position. The result is a
+// *CachedSequence, so its Stats are available through a type assertion.
+// inner shouldn't be used by anything else while the CachedSequence is in
+// use. It panics if inner is nil or cacheSize is less than 1.
+func NewCachedSequence(inner Sequence, cacheSize int) Sequence {
+	if inner == nil {
+		panic("need a usable PRNG apophenia.Sequence")
+	}
+	if cacheSize < 1 {
+		panic(fmt.Sprintf("cache size must be at least 1, got %d", cacheSize))
+	}
+	return &CachedSequence{
+		inner:   inner,
+		size:    cacheSize,
+		entries: make(map[Uint128]*list.Element, cacheSize),
+		offset:  OffsetFor(SequenceRandSource, 0, 0, 0),
+	}
+}
+
+// Stats returns the number of BitsAt results found in the cache, and the
+// number which had to be computed, since the CachedSequence was created.
+func (s *CachedSequence) Stats() (hits, misses uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits, s.misses
+}
+
+// Seed reseeds the underlying Sequence, empties the cache, and resets the
+// position to the start of the SequenceRandSource offsets, where
+// NewSequence's position starts.
+func (s *CachedSequence) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inner.Seed(seed)
+	s.entries = make(map[Uint128]*list.Element, s.size)
+	s.lru.Init()
+	s.offset = OffsetFor(SequenceRandSource, 0, 0, 0)
+}
+
+// Int63 returns a value in 0..(1<<63)-1.
+func (s *CachedSequence) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+// Uint64 returns a value in 0..(1<<64)-1.
+func (s *CachedSequence) Uint64() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := s.bitsAt(s.offset)
+	s.offset.Inc()
+	return out.Lo
+}
+
+// Seek sets the position used by Uint64 and Int63, returning the previous
+// position.
+func (s *CachedSequence) Seek(offset Uint128) (old Uint128) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, s.offset = s.offset, offset
+	return old
+}
+
+// BitsAt yields the bits at offset, from the cache if possible.
+func (s *CachedSequence) BitsAt(offset Uint128) Uint128 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.bitsAt(offset)
+}
+
+// BitsSliceAt fills dst with the bits at consecutive offsets from start,
+// using and updating the cache for each.
+func (s *CachedSequence) BitsSliceAt(start Uint128, dst []Uint128) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range dst {
+		dst[i] = s.bitsAt(start)
+		start.Inc()
+	}
+}
+
+// bitsAt looks up offset in the cache, computing and adding it if it's
+// not there. The caller must hold s.mu.
+func (s *CachedSequence) bitsAt(offset Uint128) Uint128 {
+	if e, ok := s.entries[offset]; ok {
+		s.hits++
+		s.lru.MoveToFront(e)
+		return e.Value.(cacheEntry).bits
+	}
+	s.misses++
+	out := s.inner.BitsAt(offset)
+	if s.lru.Len() >= s.size {
+		oldest := s.lru.Back()
+		delete(s.entries, oldest.Value.(cacheEntry).offset)
+		s.lru.Remove(oldest)
+	}
+	s.entries[offset] = s.lru.PushFront(cacheEntry{offset: offset, bits: out})
+	return out
+}
@@ -0,0 +1,124 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"sync"
+	"testing"
+)
+
+func Test_CachedSequence(t *testing.T) {
+	plain := NewSequence(3)
+	s := NewCachedSequence(NewSequence(3), 4)
+	c := s.(*CachedSequence)
+	// a new CachedSequence starts where NewSequence does.
+	if got, exp := NewCachedSequence(NewSequence(1), 8).Uint64(), NewSequence(1).Uint64(); got != exp {
+		t.Fatalf("first Uint64: expected %#x, got %#x", exp, got)
+	}
+	offsets := []Uint128{{Lo: 1}, {Lo: 2}, {Lo: 1}, {Hi: 1}, {Lo: 3}, {Lo: 4}, {Lo: 1}, {Lo: 2}}
+	// 1, 2 miss; 1 hits; Hi:1, 3 miss, filling the cache; 4 misses and
+	// evicts 2, the least recently used; 1 hits; 2 misses again.
+	for _, o := range offsets {
+		if got, exp := s.BitsAt(o), plain.BitsAt(o); got != exp {
+			t.Fatalf("BitsAt(%s): expected %s, got %s", o, exp, got)
+		}
+	}
+	if hits, misses := c.Stats(); hits != 2 || misses != 6 {
+		t.Fatalf("expected 2 hits and 6 misses, got %d and %d", hits, misses)
+	}
+
+	dst, exp := make([]Uint128, 10), make([]Uint128, 10)
+	s.BitsSliceAt(Uint128{}, dst)
+	plain.BitsSliceAt(Uint128{}, exp)
+	for i := range dst {
+		if dst[i] != exp[i] {
+			t.Fatalf("BitsSliceAt value %d: expected %s, got %s", i, exp[i], dst[i])
+		}
+	}
+	s.Seek(Uint128{Lo: 5})
+	plain.Seek(Uint128{Lo: 5})
+	for i := 0; i < 10; i++ {
+		if got, exp := s.Uint64(), plain.Uint64(); got != exp {
+			t.Fatalf("Uint64 %d: expected %#x, got %#x", i, exp, got)
+		}
+	}
+
+	s.Seed(4)
+	plain.Seed(4)
+	for i := uint64(0); i < 10; i++ {
+		if got, exp := s.BitsAt(Uint128{Lo: i}), plain.BitsAt(Uint128{Lo: i}); got != exp {
+			t.Fatalf("after reseeding, BitsAt(%d): expected %s, got %s", i, exp, got)
+		}
+	}
+	fresh := NewSequence(4)
+	for i := 0; i < 10; i++ {
+		if got, exp := s.Uint64(), fresh.Uint64(); got != exp {
+			t.Fatalf("after reseeding, Uint64 %d: expected %#x, got %#x", i, exp, got)
+		}
+	}
+}
+
+func Test_CachedSequenceConcurrent(t *testing.T) {
+	plain := NewSequence(0)
+	expected := make([]Uint128, 64)
+	plain.BitsSliceAt(Uint128{}, expected)
+	s := NewCachedSequence(NewSequence(0), 16)
+	var wg sync.WaitGroup
+	errs := make(chan string, 8)
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 2000; i++ {
+				n := uint64(i*(g+1)) % 64
+				if got := s.BitsAt(Uint128{Lo: n}); got != expected[n] {
+					errs <- "wrong value for offset " + Uint128{Lo: n}.String()
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if hits, misses := s.(*CachedSequence).Stats(); hits+misses != 8*2000 {
+		t.Errorf("expected %d lookups, got %d hits and %d misses", 8*2000, hits, misses)
+	}
+}
+
+func Test_CachedSequenceInvalid(t *testing.T) {
+	for _, bad := range []struct {
+		inner Sequence
+		size  int
+	}{{nil, 1}, {NewSequence(0), 0}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewCachedSequence(%v, %d): expected panic", bad.inner, bad.size)
+				}
+			}()
+			NewCachedSequence(bad.inner, bad.size)
+		}()
+	}
+}
+
+func BenchmarkCachedSequence(b *testing.B) {
+	s := NewCachedSequence(NewSequence(0), 64)
+	for i := 0; i < b.N; i++ {
+		s.BitsAt(Uint128{Lo: uint64(i % 32)})
+	}
+}
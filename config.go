@@ -0,0 +1,82 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import "fmt"
+
+// DistributionConfig describes a Distribution, for reading from
+// configuration files. Type selects the distribution, and determines
+// which other fields are used:
+//
+//	"zipf": Q, V, Max (inclusive), as for NewZipf
+//	"uniform": Max (exclusive), as for NewUniform
+//	"gaussian": Mean, StdDev, as for NewDiscreteGaussian
+//	"exponential": Rate, as for NewDiscreteExponential
+//	"poisson": Lambda, as for NewPoisson
+//
+// All of them use Seed. The field tags use the same names for JSON,
+// YAML, and TOML.
+type DistributionConfig struct {
+	Type   string  `json:"type" yaml:"type" toml:"type"`
+	Q      float64 `json:"q,omitempty" yaml:"q,omitempty" toml:"q,omitempty"`
+	V      float64 `json:"v,omitempty" yaml:"v,omitempty" toml:"v,omitempty"`
+	Max    uint64  `json:"max,omitempty" yaml:"max,omitempty" toml:"max,omitempty"`
+	Mean   float64 `json:"mean,omitempty" yaml:"mean,omitempty" toml:"mean,omitempty"`
+	StdDev float64 `json:"stddev,omitempty" yaml:"stddev,omitempty" toml:"stddev,omitempty"`
+	Rate   float64 `json:"rate,omitempty" yaml:"rate,omitempty" toml:"rate,omitempty"`
+	Lambda float64 `json:"lambda,omitempty" yaml:"lambda,omitempty" toml:"lambda,omitempty"`
+	Seed   uint32  `json:"seed,omitempty" yaml:"seed,omitempty" toml:"seed,omitempty"`
+}
+
+// NewDistributionFromConfig creates the Distribution described by cfg,
+// using src. On error, the returned Distribution is nil.
+func NewDistributionFromConfig(cfg DistributionConfig, src Sequence) (Distribution, error) {
+	// Each case checks err itself, because returning a nil *Zipf (say)
+	// as a Distribution would produce a non-nil interface value.
+	switch cfg.Type {
+	case "zipf":
+		d, err := NewZipf(cfg.Q, cfg.V, cfg.Max, cfg.Seed, src)
+		if err != nil {
+			return nil, err
+		}
+		return d, nil
+	case "uniform":
+		d, err := NewUniform(cfg.Max, cfg.Seed, src)
+		if err != nil {
+			return nil, err
+		}
+		return d, nil
+	case "gaussian":
+		d, err := NewDiscreteGaussian(cfg.Mean, cfg.StdDev, cfg.Seed, src)
+		if err != nil {
+			return nil, err
+		}
+		return d, nil
+	case "exponential":
+		d, err := NewDiscreteExponential(cfg.Rate, cfg.Seed, src)
+		if err != nil {
+			return nil, err
+		}
+		return d, nil
+	case "poisson":
+		d, err := NewPoisson(cfg.Lambda, cfg.Seed, src)
+		if err != nil {
+			return nil, err
+		}
+		return d, nil
+	default:
+		return nil, fmt.Errorf("unknown distribution type %q", cfg.Type)
+	}
+}
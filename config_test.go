@@ -0,0 +1,115 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func Test_DistributionConfigTags(t *testing.T) {
+	// no YAML or TOML packages to marshal with, so check that the tags
+	// give those formats the same names as JSON.
+	tags := []struct{ field, name string }{
+		{"Type", "type"},
+		{"Q", "q,omitempty"},
+		{"V", "v,omitempty"},
+		{"Max", "max,omitempty"},
+		{"Mean", "mean,omitempty"},
+		{"StdDev", "stddev,omitempty"},
+		{"Rate", "rate,omitempty"},
+		{"Lambda", "lambda,omitempty"},
+		{"Seed", "seed,omitempty"},
+	}
+	typ := reflect.TypeOf(DistributionConfig{})
+	if typ.NumField() != len(tags) {
+		t.Fatalf("DistributionConfig has %d fields, expected %d", typ.NumField(), len(tags))
+	}
+	for _, c := range tags {
+		f, ok := typ.FieldByName(c.field)
+		if !ok {
+			t.Fatalf("no field %s", c.field)
+		}
+		for _, format := range []string{"json", "yaml", "toml"} {
+			if got := f.Tag.Get(format); got != c.name {
+				t.Errorf("%s: %s tag %q, expected %q", c.field, format, got, c.name)
+			}
+		}
+	}
+}
+
+func Test_DistributionConfig(t *testing.T) {
+	src := NewSequence(0)
+	configs := []DistributionConfig{
+		{Type: "zipf", Q: 1.3, V: 1.5, Max: 100, Seed: 2},
+		{Type: "uniform", Max: 10, Seed: 1},
+		{Type: "gaussian", Mean: 20, StdDev: 4.5, Seed: 3},
+		{Type: "exponential", Rate: 0.25},
+		{Type: "poisson", Lambda: 4.5},
+	}
+	for _, cfg := range configs {
+		data, err := json.Marshal(cfg)
+		if err != nil {
+			t.Fatalf("marshaling %+v: %v", cfg, err)
+		}
+		var decoded DistributionConfig
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("unmarshaling %s: %v", data, err)
+		}
+		if decoded != cfg {
+			t.Fatalf("round trip of %+v produced %+v", cfg, decoded)
+		}
+		d, err := NewDistributionFromConfig(decoded, src)
+		if err != nil {
+			t.Fatalf("creating distribution from %s: %v", data, err)
+		}
+		if d == nil {
+			t.Fatalf("nil distribution from %s", data)
+		}
+	}
+
+	var cfg DistributionConfig
+	if err := json.Unmarshal([]byte(`{"type": "zipf", "q": 1.3, "v": 1.5, "max": 100, "seed": 2}`), &cfg); err != nil {
+		t.Fatalf("unmarshaling: %v", err)
+	}
+	d, _ := NewDistributionFromConfig(cfg, src)
+	z, _ := NewZipf(1.3, 1.5, 100, 2, src)
+	for i := uint64(0); i < 100; i++ {
+		if a, b := d.Nth(i), z.Nth(i); a != b {
+			t.Fatalf("index %d: configured zipf gave %d, NewZipf gave %d", i, a, b)
+		}
+	}
+
+	for _, bad := range []DistributionConfig{
+		{Type: "zipfian"},
+		{Type: ""},
+		{Type: "gaussian"},
+		{Type: "gaussian", Mean: math.NaN(), StdDev: 1},
+		{Type: "exponential"},
+		{Type: "zipf", Q: 0.5, V: 1, Max: 10},
+		{Type: "uniform"},
+		{Type: "poisson", Lambda: -1},
+	} {
+		d, err := NewDistributionFromConfig(bad, src)
+		if err == nil {
+			t.Errorf("%+v: expected error", bad)
+		}
+		if d != nil {
+			t.Errorf("%+v: expected nil distribution with error, got %#v", bad, d)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import "math"
+
+// couponIntervals is the number of intervals used by the numerical
+// integration in CouponCollectorExpected.
+const couponIntervals = 1 << 14
+
+// CouponCollectorExpected returns the expected number of values drawn
+// from d before every value in [0,max) has appeared at least once. Draws
+// of values outside [0,max) count, but don't help. If some value in
+// [0,max) has probability zero, the result is +Inf.
+//
+// This uses the identity E[T] = integral from 0 to infinity of
+// 1 - prod(1 - exp(-p[i] t)) dt, computed numerically, so it takes time
+// proportional to max.
+func CouponCollectorExpected(d Distribution, max uint64) float64 {
+	if max == 0 {
+		return 0
+	}
+	probs := make([]float64, max)
+	pMin := math.Inf(1)
+	for v := range probs {
+		probs[v] = d.Probability(uint64(v))
+		if !(probs[v] > 0) {
+			return math.Inf(1)
+		}
+		pMin = math.Min(pMin, probs[v])
+	}
+	f := func(t float64) float64 {
+		logProd := 0.0
+		for _, p := range probs {
+			logProd += math.Log1p(-math.Exp(-p * t))
+		}
+		return -math.Expm1(logProd)
+	}
+	// Past this point, the sum of exp(-p t) is under e^-40, so the
+	// integrand is indistinguishable from 0.
+	end := (math.Log(float64(max)) + 40) / pMin
+	h := end / couponIntervals
+	// Simpson's rule; f(0) is 1.
+	sum := 1 + f(end)
+	for i := 1; i < couponIntervals; i++ {
+		if i&1 != 0 {
+			sum += 4 * f(float64(i)*h)
+		} else {
+			sum += 2 * f(float64(i)*h)
+		}
+	}
+	return sum * h / 3
+}
+
+// CouponCollectorSample runs trials of drawing values from d until every
+// value in [0,max) has appeared, returning the number of draws each trial
+// took. Each trial starts from an index of d chosen using seed and src,
+// and draws consecutive indexes from there. It returns nil if some value
+// in [0,max) has probability zero, since those trials would never end.
+func CouponCollectorSample(d Distribution, max uint64, trials int, seed uint32, src Sequence) []int {
+	for v := uint64(0); v < max; v++ {
+		if !(d.Probability(v) > 0) {
+			return nil
+		}
+	}
+	out := make([]int, trials)
+	seen := make([]bool, max)
+	for trial := range out {
+		for i := range seen {
+			seen[i] = false
+		}
+		index := src.BitsAt(OffsetFor(SequenceCouponCollector, seed, 0, uint64(trial))).Lo
+		draws := 0
+		for missing := max; missing > 0; index++ {
+			draws++
+			if v := d.Nth(index); v < max && !seen[v] {
+				seen[v] = true
+				missing--
+			}
+		}
+		out[trial] = draws
+	}
+	return out
+}
@@ -0,0 +1,89 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_CouponCollectorUniform(t *testing.T) {
+	for _, max := range []uint64{1, 2, 10, 100, 1000} {
+		u, err := NewUniform(max, 0, NewSequence(0))
+		if err != nil {
+			t.Fatalf("creating uniform: %v", err)
+		}
+		harmonic := 0.0
+		for k := uint64(1); k <= max; k++ {
+			harmonic += 1 / float64(k)
+		}
+		exp := float64(max) * harmonic
+		got := CouponCollectorExpected(u, max)
+		if math.Abs(got-exp) > 1e-6*exp {
+			t.Errorf("max %d: expected %g, got %g", max, exp, got)
+		}
+	}
+	const max, trials = 50, 2000
+	u, _ := NewUniform(max, 0, NewSequence(0))
+	samples := CouponCollectorSample(u, max, trials, 0, NewSequence(1))
+	if len(samples) != trials {
+		t.Fatalf("expected %d trials, got %d", trials, len(samples))
+	}
+	mean := 0.0
+	for _, s := range samples {
+		if s < max {
+			t.Fatalf("trial took %d draws, need at least %d", s, max)
+		}
+		mean += float64(s)
+	}
+	mean /= trials
+	// the standard deviation for n=50 is about 61, so the mean of 2000
+	// trials should be within a few units of the expected value.
+	if exp := CouponCollectorExpected(u, max); math.Abs(mean-exp) > 7 {
+		t.Errorf("sampled mean %g, expected about %g", mean, exp)
+	}
+}
+
+func Test_CouponCollectorZipf(t *testing.T) {
+	z, err := NewZipf(1.5, 2, 20, 0, NewSequence(0))
+	if err != nil {
+		t.Fatalf("creating zipf: %v", err)
+	}
+	total := 0.0
+	for v := uint64(0); v <= 20; v++ {
+		total += z.Probability(v)
+	}
+	if math.Abs(total-1) > 1e-12 {
+		t.Fatalf("zipf probabilities sum to %g", total)
+	}
+	// Only collecting values below 10 ignores the rarest ones.
+	const max, trials = 10, 2000
+	exp := CouponCollectorExpected(z, max)
+	samples := CouponCollectorSample(z, max, trials, 0, NewSequence(1))
+	mean := 0.0
+	for _, s := range samples {
+		mean += float64(s)
+	}
+	mean /= trials
+	if math.Abs(mean-exp) > 0.05*exp {
+		t.Errorf("sampled mean %g, expected about %g", mean, exp)
+	}
+	if got := CouponCollectorExpected(z, 30); !math.IsInf(got, 1) {
+		t.Errorf("values past max have probability 0; expected +Inf, got %g", got)
+	}
+	if got := CouponCollectorSample(z, 30, 1, 0, NewSequence(1)); got != nil {
+		t.Errorf("expected nil for unreachable values, got %v", got)
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"testing"
+)
+
+// determinismMax is the max used for the bounded generators in
+// TestDeterminism, so max-1 is a meaningful boundary index.
+const determinismMax = 1000
+
+// determinismGenerators produce the value a given generator yields for a
+// given seed and index, widened to a Uint128 so they can share a table.
+var determinismGenerators = map[string]func(seed int64, index uint64, tb testing.TB) Uint128{
+	"sequence": func(seed int64, index uint64, tb testing.TB) Uint128 {
+		return NewSequence(seed).BitsAt(OffsetFor(SequenceDefault, 0, 0, index))
+	},
+	"permutation": func(seed int64, index uint64, tb testing.TB) Uint128 {
+		p := PermutationOrBust(determinismMax, seed, "", tb)
+		return Uint128{Lo: uint64(p.Nth(int64(index)))}
+	},
+	"zipf": func(seed int64, index uint64, tb testing.TB) Uint128 {
+		z, err := NewZipf(1.1, 2, determinismMax, 0, NewSequence(seed))
+		if err != nil {
+			tb.Fatalf("making zipf: %v", err)
+		}
+		return Uint128{Lo: z.Nth(index)}
+	},
+	"weighted": func(seed int64, index uint64, tb testing.TB) Uint128 {
+		w, err := NewWeighted(NewSequence(seed))
+		if err != nil {
+			tb.Fatalf("making weighted: %v", err)
+		}
+		// Bits shifts the column down by 7, so shift it up to get
+		// a distinct batch of bits for each index.
+		return w.Bits(OffsetFor(SequenceWeighted, 0, 0, index<<7), 3, 8)
+	},
+}
+
+// TestDeterminism pins the outputs of each generator, so that accidental
+// changes to any of the underlying algorithms show up as test failures.
+// If you change one of these values on purpose, you are changing the
+// output that existing users get for their existing seeds.
+func TestDeterminism(t *testing.T) {
+	cases := []struct {
+		gen   string
+		seed  int64
+		index uint64
+		exp   Uint128
+	}{
+		{gen: "sequence", seed: 0, index: 0, exp: Uint128{Lo: 0x3b2c8aefd44be966, Hi: 0x2e2b34ca59fa4c88}},
+		{gen: "sequence", seed: 0, index: 1, exp: Uint128{Lo: 0xf06f1de916187147, Hi: 0xd30f8ef52bbfbb59}},
+		{gen: "sequence", seed: 0, index: 2, exp: Uint128{Lo: 0x8580adeaa776f1bc, Hi: 0x81a2622436eaaceb}},
+		{gen: "sequence", seed: 0, index: 12345, exp: Uint128{Lo: 0x503ffa8b9dd4ff48, Hi: 0x72d97a8fa4619951}},
+		{gen: "sequence", seed: 0, index: math.MaxUint64, exp: Uint128{Lo: 0xf5e05f98e7c307f8, Hi: 0x9e10c525db2c0ea5}},
+		{gen: "sequence", seed: 7, index: 0, exp: Uint128{Lo: 0x55ca9473683636d8, Hi: 0xb74aea98213aa738}},
+		{gen: "sequence", seed: 7, index: 1, exp: Uint128{Lo: 0x24c0047b8c6c4fc2, Hi: 0x3fcbcc24fea1ce92}},
+		{gen: "sequence", seed: 7, index: 2, exp: Uint128{Lo: 0x8ab1110bbe724be2, Hi: 0x93e656f85f50b423}},
+		{gen: "sequence", seed: 7, index: 12345, exp: Uint128{Lo: 0xd9f443c361ecb47a, Hi: 0x1c2c9c9175a19c34}},
+		{gen: "sequence", seed: 7, index: math.MaxUint64, exp: Uint128{Lo: 0x48890999708c674c, Hi: 0xc710682859e22809}},
+		{gen: "permutation", seed: 0, index: 0, exp: Uint128{Lo: 62}},
+		{gen: "permutation", seed: 0, index: 1, exp: Uint128{Lo: 777}},
+		{gen: "permutation", seed: 0, index: 2, exp: Uint128{Lo: 732}},
+		{gen: "permutation", seed: 0, index: 500, exp: Uint128{Lo: 453}},
+		{gen: "permutation", seed: 0, index: determinismMax - 1, exp: Uint128{Lo: 825}},
+		{gen: "permutation", seed: 7, index: 0, exp: Uint128{Lo: 810}},
+		{gen: "permutation", seed: 7, index: 1, exp: Uint128{Lo: 32}},
+		{gen: "permutation", seed: 7, index: 2, exp: Uint128{Lo: 440}},
+		{gen: "permutation", seed: 7, index: 500, exp: Uint128{Lo: 575}},
+		{gen: "permutation", seed: 7, index: determinismMax - 1, exp: Uint128{Lo: 178}},
+		{gen: "zipf", seed: 0, index: 0, exp: Uint128{Lo: 34}},
+		{gen: "zipf", seed: 0, index: 1, exp: Uint128{Lo: 977}},
+		{gen: "zipf", seed: 0, index: 2, exp: Uint128{Lo: 3}},
+		{gen: "zipf", seed: 0, index: 500, exp: Uint128{Lo: 244}},
+		{gen: "zipf", seed: 0, index: determinismMax - 1, exp: Uint128{Lo: 9}},
+		{gen: "zipf", seed: 0, index: math.MaxUint64, exp: Uint128{Lo: 1}},
+		{gen: "zipf", seed: 7, index: 0, exp: Uint128{Lo: 1}},
+		{gen: "zipf", seed: 7, index: 1, exp: Uint128{Lo: 8}},
+		{gen: "zipf", seed: 7, index: 2, exp: Uint128{Lo: 161}},
+		{gen: "zipf", seed: 7, index: 500, exp: Uint128{Lo: 1}},
+		{gen: "zipf", seed: 7, index: determinismMax - 1, exp: Uint128{Lo: 3}},
+		{gen: "zipf", seed: 7, index: math.MaxUint64, exp: Uint128{Lo: 2}},
+		{gen: "weighted", seed: 0, index: 0, exp: Uint128{Lo: 0x80e861449e269350, Hi: 0xef911ae640201412}},
+		{gen: "weighted", seed: 0, index: 1, exp: Uint128{Lo: 0x11d56868324f085, Hi: 0x1f0495e78ecb74c0}},
+		{gen: "weighted", seed: 0, index: 2, exp: Uint128{Lo: 0x6100687da20f620, Hi: 0xf2b01214155248b6}},
+		{gen: "weighted", seed: 0, index: 500, exp: Uint128{Lo: 0x4280080d6844c2c, Hi: 0x16440802c0250021}},
+		{gen: "weighted", seed: 0, index: determinismMax - 1, exp: Uint128{Lo: 0x262048130179b001, Hi: 0x444433f843995029}},
+		{gen: "weighted", seed: 7, index: 0, exp: Uint128{Lo: 0x3c06c164214a0408, Hi: 0x4d453817122c016d}},
+		{gen: "weighted", seed: 7, index: 1, exp: Uint128{Lo: 0x48c260084e6e526c, Hi: 0x5c0940f66f657092}},
+		{gen: "weighted", seed: 7, index: 2, exp: Uint128{Lo: 0x1c4ad2b8306a775e, Hi: 0x3568581096302680}},
+		{gen: "weighted", seed: 7, index: 500, exp: Uint128{Lo: 0x80b4c4071923125d, Hi: 0x730a270a0fd22640}},
+		{gen: "weighted", seed: 7, index: determinismMax - 1, exp: Uint128{Lo: 0x32247c30833a4017, Hi: 0xa210011838e5cee1}},
+	}
+	for _, c := range cases {
+		gen, ok := determinismGenerators[c.gen]
+		if !ok {
+			t.Fatalf("unknown generator %q", c.gen)
+		}
+		got := gen(c.seed, c.index, t)
+		if got != c.exp {
+			t.Errorf("%s (seed %d, index %d): expected %s, got %s",
+				c.gen, c.seed, c.index, c.exp, got)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"crypto/aes"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// SequenceDiagInfo describes the environment a Sequence is running in,
+// for performance debugging.
+type SequenceDiagInfo struct {
+	// HardwareAES is true if crypto/aes appears to be using hardware
+	// AES instructions rather than its much slower software fallback.
+	// It's an estimate, from timing crypto/aes against a loop doing
+	// about the work of a software AES block, so it reflects what
+	// crypto/aes actually does on any architecture, including with
+	// GODEBUG=cpu.aes=off. Like BlocksPerSecond, it can be thrown off
+	// by a heavily loaded machine.
+	HardwareAES bool
+	// GoVersion is the Go version the program was built with.
+	GoVersion string
+	// GOARCH is the architecture the program was built for.
+	GOARCH string
+	// BlocksPerSecond is how many BitsAt calls a Sequence did per
+	// second, measured over about a millisecond.
+	BlocksPerSecond float64
+}
+
+// diagDuration is how long SequenceDiagnostics measures for.
+const diagDuration = time.Millisecond
+
+// SequenceDiagnostics reports whether AES appears to be
+// hardware-accelerated, and how fast a Sequence actually is here. It
+// takes about a millisecond, because it measures the speed by generating
+// values.
+func SequenceDiagnostics() SequenceDiagInfo {
+	info := SequenceDiagInfo{
+		HardwareAES: hardwareAES(),
+		GoVersion:   runtime.Version(),
+		GOARCH:      runtime.GOARCH,
+	}
+	s := NewSequence(0)
+	var offset Uint128
+	blocks := 0
+	start := time.Now()
+	elapsed := time.Duration(0)
+	for elapsed < diagDuration {
+		// check the clock only every so often, so it doesn't dominate
+		// the measurement.
+		for i := 0; i < 64; i++ {
+			s.BitsAt(offset)
+			offset.Inc()
+		}
+		blocks += 64
+		elapsed = time.Since(start)
+	}
+	info.BlocksPerSecond = float64(blocks) / elapsed.Seconds()
+	return info
+}
+
+// diagSink keeps the compiler from discarding hardwareAES's reference
+// loop.
+var diagSink uint32
+
+// diagTable is the lookup table for hardwareAES's reference loop. It's a
+// package variable, like software AES's tables, so the loop's memory
+// accesses cost what those do, even under the race detector.
+var diagTable = func() (table [256]uint32) {
+	for i := range table {
+		table[i] = uint32(i) * 0x9e3779b9
+	}
+	return table
+}()
+
+// hardwareAES guesses whether crypto/aes is hardware-accelerated. It
+// times single-block encryption against a loop of ten rounds of 16 table
+// lookups, which is about what a software AES block costs. Hardware AES
+// takes a fraction of the loop's time, and the software fallback takes
+// about as long or longer, so the threshold is half. Each is timed a few
+// times, alternating, and the fastest time counts, to reduce noise.
+func hardwareAES() bool {
+	const batch, tries = 256, 5
+	block, _ := aes.NewCipher(make([]byte, 16))
+	var buf [16]byte
+	table := &diagTable
+	var w [4]uint32
+	aesTime, loopTime := time.Duration(1<<62), time.Duration(1<<62)
+	for try := 0; try < tries; try++ {
+		start := time.Now()
+		for i := 0; i < batch; i++ {
+			block.Encrypt(buf[:], buf[:])
+		}
+		if d := time.Since(start); d < aesTime {
+			aesTime = d
+		}
+		start = time.Now()
+		for i := 0; i < batch; i++ {
+			for r := uint32(0); r < 10; r++ {
+				s0, s1, s2, s3 := w[0], w[1], w[2], w[3]
+				w[0] = table[s0>>24] ^ table[s1>>16&0xff] ^ table[s2>>8&0xff] ^ table[s3&0xff] ^ r
+				w[1] = table[s1>>24] ^ table[s2>>16&0xff] ^ table[s3>>8&0xff] ^ table[s0&0xff]
+				w[2] = table[s2>>24] ^ table[s3>>16&0xff] ^ table[s0>>8&0xff] ^ table[s1&0xff]
+				w[3] = table[s3>>24] ^ table[s0>>16&0xff] ^ table[s1>>8&0xff] ^ table[s2&0xff]
+			}
+		}
+		if d := time.Since(start); d < loopTime {
+			loopTime = d
+		}
+	}
+	atomic.StoreUint32(&diagSink, w[0]^uint32(buf[0]))
+	return aesTime < loopTime/2
+}
@@ -0,0 +1,72 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func Test_SequenceDiagnostics(t *testing.T) {
+	info := SequenceDiagnostics()
+	if info.GoVersion != runtime.Version() {
+		t.Errorf("expected Go version %q, got %q", runtime.Version(), info.GoVersion)
+	}
+	if info.GOARCH != runtime.GOARCH {
+		t.Errorf("expected GOARCH %q, got %q", runtime.GOARCH, info.GOARCH)
+	}
+	if !(info.BlocksPerSecond > 0) {
+		t.Errorf("expected positive blocks per second, got %g", info.BlocksPerSecond)
+	}
+	t.Logf("%+v", info)
+	if strings.Contains(os.Getenv("GODEBUG"), "cpu.aes=off") {
+		if info.HardwareAES {
+			t.Errorf("hardware AES reported with GODEBUG=cpu.aes=off")
+		}
+		return
+	}
+	// On Linux, the kernel reports AES instructions as the "aes" flag,
+	// on the "flags" line on amd64 and the "Features" line on arm64.
+	var flagsLine string
+	switch runtime.GOARCH {
+	case "amd64":
+		flagsLine = "flags"
+	case "arm64":
+		flagsLine = "Features"
+	default:
+		t.Skipf("don't know where to find AES support for %s", runtime.GOARCH)
+	}
+	cpuinfo, err := os.ReadFile("/proc/cpuinfo")
+	if err != nil {
+		t.Skipf("can't check hardware AES against /proc/cpuinfo: %v", err)
+	}
+	expected := false
+	for _, line := range strings.Split(string(cpuinfo), "\n") {
+		if !strings.HasPrefix(line, flagsLine) {
+			continue
+		}
+		for _, flag := range strings.Fields(line) {
+			if flag == "aes" {
+				expected = true
+			}
+		}
+		break
+	}
+	if info.HardwareAES != expected {
+		t.Errorf("expected hardware AES %t to match /proc/cpuinfo, got %t", expected, info.HardwareAES)
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+// Distribution is a seekable discrete distribution over uint64 values,
+// which can also report the probability of each value. Zipf and Uniform
+// are Distributions.
+type Distribution interface {
+	// Nth returns the value for the given index.
+	Nth(index uint64) uint64
+	// Probability returns the probability of the given value.
+	Probability(value uint64) float64
+}
+
+// ContinuousDistribution is a seekable continuous distribution over
+// float64 values. Exponential and PowerLaw are ContinuousDistributions.
+type ContinuousDistribution interface {
+	// Nth returns the value for the given index.
+	Nth(index uint64) float64
+}
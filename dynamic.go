@@ -0,0 +1,199 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// DynamicWeighted samples indexes in proportion to a table of weights
+// which can be changed at any time. Where an aliasTable needs rebuilding
+// from scratch when weights change, DynamicWeighted keeps a binary
+// indexed (Fenwick) tree of partial sums, so both updates and samples
+// take O(log N) time.
+//
+// Since updates are applied as differences to the partial sums, a series
+// of updates can accumulate floating-point rounding error, so the sampled
+// probabilities are close to, but not exactly, proportional to the
+// current weights. To keep that error from building up, the tree is
+// rebuilt from the weights every Len() updates, and whenever all the
+// weights become zero.
+type DynamicWeighted struct {
+	src     Sequence
+	seed    uint32
+	weights []float64
+	tree    []float64 // 1-based Fenwick tree over weights
+	topBit  int       // highest power of two <= len(weights)
+	nonZero int       // number of non-zero weights, counted exactly
+	updates int       // updates since the tree was last rebuilt
+}
+
+// NewDynamicWeighted creates a DynamicWeighted with n indexes, all of
+// which initially have weight zero.
+func NewDynamicWeighted(n int, seed uint32, src Sequence) (*DynamicWeighted, error) {
+	if src == nil {
+		return nil, errors.New("need a usable PRNG apophenia.Sequence")
+	}
+	if n < 1 {
+		return nil, fmt.Errorf("need at least one index, got %d", n)
+	}
+	d := &DynamicWeighted{src: src, seed: seed, weights: make([]float64, n), tree: make([]float64, n+1)}
+	d.topBit = 1
+	for d.topBit*2 <= n {
+		d.topBit *= 2
+	}
+	return d, nil
+}
+
+// Len returns the number of indexes.
+func (d *DynamicWeighted) Len() int {
+	return len(d.weights)
+}
+
+// Weight returns the current weight of index.
+func (d *DynamicWeighted) Weight(index int) float64 {
+	return d.weights[index]
+}
+
+// Set changes the weight of index. It panics if index is out of range,
+// or weight is negative, NaN, or infinite.
+func (d *DynamicWeighted) Set(index int, weight float64) {
+	if index < 0 || index >= len(d.weights) {
+		panic(fmt.Sprintf("index %d out of range [0,%d)", index, len(d.weights)))
+	}
+	if !(weight >= 0) || math.IsInf(weight, 1) {
+		panic(fmt.Sprintf("weight must be finite and non-negative, got %g", weight))
+	}
+	old := d.weights[index]
+	d.weights[index] = weight
+	if old == 0 && weight != 0 {
+		d.nonZero++
+	} else if old != 0 && weight == 0 {
+		d.nonZero--
+	}
+	d.updates++
+	if d.nonZero == 0 || d.updates >= len(d.weights) {
+		d.rebuild()
+		return
+	}
+	delta := weight - old
+	for i := index + 1; i < len(d.tree); i += i & -i {
+		d.tree[i] += delta
+	}
+}
+
+// rebuild recomputes the tree from the weights, in O(N) time, discarding
+// any accumulated rounding error.
+func (d *DynamicWeighted) rebuild() {
+	for i := range d.tree {
+		d.tree[i] = 0
+	}
+	for i := 1; i < len(d.tree); i++ {
+		d.tree[i] += d.weights[i-1]
+		if parent := i + (i & -i); parent < len(d.tree) {
+			d.tree[parent] += d.tree[i]
+		}
+	}
+	d.updates = 0
+}
+
+// Total returns the sum of all the weights. It's exactly 0 if all the
+// weights are zero.
+func (d *DynamicWeighted) Total() float64 {
+	if d.nonZero == 0 {
+		return 0
+	}
+	return d.prefix(len(d.weights))
+}
+
+// prefix returns the sum of the first n weights.
+func (d *DynamicWeighted) prefix(n int) (sum float64) {
+	for i := n; i > 0; i -= i & -i {
+		sum += d.tree[i]
+	}
+	return sum
+}
+
+// CDF returns the fraction of the total weight in indexes 0 through k,
+// which is the probability that Sample returns k or less. It returns 0 if
+// all the weights are zero.
+func (d *DynamicWeighted) CDF(k int) float64 {
+	if k < 0 || k >= len(d.weights) {
+		panic(fmt.Sprintf("index %d out of range [0,%d)", k, len(d.weights)))
+	}
+	total := d.Total()
+	if !(total > 0) {
+		return 0
+	}
+	if k == len(d.weights)-1 {
+		return 1
+	}
+	return math.Min(d.prefix(k+1)/total, 1)
+}
+
+// Quantile returns the smallest index k such that CDF(k) >= p. It panics
+// if p isn't in [0,1], and returns -1 if all the weights are zero. For
+// positive weights, Quantile(CDF(k)) is k, and Quantile(1) is the last
+// index; zero weights are skipped over, as they are by Sample, except that
+// Quantile(0) is always 0.
+func (d *DynamicWeighted) Quantile(p float64) int {
+	if !(p >= 0 && p <= 1) {
+		panic(fmt.Sprintf("probability must be in [0,1], got %g", p))
+	}
+	if !(d.Total() > 0) {
+		return -1
+	}
+	// Binary search over CDF itself, rather than the partial sums, so
+	// Quantile rounds exactly the way CDF does.
+	return sort.Search(len(d.weights)-1, func(k int) bool {
+		return d.CDF(k) >= p
+	})
+}
+
+// Sample returns an index chosen in proportion to the current weights,
+// using the bits for the given sample index. It returns -1 if all the
+// weights are zero. An index with zero weight is never returned.
+func (d *DynamicWeighted) Sample(index uint64) int {
+	total := d.Total()
+	if !(total > 0) {
+		return -1
+	}
+	u := unitFloat64(d.src.BitsAt(OffsetFor(SequenceDynamicWeighted, d.seed, 0, index)).Lo) * total
+	// Find the largest pos such that the first pos weights sum to no
+	// more than u; the weight at pos is then the one u falls in.
+	pos := 0
+	for step := d.topBit; step > 0; step >>= 1 {
+		if next := pos + step; next < len(d.tree) && d.tree[next] <= u {
+			pos = next
+			u -= d.tree[next]
+		}
+	}
+	// Rounding error can leave u past the last non-zero weight, or
+	// on a zero weight; there's at least one non-zero weight, so look
+	// down and then up for one.
+	if pos >= len(d.weights) {
+		pos = len(d.weights) - 1
+	}
+	for pos > 0 && d.weights[pos] == 0 {
+		pos--
+	}
+	for d.weights[pos] == 0 {
+		pos++
+	}
+	return pos
+}
@@ -0,0 +1,247 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// checkDynamicWeighted verifies that samples from d are distributed in
+// proportion to its weights.
+func checkDynamicWeighted(t *testing.T, d *DynamicWeighted, firstSample uint64) {
+	t.Helper()
+	const samples = 100000
+	counts := make([]int, d.Len())
+	for i := uint64(0); i < samples; i++ {
+		counts[d.Sample(firstSample+i)]++
+	}
+	total := d.Total()
+	for i, c := range counts {
+		w := d.Weight(i)
+		if w == 0 {
+			if c != 0 {
+				t.Errorf("zero-weight index %d sampled %d times", i, c)
+			}
+			continue
+		}
+		exp := w / total * samples
+		if math.Abs(float64(c)-exp) > 5*math.Sqrt(exp)+1 {
+			t.Errorf("index %d (weight %g): expected about %.0f samples, got %d", i, w, exp, c)
+		}
+	}
+}
+
+func Test_DynamicWeighted(t *testing.T) {
+	d, err := NewDynamicWeighted(7, 0, NewSequence(0))
+	if err != nil {
+		t.Fatalf("creating DynamicWeighted: %v", err)
+	}
+	if got := d.Sample(0); got != -1 {
+		t.Fatalf("expected -1 with all weights zero, got %d", got)
+	}
+	weights := []float64{1, 0, 3, 0.5, 5.5, 0, 2}
+	for i, w := range weights {
+		d.Set(i, w)
+	}
+	if total := d.Total(); math.Abs(total-12) > 1e-12 {
+		t.Fatalf("expected total 12, got %g", total)
+	}
+	checkDynamicWeighted(t, d, 0)
+
+	// move weight around, including to and from zero.
+	d.Set(0, 0)
+	d.Set(1, 4)
+	d.Set(4, 0.25)
+	d.Set(6, 6)
+	if total := d.Total(); math.Abs(total-13.75) > 1e-12 {
+		t.Fatalf("expected total 13.75, got %g", total)
+	}
+	checkDynamicWeighted(t, d, 1<<32)
+
+	d.Set(3, 1)
+	for i := 0; i < d.Len(); i++ {
+		if i != 3 {
+			d.Set(i, 0)
+		}
+	}
+	for i := uint64(0); i < 1000; i++ {
+		if got := d.Sample(i); got != 3 {
+			t.Fatalf("only index 3 has weight, but sample %d gave %d", i, got)
+		}
+	}
+}
+
+func Test_DynamicWeightedInvalid(t *testing.T) {
+	if _, err := NewDynamicWeighted(0, 0, NewSequence(0)); err == nil {
+		t.Fatalf("expected error for zero indexes")
+	}
+	if _, err := NewDynamicWeighted(1, 0, nil); err == nil {
+		t.Fatalf("expected error for nil Sequence")
+	}
+	d, _ := NewDynamicWeighted(3, 0, NewSequence(0))
+	for _, bad := range []struct {
+		index  int
+		weight float64
+	}{{-1, 1}, {3, 1}, {0, -1}, {0, math.NaN()}, {0, math.Inf(1)}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Set(%d, %g): expected panic", bad.index, bad.weight)
+				}
+			}()
+			d.Set(bad.index, bad.weight)
+		}()
+	}
+}
+
+func Test_DynamicWeightedDrift(t *testing.T) {
+	// adding and removing weights leaves rounding error in the tree,
+	// which mustn't look like a non-zero weight.
+	d, _ := NewDynamicWeighted(4, 0, NewSequence(0))
+	d.Set(0, .1)
+	d.Set(1, .2)
+	d.Set(0, 0)
+	d.Set(1, 0)
+	if total := d.Total(); total != 0 {
+		t.Fatalf("all weights zero: expected total 0, got %g", total)
+	}
+	for i := uint64(0); i < 100; i++ {
+		if got := d.Sample(i); got != -1 {
+			t.Fatalf("all weights zero: sample %d returned %d", i, got)
+		}
+	}
+	// with many updates since the last rebuild, only the one
+	// non-zero weight is ever sampled.
+	d, _ = NewDynamicWeighted(1000, 0, NewSequence(0))
+	for i := 0; i < 500; i++ {
+		d.Set(i%10, float64(i%7)*0.1+0.1)
+	}
+	for i := 0; i < 10; i++ {
+		d.Set(i, 0)
+	}
+	d.Set(500, 1e-300)
+	for i := uint64(0); i < 1000; i++ {
+		if got := d.Sample(i); got != 500 {
+			t.Fatalf("sample %d: expected only non-zero index 500, got %d", i, got)
+		}
+	}
+}
+
+func Test_DynamicWeightedQuantile(t *testing.T) {
+	d, _ := NewDynamicWeighted(1000, 0, NewSequence(0))
+	if got := d.Quantile(0.5); got != -1 {
+		t.Fatalf("expected -1 with all weights zero, got %d", got)
+	}
+	// setting weights back to zero must not leave rounding residue
+	// that looks like a non-zero total.
+	zeroed, _ := NewDynamicWeighted(4, 0, NewSequence(0))
+	zeroed.Set(0, .1)
+	zeroed.Set(1, .2)
+	zeroed.Set(0, 0)
+	zeroed.Set(1, 0)
+	for _, p := range []float64{0, 0.5, 1} {
+		if got := zeroed.Quantile(p); got != -1 {
+			t.Fatalf("weights set back to zero: Quantile(%g) returned %d, expected -1", p, got)
+		}
+	}
+	if got := zeroed.CDF(3); got != 0 {
+		t.Fatalf("weights set back to zero: CDF(3) returned %g, expected 0", got)
+	}
+	src := NewSequence(1)
+	for i := 0; i < d.Len(); i++ {
+		d.Set(i, float64(src.Uint64()%100+1)/7)
+	}
+	if got := d.Quantile(0); got != 0 {
+		t.Errorf("Quantile(0): expected 0, got %d", got)
+	}
+	if got := d.Quantile(1); got != d.Len()-1 {
+		t.Errorf("Quantile(1): expected %d, got %d", d.Len()-1, got)
+	}
+	prev := 0.0
+	for k := 0; k < d.Len(); k++ {
+		cdf := d.CDF(k)
+		if !(cdf > prev) {
+			t.Fatalf("CDF(%d) = %g, not above CDF(%d) = %g", k, cdf, k-1, prev)
+		}
+		prev = cdf
+		if got := d.Quantile(cdf); got != k {
+			t.Fatalf("Quantile(CDF(%d) = %g): expected %d, got %d", k, cdf, k, got)
+		}
+		if k > 0 {
+			// just past the previous CDF is still in this index.
+			if got := d.Quantile(math.Nextafter(d.CDF(k-1), 2)); got != k {
+				t.Fatalf("Quantile just past CDF(%d): expected %d, got %d", k-1, k, got)
+			}
+		}
+	}
+
+	// zero weights are never the answer, except for Quantile(0).
+	d.Set(0, 0)
+	d.Set(5, 0)
+	if got := d.Quantile(0); got != 0 {
+		t.Errorf("Quantile(0): expected 0, got %d", got)
+	}
+	if got := d.Quantile(math.SmallestNonzeroFloat64); got != 1 {
+		t.Errorf("Quantile of tiny p: expected 1, got %d", got)
+	}
+	if got := d.Quantile(d.CDF(4)); got != 4 {
+		t.Errorf("Quantile(CDF(4)): expected 4, got %d", got)
+	}
+	if got := d.Quantile(math.Nextafter(d.CDF(4), 2)); got != 6 {
+		t.Errorf("Quantile just past CDF(4): expected 6, got %d", got)
+	}
+	for _, bad := range []float64{-0.1, 1.1, math.NaN()} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Quantile(%g): expected panic", bad)
+				}
+			}()
+			d.Quantile(bad)
+		}()
+	}
+}
+
+func BenchmarkDynamicWeighted(b *testing.B) {
+	for _, size := range []int{16, 1024, 65536} {
+		weights := make([]float64, size)
+		for i := range weights {
+			weights[i] = float64(i%7 + 1)
+		}
+		src := NewSequence(0)
+		d, _ := NewDynamicWeighted(size, 0, src)
+		for i, w := range weights {
+			d.Set(i, w)
+		}
+		a := newAliasTable(weights)
+		b.Run(fmt.Sprintf("Sample%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = d.Sample(uint64(i))
+			}
+		})
+		b.Run(fmt.Sprintf("Set%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				d.Set(i%size, float64(i%5))
+			}
+		})
+		b.Run(fmt.Sprintf("Alias%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = a.sample(src.BitsAt(OffsetFor(SequenceDynamicWeighted, 0, 0, uint64(i))))
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Version bytes for the encoded forms of generators.
+const (
+	permutationEncodingVersion = 1
+	zipfEncodingVersion        = 1
+)
+
+// Sizes of the encoded forms of generators.
+const (
+	sequenceEncodedSize    = 16 + 16                                         // key, offset
+	permutationEncodedSize = 1 + sequenceEncodedSize + 4 + 8 + 8 + 8         // version, sequence, seed, max, counter, rounds
+	zipfEncodedSize        = 1 + sequenceEncodedSize + 4 + 8 + 8 + 8 + 8 + 1 // version, sequence, seed, q, v, max, idx, open
+)
+
+// permutationMaxEncodedRounds is the most rounds an encoded Permutation
+// can have. Decoding allocates and computes a key per round, so without
+// a limit, a few bytes of input could demand gigabytes. The default is
+// at most 6*63 rounds, so this leaves plenty of room for explicit
+// counts.
+const permutationMaxEncodedRounds = 1 << 12
+
+// encodeSequence writes the key and offset of src into buf, which must
+// be sequenceEncodedSize bytes. Only Sequences from NewSequence (or
+// NewSequenceFromSeed) can be encoded, since for other Sequences we have
+// no way to know what state would reproduce them.
+func encodeSequence(buf []byte, src Sequence) error {
+	s, ok := src.(*aesSequence128)
+	if !ok {
+		return fmt.Errorf("can't encode a %T; only Sequences from NewSequence can be encoded", src)
+	}
+	copy(buf[:16], s.key[:])
+	binary.LittleEndian.PutUint64(buf[16:24], s.offset.Lo)
+	binary.LittleEndian.PutUint64(buf[24:32], s.offset.Hi)
+	return nil
+}
+
+// decodeSequence creates a Sequence from the key and offset in buf.
+func decodeSequence(buf []byte) Sequence {
+	var key [16]byte
+	copy(key[:], buf[:16])
+	s := newAESSequence(key)
+	s.offset.Lo = binary.LittleEndian.Uint64(buf[16:24])
+	s.offset.Hi = binary.LittleEndian.Uint64(buf[24:32])
+	return s
+}
+
+// GobEncode encodes everything needed to reproduce p's output, including
+// its position, so a decoded Permutation continues from where p was. The
+// Permutation must use a Sequence from NewSequence, no RoundFunc, and at
+// most 4096 rounds.
+func (p *Permutation) GobEncode() ([]byte, error) {
+	if p.round != nil {
+		return nil, errors.New("can't encode a Permutation with a RoundFunc")
+	}
+	if p.lo != 0 {
+		return nil, errors.New("can't encode a Permutation from NewPermutationRange")
+	}
+	if p.rounds > permutationMaxEncodedRounds {
+		return nil, fmt.Errorf("can't encode a Permutation with more than %d rounds, got %d", permutationMaxEncodedRounds, p.rounds)
+	}
+	buf := make([]byte, permutationEncodedSize)
+	buf[0] = permutationEncodingVersion
+	if err := encodeSequence(buf[1:], p.src); err != nil {
+		return nil, err
+	}
+	b := buf[1+sequenceEncodedSize:]
+	binary.LittleEndian.PutUint32(b[0:4], p.permSeed)
+	binary.LittleEndian.PutUint64(b[4:12], uint64(p.max))
+	binary.LittleEndian.PutUint64(b[12:20], uint64(p.counter))
+	binary.LittleEndian.PutUint64(b[20:28], uint64(p.rounds))
+	return buf, nil
+}
+
+// GobDecode replaces p with the Permutation encoded in data. The decoded
+// Permutation has its own new Sequence, with the same key as the
+// original's.
+func (p *Permutation) GobDecode(data []byte) error {
+	if len(data) != permutationEncodedSize {
+		return fmt.Errorf("encoded Permutation must be %d bytes, got %d", permutationEncodedSize, len(data))
+	}
+	if data[0] != permutationEncodingVersion {
+		return fmt.Errorf("unknown Permutation encoding version %d", data[0])
+	}
+	src := decodeSequence(data[1:])
+	b := data[1+sequenceEncodedSize:]
+	seed := binary.LittleEndian.Uint32(b[0:4])
+	max := int64(binary.LittleEndian.Uint64(b[4:12]))
+	counter := int64(binary.LittleEndian.Uint64(b[12:20]))
+	rounds := int64(binary.LittleEndian.Uint64(b[20:28]))
+	if rounds < 1 || rounds > permutationMaxEncodedRounds {
+		return fmt.Errorf("invalid encoded Permutation: %d rounds", rounds)
+	}
+	decoded, err := newPermutation(max, seed, int(rounds), src, nil, 0)
+	if err != nil {
+		return fmt.Errorf("invalid encoded Permutation: %v", err)
+	}
+	decoded.counter = counter
+	*p = *decoded
+	return nil
+}
+
+// permutationTextPrefix starts the text form of a Permutation; the
+// number is the binary encoding's version.
+const permutationTextPrefix = "permutation.v1:"
+
+// MarshalText encodes p as text, for embedding in JSON configs or log
+// lines. The text is "permutation.v1:", then p's max in decimal, then a
+// colon and the base64 of the GobEncode form, so a reader can see which
+// version and what size of Permutation it is. The same restrictions as
+// for GobEncode apply.
+func (p *Permutation) MarshalText() ([]byte, error) {
+	data, err := p.GobEncode()
+	if err != nil {
+		return nil, err
+	}
+	text := append([]byte(permutationTextPrefix), strconv.FormatInt(p.max, 10)...)
+	text = append(text, ':')
+	encoded := make([]byte, base64.StdEncoding.EncodedLen(len(data)))
+	base64.StdEncoding.Encode(encoded, data)
+	return append(text, encoded...), nil
+}
+
+// UnmarshalText replaces p with the Permutation encoded in text by
+// MarshalText.
+func (p *Permutation) UnmarshalText(text []byte) error {
+	if !bytes.HasPrefix(text, []byte(permutationTextPrefix)) {
+		return fmt.Errorf("encoded Permutation text must start with %q", permutationTextPrefix)
+	}
+	rest := text[len(permutationTextPrefix):]
+	colon := bytes.IndexByte(rest, ':')
+	if colon < 0 {
+		return errors.New("invalid encoded Permutation text: no colon after max")
+	}
+	max, err := strconv.ParseInt(string(rest[:colon]), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid encoded Permutation text: max: %v", err)
+	}
+	data, err := base64.StdEncoding.DecodeString(string(rest[colon+1:]))
+	if err != nil {
+		return fmt.Errorf("invalid encoded Permutation text: %v", err)
+	}
+	var decoded Permutation
+	if err := decoded.GobDecode(data); err != nil {
+		return err
+	}
+	if decoded.max != max {
+		return fmt.Errorf("invalid encoded Permutation text: max %d doesn't match encoded max %d", max, decoded.max)
+	}
+	*p = decoded
+	return nil
+}
+
+// GobEncode encodes everything needed to reproduce z's output, including
+// its position, so a decoded Zipf continues from where z was. The Zipf
+// must use a Sequence from NewSequence.
+func (z *Zipf) GobEncode() ([]byte, error) {
+	buf := make([]byte, zipfEncodedSize)
+	buf[0] = zipfEncodingVersion
+	if err := encodeSequence(buf[1:], z.src); err != nil {
+		return nil, err
+	}
+	b := buf[1+sequenceEncodedSize:]
+	binary.LittleEndian.PutUint32(b[0:4], z.seed)
+	binary.LittleEndian.PutUint64(b[4:12], math.Float64bits(z.q))
+	binary.LittleEndian.PutUint64(b[12:20], math.Float64bits(z.v))
+	binary.LittleEndian.PutUint64(b[20:28], math.Float64bits(z.max))
+	binary.LittleEndian.PutUint64(b[28:36], z.idx)
+	if z.open {
+		b[36] = 1
+	}
+	return buf, nil
+}
+
+// GobDecode replaces z with the Zipf encoded in data. The decoded Zipf
+// has its own new Sequence, with the same key as the original's.
+func (z *Zipf) GobDecode(data []byte) error {
+	if len(data) != zipfEncodedSize {
+		return fmt.Errorf("encoded Zipf must be %d bytes, got %d", zipfEncodedSize, len(data))
+	}
+	if data[0] != zipfEncodingVersion {
+		return fmt.Errorf("unknown Zipf encoding version %d", data[0])
+	}
+	src := decodeSequence(data[1:])
+	b := data[1+sequenceEncodedSize:]
+	seed := binary.LittleEndian.Uint32(b[0:4])
+	q := math.Float64frombits(binary.LittleEndian.Uint64(b[4:12]))
+	v := math.Float64frombits(binary.LittleEndian.Uint64(b[12:20]))
+	max := math.Float64frombits(binary.LittleEndian.Uint64(b[20:28]))
+	// a max of math.MaxUint64 is stored as 2^64.
+	if !(max >= 0 && max <= 1<<64) || max != math.Floor(max) {
+		return fmt.Errorf("invalid encoded Zipf: max %g", max)
+	}
+	decoded, err := newZipf(q, v, saturateUint64(max), seed, src)
+	if err != nil {
+		return fmt.Errorf("invalid encoded Zipf: %v", err)
+	}
+	decoded.idx = binary.LittleEndian.Uint64(b[28:36])
+	decoded.open = b[36] != 0
+	*z = *decoded
+	return nil
+}
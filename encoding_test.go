@@ -0,0 +1,179 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func Test_PermutationGob(t *testing.T) {
+	p, err := NewPermutation(1000, 7, NewSequence(3))
+	if err != nil {
+		t.Fatalf("creating permutation: %v", err)
+	}
+	for i := 0; i < 500; i++ {
+		p.Next()
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(p); err != nil {
+		t.Fatalf("encoding: %v", err)
+	}
+	var decoded Permutation
+	if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+		t.Fatalf("decoding: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		if a, b := p.Next(), decoded.Next(); a != b {
+			t.Fatalf("value %d after decoding: expected %d, got %d", i, a, b)
+		}
+	}
+
+	custom, _ := NewPermutationWithRoundFunc(10, 0, 0, NewSequence(0), SequenceRoundFunc)
+	if _, err := custom.GobEncode(); err == nil {
+		t.Errorf("expected error encoding a Permutation with a RoundFunc")
+	}
+	reader, _ := NewReaderSequence(bytes.NewReader(make([]byte, 1<<16)))
+	other, _ := NewPermutation(10, 0, reader)
+	if _, err := other.GobEncode(); err == nil {
+		t.Errorf("expected error encoding a Permutation with a ReaderSequence")
+	}
+	data, _ := p.GobEncode()
+	if err := decoded.GobDecode(data[:len(data)-1]); err == nil {
+		t.Errorf("expected error decoding truncated data")
+	}
+	// a corrupt round count mustn't make decoding allocate and compute
+	// billions of keys.
+	binary.LittleEndian.PutUint64(data[len(data)-8:], 1<<31-1)
+	if err := decoded.GobDecode(data); err == nil {
+		t.Errorf("expected error decoding 2^31-1 rounds")
+	}
+	many, _ := NewPermutationWithRoundFunc(10, 0, permutationMaxEncodedRounds, NewSequence(0), nil)
+	data, err = many.GobEncode()
+	if err != nil {
+		t.Fatalf("encoding %d rounds: %v", permutationMaxEncodedRounds, err)
+	}
+	if err := decoded.GobDecode(data); err != nil || decoded.rounds != permutationMaxEncodedRounds {
+		t.Errorf("decoding %d rounds: got %d rounds, error %v", permutationMaxEncodedRounds, decoded.rounds, err)
+	}
+	tooMany, _ := NewPermutationWithRoundFunc(10, 0, permutationMaxEncodedRounds+1, NewSequence(0), nil)
+	if _, err := tooMany.GobEncode(); err == nil {
+		t.Errorf("expected error encoding %d rounds", permutationMaxEncodedRounds+1)
+	}
+	data[0] = 99
+	if err := decoded.GobDecode(data); err == nil {
+		t.Errorf("expected error decoding unknown version")
+	}
+}
+
+func Test_PermutationText(t *testing.T) {
+	p, err := NewPermutation(1000, 7, NewSequence(3))
+	if err != nil {
+		t.Fatalf("creating permutation: %v", err)
+	}
+	for i := 0; i < 500; i++ {
+		p.Next()
+	}
+	text, err := p.MarshalText()
+	if err != nil {
+		t.Fatalf("encoding: %v", err)
+	}
+	if !strings.HasPrefix(string(text), "permutation.v1:1000:") {
+		t.Fatalf("expected version and max at start of text, got %q", text)
+	}
+	var decoded Permutation
+	if err := decoded.UnmarshalText(text); err != nil {
+		t.Fatalf("decoding: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		if a, b := p.Next(), decoded.Next(); a != b {
+			t.Fatalf("value %d after decoding: expected %d, got %d", i, a, b)
+		}
+	}
+
+	// and embedded in JSON.
+	config := struct{ Perm *Permutation }{p}
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("encoding JSON: %v", err)
+	}
+	var decodedConfig struct{ Perm *Permutation }
+	if err := json.Unmarshal(data, &decodedConfig); err != nil {
+		t.Fatalf("decoding JSON %s: %v", data, err)
+	}
+	for i := int64(0); i < 1000; i++ {
+		if a, b := p.Nth(i), decodedConfig.Perm.Nth(i); a != b {
+			t.Fatalf("Nth(%d) after JSON decoding: expected %d, got %d", i, a, b)
+		}
+	}
+
+	for i := 0; i < len(text); i++ {
+		if err := decoded.UnmarshalText(text[:i]); err == nil {
+			t.Fatalf("expected error decoding text truncated to %q", text[:i])
+		}
+	}
+	for _, bad := range []string{
+		strings.Replace(string(text), "v1", "v2", 1),
+		strings.Replace(string(text), ":1000:", ":999:", 1),
+		strings.Replace(string(text), ":1000:", ":x:", 1),
+		string(text) + "!",
+	} {
+		if err := decoded.UnmarshalText([]byte(bad)); err == nil {
+			t.Errorf("expected error decoding %q", bad)
+		}
+	}
+	custom, _ := NewPermutationWithRoundFunc(10, 0, 0, NewSequence(0), SequenceRoundFunc)
+	if _, err := custom.MarshalText(); err == nil {
+		t.Errorf("expected error encoding a Permutation with a RoundFunc")
+	}
+}
+
+func Test_ZipfGob(t *testing.T) {
+	for _, open := range []bool{false, true} {
+		newZ := NewZipfClosed
+		if open {
+			newZ = NewZipfOpen
+		}
+		z, err := newZ(1.3, 1.5, 100, 2, NewSequence(5))
+		if err != nil {
+			t.Fatalf("creating zipf: %v", err)
+		}
+		for i := 0; i < 500; i++ {
+			z.Next()
+		}
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(z); err != nil {
+			t.Fatalf("encoding: %v", err)
+		}
+		var decoded Zipf
+		if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+			t.Fatalf("decoding: %v", err)
+		}
+		for i := 0; i < 100; i++ {
+			if a, b := z.Next(), decoded.Next(); a != b {
+				t.Fatalf("open %t: value %d after decoding: expected %d, got %d", open, i, a, b)
+			}
+		}
+		a, _ := z.WithMax(10)
+		b, _ := decoded.WithMax(10)
+		if x, y := a.Nth(3), b.Nth(3); x != y {
+			t.Fatalf("open %t: resized zipfs differ: %d vs %d", open, x, y)
+		}
+	}
+}
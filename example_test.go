@@ -0,0 +1,91 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia_test
+
+import (
+	"fmt"
+
+	"github.com/molecula/apophenia"
+)
+
+func ExampleNewSequence() {
+	// A Sequence is a rand.Source64, but you can also get the bits at
+	// any offset directly, in any order.
+	seq := apophenia.NewSequence(42)
+	offset := apophenia.OffsetFor(apophenia.SequenceUser1, 0, 0, 7)
+	fmt.Println(seq.BitsAt(offset))
+	fmt.Println(seq.BitsAt(offset) == apophenia.NewSequence(42).BitsAt(offset))
+	// Output:
+	// 0xa17285dfdfdb1bd0dda2331a9a0e82eb
+	// true
+}
+
+func ExampleNewPermutation() {
+	// Shuffle the numbers 0 through 9.
+	perm, err := apophenia.NewPermutation(10, 0, apophenia.NewSequence(1))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	values := make([]int64, 10)
+	for i := range values {
+		values[i] = perm.Next()
+	}
+	fmt.Println(values)
+	// Nth seeks directly to a position.
+	fmt.Println(perm.Nth(3))
+	// Output:
+	// [6 1 9 0 4 2 7 5 3 8]
+	// 0
+}
+
+func ExampleNewZipf() {
+	// Values from 0 to 100, with smaller values much more common.
+	z, err := apophenia.NewZipf(1.5, 1, 100, 0, apophenia.NewSequence(1))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	values := make([]uint64, 10)
+	for i := range values {
+		values[i] = z.Next()
+	}
+	fmt.Println(values)
+	// The value for a given index is always the same.
+	fmt.Println(z.Nth(4))
+	// Output:
+	// [3 73 16 1 1 19 0 47 0 0]
+	// 1
+}
+
+func ExampleNewWeighted() {
+	// Bits which are set a quarter of the time.
+	w, err := apophenia.NewWeighted(apophenia.NewSequence(1))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	set := 0
+	for i := uint64(0); i < 32; i++ {
+		bit := w.Bit(apophenia.OffsetFor(apophenia.SequenceWeighted, 0, 0, i), 1, 4)
+		fmt.Print(bit)
+		set += int(bit)
+	}
+	fmt.Println()
+	fmt.Println(set, "of 32 set")
+	// Output:
+	// 10000010000011010010100001011000
+	// 10 of 32 set
+}
@@ -0,0 +1,93 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"fmt"
+	"math"
+)
+
+// Exponential produces values following the exponential distribution
+// with the given rate, which has mean 1/rate. It's the distribution of
+// times between events which occur independently at a constant rate.
+type Exponential struct {
+	src  Sequence
+	seed uint32
+	rate float64
+}
+
+// NewExponential returns an Exponential with the given rate, which must
+// be positive and finite.
+func NewExponential(rate float64, seed uint32, src Sequence) (*Exponential, error) {
+	if math.IsNaN(rate) || math.IsInf(rate, 0) || rate <= 0 {
+		return nil, fmt.Errorf("need finite rate > 0 (got %g) for exponential distribution", rate)
+	}
+	if src == nil {
+		return nil, fmt.Errorf("need a usable PRNG apophenia.Sequence")
+	}
+	return &Exponential{src: src, seed: seed, rate: rate}, nil
+}
+
+// NewExponentialFromSeed is NewExponential with seed.Uint32() as the
+// seed.
+func NewExponentialFromSeed(rate float64, seed Seed, src Sequence) (*Exponential, error) {
+	return NewExponential(rate, seed.Uint32(), src)
+}
+
+// Nth returns the value for the given index.
+func (e *Exponential) Nth(index uint64) float64 {
+	u := unitFloat64(e.src.BitsAt(OffsetFor(SequenceExponential, e.seed, 0, index)).Lo)
+	return -math.Log1p(-u) / e.rate
+}
+
+// DiscreteExponential produces the integer parts of the values of an
+// Exponential, which follow the geometric distribution of the number
+// of failures before a success, with success probability 1-e^-rate.
+// With the same seed and Sequence, it produces the floors of the
+// corresponding Exponential's values.
+type DiscreteExponential struct {
+	exp Exponential
+}
+
+// NewDiscreteExponential returns a DiscreteExponential with the given
+// rate, which must be positive and finite.
+func NewDiscreteExponential(rate float64, seed uint32, src Sequence) (*DiscreteExponential, error) {
+	e, err := NewExponential(rate, seed, src)
+	if err != nil {
+		return nil, err
+	}
+	return &DiscreteExponential{exp: *e}, nil
+}
+
+// NewDiscreteExponentialFromSeed is NewDiscreteExponential with
+// seed.Uint32() as the seed.
+func NewDiscreteExponentialFromSeed(rate float64, seed Seed, src Sequence) (*DiscreteExponential, error) {
+	return NewDiscreteExponential(rate, seed.Uint32(), src)
+}
+
+// Nth returns the value for the given index.
+func (d *DiscreteExponential) Nth(index uint64) uint64 {
+	x := d.exp.Nth(index)
+	if x >= 1<<64 {
+		return math.MaxUint64
+	}
+	return uint64(x)
+}
+
+// Probability returns the probability of the given value, which is
+// e^(-rate*value) * (1-e^-rate).
+func (d *DiscreteExponential) Probability(value uint64) float64 {
+	return math.Exp(-d.exp.rate*float64(value)) * -math.Expm1(-d.exp.rate)
+}
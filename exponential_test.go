@@ -0,0 +1,85 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_Exponential(t *testing.T) {
+	const rate, samples = 3.0, 100000
+	e, err := NewExponential(rate, 0, NewSequence(0))
+	if err != nil {
+		t.Fatalf("creating exponential: %v", err)
+	}
+	sum, over := 0.0, 0
+	for i := uint64(0); i < samples; i++ {
+		x := e.Nth(i)
+		if x < 0 {
+			t.Fatalf("index %d: negative value %g", i, x)
+		}
+		sum += x
+		if x > 1 {
+			over++
+		}
+	}
+	if mean := sum / samples; math.Abs(mean-1/rate) > 5/rate/math.Sqrt(samples) {
+		t.Errorf("mean %g, expected about %g", mean, 1/rate)
+	}
+	// P(X > 1) = e^-rate
+	exp := math.Exp(-rate) * samples
+	if math.Abs(float64(over)-exp) > 5*math.Sqrt(exp) {
+		t.Errorf("%d values over 1, expected about %.0f", over, exp)
+	}
+	for _, rate := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		if _, err := NewExponential(rate, 0, NewSequence(0)); err == nil {
+			t.Errorf("rate %g: expected error", rate)
+		}
+	}
+}
+
+func Test_DiscreteExponential(t *testing.T) {
+	const rate, samples = 0.5, 100000
+	src := NewSequence(0)
+	d, err := NewDiscreteExponential(rate, 1, src)
+	if err != nil {
+		t.Fatalf("creating discrete exponential: %v", err)
+	}
+	e, _ := NewExponential(rate, 1, src)
+	counts := make(map[uint64]int)
+	for i := uint64(0); i < samples; i++ {
+		v := d.Nth(i)
+		if exp := math.Floor(e.Nth(i)); float64(v) != exp {
+			t.Fatalf("index %d: got %d, expected floor of exponential value, %g", i, v, exp)
+		}
+		counts[v]++
+	}
+	total := 0.0
+	for v := uint64(0); v < 60; v++ {
+		p := d.Probability(v)
+		total += p
+		exp := p * samples
+		if math.Abs(float64(counts[v])-exp) > 5*math.Sqrt(exp)+1 {
+			t.Errorf("value %d: got %d, expected about %.0f", v, counts[v], exp)
+		}
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("probabilities of 0..59 sum to %g, expected 1", total)
+	}
+	if _, err := NewDiscreteExponential(0, 0, src); err == nil {
+		t.Errorf("rate 0: expected error")
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import "math/bits"
+
+// FixedPointCount returns the number of positions whose value is the
+// position itself, so Nth(x) == x; for a Permutation from
+// NewPermutationRange, that's Nth(x) == lo+x. A uniformly random
+// permutation has one fixed point on average, whatever its size, and the
+// count follows a Poisson distribution with mean 1, so many more than
+// that suggests a poor shuffle. It computes every value, taking time
+// proportional to max; see EstimateFixedPoints for large permutations.
+func (p *Permutation) FixedPointCount() int64 {
+	var count int64
+	for x := int64(0); x < p.max; x++ {
+		if p.permute(x) == x {
+			count++
+		}
+	}
+	return count
+}
+
+// EstimateFixedPoints estimates FixedPointCount from a sample of
+// pseudo-random positions, chosen with replacement, as the fraction of
+// them which are fixed points times max. The estimate is unbiased, but
+// since a good shuffle has so few fixed points, most samples don't find
+// any, so a single estimate is mostly useful for spotting permutations
+// with far too many. The positions are derived from p's seed, so the
+// estimate is repeatable. If samples is less than 1, the estimate is 0.
+func (p *Permutation) EstimateFixedPoints(samples int) float64 {
+	if samples < 1 {
+		return 0
+	}
+	found := 0
+	for i := 0; i < samples; i++ {
+		x, _ := bits.Mul64(p.bitsAt(OffsetFor(SequenceFixedPoints, p.permSeed, 0, uint64(i))).Lo, uint64(p.max))
+		if p.permute(int64(x)) == int64(x) {
+			found++
+		}
+	}
+	return float64(found) / float64(samples) * float64(p.max)
+}
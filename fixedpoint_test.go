@@ -0,0 +1,101 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_FixedPointCount(t *testing.T) {
+	perms := 400
+	if testing.Short() {
+		perms = 100
+	}
+	// fixed points of random permutations are Poisson with mean 1, so
+	// the mean over perms permutations has standard deviation
+	// 1/sqrt(perms).
+	total := int64(0)
+	for seed := 0; seed < perms; seed++ {
+		p, err := NewPermutation(1000, uint32(seed), NewSequence(int64(seed)))
+		if err != nil {
+			t.Fatalf("creating permutation: %v", err)
+		}
+		n := p.FixedPointCount()
+		if seed < 10 {
+			expected := int64(0)
+			for x := int64(0); x < 1000; x++ {
+				if p.NthStateless(x) == x {
+					expected++
+				}
+			}
+			if n != expected {
+				t.Fatalf("seed %d: FixedPointCount %d, but NthStateless finds %d", seed, n, expected)
+			}
+		}
+		total += n
+	}
+	mean := float64(total) / float64(perms)
+	if sigma := 1 / math.Sqrt(float64(perms)); math.Abs(mean-1) > 3*sigma {
+		t.Errorf("mean fixed points %.3f, expected 1 +/- %.3f", mean, 3*sigma)
+	}
+
+	// a range permutation's fixed points are relative to lo.
+	r, _ := NewPermutationRange(500, 1500, 3, NewSequence(3))
+	p, _ := NewPermutation(1000, 3, NewSequence(3))
+	if got, exp := r.FixedPointCount(), p.FixedPointCount(); got != exp {
+		t.Errorf("range permutation: expected %d fixed points, got %d", exp, got)
+	}
+
+	// a round function which never swaps yields the identity.
+	never := func(offset Uint128, src Sequence) Uint128 { return Uint128{} }
+	identity, _ := NewPermutationWithRoundFunc(100, 0, 0, nil, never)
+	if got := identity.FixedPointCount(); got != 100 {
+		t.Errorf("identity permutation: expected 100 fixed points, got %d", got)
+	}
+	if got := identity.EstimateFixedPoints(50); got != 100 {
+		t.Errorf("identity permutation: expected estimate 100, got %g", got)
+	}
+}
+
+func Test_EstimateFixedPoints(t *testing.T) {
+	const max, samples = 10000, 2000
+	perms := 200
+	if testing.Short() {
+		perms = 50
+	}
+	// each estimate is max/samples times a count which is roughly
+	// binomial with mean samples/max, so its variance is about
+	// max/samples.
+	sum := 0.0
+	for seed := 0; seed < perms; seed++ {
+		p, _ := NewPermutation(max, uint32(seed), NewSequence(int64(seed)))
+		e := p.EstimateFixedPoints(samples)
+		if seed < 10 {
+			if again := p.EstimateFixedPoints(samples); again != e {
+				t.Fatalf("seed %d: estimates %g and %g differ", seed, e, again)
+			}
+		}
+		sum += e
+	}
+	mean := sum / float64(perms)
+	if sigma := math.Sqrt(float64(max) / samples / float64(perms)); math.Abs(mean-1) > 5*sigma {
+		t.Errorf("mean estimate %.3f, expected 1 +/- %.3f", mean, 5*sigma)
+	}
+	p, _ := NewPermutation(max, 0, NewSequence(0))
+	if got := p.EstimateFixedPoints(0); got != 0 {
+		t.Errorf("0 samples: expected 0, got %g", got)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import "math"
+
+// normalAt returns a standard normal variate from the bits at offset,
+// using the Box-Muller transform.
+func normalAt(offset Uint128, src Sequence) float64 {
+	bits := src.BitsAt(offset)
+	// 1-u is in (0,1], so the log is finite.
+	u1, u2 := 1-unitFloat64(bits.Lo), unitFloat64(bits.Hi)
+	return math.Sqrt(-2*math.Log(u1)) * math.Cos(2*math.Pi*u2)
+}
+
+// gammaAt returns a Gamma(shape, 1) variate, using the bits starting at
+// offset, using the method of:
+//
+// "A simple method for generating gamma variables"
+// G. Marsaglia, W. W. Tsang [2000]
+//
+// Each attempt uses two values, incrementing offset.Hi, which is to say
+// the iteration part of the offset, for each. Fewer than 5% of attempts
+// are rejected for any shape.
+func gammaAt(shape float64, offset Uint128, src Sequence) float64 {
+	if shape < 1 {
+		// Gamma(a) = Gamma(a+1) * U^(1/a). Use the first value for
+		// U, and the rest for Gamma(a+1).
+		u := 1 - unitFloat64(src.BitsAt(offset).Lo)
+		offset.Hi++
+		return gammaAt(shape+1, offset, src) * math.Pow(u, 1/shape)
+	}
+	d := shape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := normalAt(offset, src)
+		offset.Hi++
+		u := 1 - unitFloat64(src.BitsAt(offset).Lo)
+		offset.Hi++
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		if math.Log(u) < 0.5*x*x+d-d*v+d*math.Log(v) {
+			return d * v
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_Gamma(t *testing.T) {
+	const samples = 100000
+	src := NewSequence(0)
+	for _, shape := range []float64{0.3, 1, 2.5, 100, 1 << 40} {
+		sum, sumSq := 0.0, 0.0
+		for i := uint64(0); i < samples; i++ {
+			x := gammaAt(shape, OffsetFor(SequenceUser1, 0, 0, i), src)
+			if !(x > 0) {
+				t.Fatalf("shape %g: sample %d is %g", shape, i, x)
+			}
+			sum += x
+			sumSq += x * x
+		}
+		// Gamma(shape, 1) has mean and variance shape.
+		mean := sum / samples
+		variance := sumSq/samples - mean*mean
+		if math.Abs(mean-shape) > 5*math.Sqrt(shape/samples) {
+			t.Errorf("shape %g: mean %g", shape, mean)
+		}
+		if math.Abs(variance-shape) > 0.05*shape {
+			t.Errorf("shape %g: variance %g", shape, variance)
+		}
+	}
+}
+
+func Test_Normal(t *testing.T) {
+	const samples = 100000
+	src := NewSequence(0)
+	sum, sumSq := 0.0, 0.0
+	for i := uint64(0); i < samples; i++ {
+		x := normalAt(OffsetFor(SequenceUser1, 0, 0, i), src)
+		sum += x
+		sumSq += x * x
+	}
+	mean := sum / samples
+	variance := sumSq/samples - mean*mean
+	if math.Abs(mean) > 5/math.Sqrt(samples) || math.Abs(variance-1) > 0.02 {
+		t.Errorf("expected mean 0 and variance 1, got %g and %g", mean, variance)
+	}
+}
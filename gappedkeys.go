@@ -0,0 +1,82 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"fmt"
+	"math"
+)
+
+// GappedKeyGen generates a set of distinct keys from [0,maxKey), with the
+// rest missing, like the primary keys of a table which has had rows
+// deleted. The keys are the first Len values of a Permutation of
+// [0,maxKey), so they're spread evenly over the range, and Contains can
+// tell whether a given key is present without generating them all.
+type GappedKeyGen struct {
+	perm  *Permutation
+	count int64
+	next  int64
+}
+
+// NewGappedKeyGen creates a GappedKeyGen with int64(maxKey*fillRatio) of
+// the keys in [0,maxKey). The fillRatio must be in [0,1]; a fillRatio of
+// 1 always yields every key, even for a maxKey which a float64 can't
+// represent exactly.
+func NewGappedKeyGen(maxKey int64, fillRatio float64, seed uint32, src Sequence) (*GappedKeyGen, error) {
+	if !(fillRatio >= 0 && fillRatio <= 1) {
+		return nil, fmt.Errorf("need fill ratio in [0,1] (got %g) for gapped keys", fillRatio)
+	}
+	perm, err := NewPermutation(maxKey, seed, src)
+	if err != nil {
+		return nil, err
+	}
+	count := maxKey
+	if fillRatio < 1 {
+		count = int64(math.Min(float64(maxKey)*fillRatio, float64(maxKey-1)))
+	}
+	return &GappedKeyGen{perm: perm, count: count}, nil
+}
+
+// Len returns the number of keys.
+func (g *GappedKeyGen) Len() int64 {
+	return g.count
+}
+
+// Nth returns the nth key, for n in [0,Len()). It panics if n is out of
+// range.
+func (g *GappedKeyGen) Nth(n int64) int64 {
+	if n < 0 || n >= g.count {
+		panic(fmt.Sprintf("key index %d out of range [0,%d)", n, g.count))
+	}
+	return g.perm.NthStateless(n)
+}
+
+// Next returns the key after the last one Next returned, starting with
+// Nth(0), and true, or 0 and false once all Len keys have been returned.
+func (g *GappedKeyGen) Next() (int64, bool) {
+	if g.next >= g.count {
+		return 0, false
+	}
+	g.next++
+	return g.perm.NthStateless(g.next - 1), true
+}
+
+// Contains reports whether key is one of the keys.
+func (g *GappedKeyGen) Contains(key int64) bool {
+	if key < 0 || key >= g.perm.max {
+		return false
+	}
+	return g.perm.unpermute(key) < g.count
+}
@@ -0,0 +1,78 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_GappedKeyGen(t *testing.T) {
+	for _, c := range []struct {
+		maxKey    int64
+		fillRatio float64
+		count     int64
+	}{{1000, 0.7, 700}, {1000, 1, 1000}, {1000, 0, 0}, {999, 0.5, 499}, {1, 1, 1}} {
+		g, err := NewGappedKeyGen(c.maxKey, c.fillRatio, 3, NewSequence(0))
+		if err != nil {
+			t.Fatalf("max %d, ratio %g: %v", c.maxKey, c.fillRatio, err)
+		}
+		if g.Len() != c.count {
+			t.Fatalf("max %d, ratio %g: expected %d keys, got %d", c.maxKey, c.fillRatio, c.count, g.Len())
+		}
+		seen := make(map[int64]bool)
+		for i := int64(0); ; i++ {
+			key, ok := g.Next()
+			if !ok {
+				if i != c.count {
+					t.Fatalf("max %d, ratio %g: Next stopped after %d keys", c.maxKey, c.fillRatio, i)
+				}
+				break
+			}
+			if key < 0 || key >= c.maxKey {
+				t.Fatalf("max %d, ratio %g: key %d out of range", c.maxKey, c.fillRatio, key)
+			}
+			if seen[key] {
+				t.Fatalf("max %d, ratio %g: key %d repeated", c.maxKey, c.fillRatio, key)
+			}
+			seen[key] = true
+			if got := g.Nth(i); got != key {
+				t.Fatalf("max %d, ratio %g: Nth(%d) = %d, Next gave %d", c.maxKey, c.fillRatio, i, got, key)
+			}
+		}
+		for key := int64(-1); key <= c.maxKey; key++ {
+			if got := g.Contains(key); got != seen[key] {
+				t.Fatalf("max %d, ratio %g: Contains(%d) = %t, expected %t", c.maxKey, c.fillRatio, key, got, seen[key])
+			}
+		}
+	}
+	// a full set of a huge range stays exact.
+	g, _ := NewGappedKeyGen(1<<62+1, 1, 0, NewSequence(0))
+	if g.Len() != 1<<62+1 {
+		t.Errorf("ratio 1 of 2^62+1: expected 2^62+1 keys, got %d", g.Len())
+	}
+	g, _ = NewGappedKeyGen(1<<62+1, math.Nextafter(1, 0), 0, NewSequence(0))
+	if g.Len() > 1<<62 {
+		t.Errorf("ratio just under 1 of 2^62+1: expected fewer than all keys, got %d", g.Len())
+	}
+	for _, ratio := range []float64{-0.1, 1.1, math.NaN()} {
+		if _, err := NewGappedKeyGen(100, ratio, 0, NewSequence(0)); err == nil {
+			t.Errorf("ratio %g: expected error", ratio)
+		}
+	}
+	if _, err := NewGappedKeyGen(0, 0.5, 0, NewSequence(0)); err == nil {
+		t.Errorf("max 0: expected error")
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"fmt"
+	"math"
+)
+
+// DiscreteGaussian produces non-negative integers following a normal
+// distribution with the given mean and standard deviation, rounded to
+// the nearest integer. Values which would round below zero are 0, and
+// values which would round past 1<<64-1 are 1<<64-1.
+type DiscreteGaussian struct {
+	src    Sequence
+	seed   uint32
+	mean   float64
+	stddev float64
+}
+
+// NewDiscreteGaussian returns a DiscreteGaussian with the given mean,
+// which must be finite, and standard deviation, which must be positive
+// and finite.
+func NewDiscreteGaussian(mean, stddev float64, seed uint32, src Sequence) (*DiscreteGaussian, error) {
+	if math.IsNaN(mean) || math.IsInf(mean, 0) {
+		return nil, fmt.Errorf("need finite mean (got %g) for Gaussian distribution", mean)
+	}
+	if math.IsNaN(stddev) || math.IsInf(stddev, 0) || stddev <= 0 {
+		return nil, fmt.Errorf("need finite stddev > 0 (got %g) for Gaussian distribution", stddev)
+	}
+	if src == nil {
+		return nil, fmt.Errorf("need a usable PRNG apophenia.Sequence")
+	}
+	return &DiscreteGaussian{src: src, seed: seed, mean: mean, stddev: stddev}, nil
+}
+
+// NewDiscreteGaussianFromSeed is NewDiscreteGaussian with seed.Uint32()
+// as the seed.
+func NewDiscreteGaussianFromSeed(mean, stddev float64, seed Seed, src Sequence) (*DiscreteGaussian, error) {
+	return NewDiscreteGaussian(mean, stddev, seed.Uint32(), src)
+}
+
+// Nth returns the value for the given index.
+func (g *DiscreteGaussian) Nth(index uint64) uint64 {
+	x := math.Floor(g.mean + g.stddev*normalAt(OffsetFor(SequenceGaussian, g.seed, 0, index), g.src) + 0.5)
+	switch {
+	case x <= 0:
+		return 0
+	case x >= 1<<64:
+		return math.MaxUint64
+	}
+	return uint64(x)
+}
+
+// Probability returns the probability of the given value, which is the
+// probability that a normal value rounds to it.
+func (g *DiscreteGaussian) Probability(value uint64) float64 {
+	lo, hi := math.Inf(-1), math.Inf(1)
+	if value > 0 {
+		lo = float64(value) - 0.5
+	}
+	if value < math.MaxUint64 {
+		hi = float64(value) + 0.5
+	}
+	return g.cdf(hi) - g.cdf(lo)
+}
+
+// cdf returns the probability that an unrounded value is below x.
+func (g *DiscreteGaussian) cdf(x float64) float64 {
+	return 0.5 * math.Erfc((g.mean-x)/(g.stddev*math.Sqrt2))
+}
@@ -0,0 +1,67 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_DiscreteGaussian(t *testing.T) {
+	const mean, stddev, samples = 10.0, 3.0, 100000
+	g, err := NewDiscreteGaussian(mean, stddev, 0, NewSequence(0))
+	if err != nil {
+		t.Fatalf("creating gaussian: %v", err)
+	}
+	counts := make(map[uint64]int)
+	sum := 0.0
+	for i := uint64(0); i < samples; i++ {
+		v := g.Nth(i)
+		counts[v]++
+		sum += float64(v)
+	}
+	// rounding adds variance of 1/12, and clamping at 0 (3.3 sigma
+	// away) hardly matters.
+	if got := sum / samples; math.Abs(got-mean) > 5*stddev/math.Sqrt(samples) {
+		t.Errorf("mean %g, expected about %g", got, mean)
+	}
+	total := 0.0
+	for v := uint64(0); v < 40; v++ {
+		p := g.Probability(v)
+		total += p
+		exp := p * samples
+		if math.Abs(float64(counts[v])-exp) > 5*math.Sqrt(exp)+1 {
+			t.Errorf("value %d: got %d, expected about %.0f", v, counts[v], exp)
+		}
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("probabilities of 0..39 sum to %g, expected 1", total)
+	}
+	// values far below zero are clamped to 0.
+	low, _ := NewDiscreteGaussian(-100, 1, 0, NewSequence(0))
+	if v := low.Nth(3); v != 0 {
+		t.Errorf("mean -100: got %d, expected 0", v)
+	}
+	if p := low.Probability(0); p != 1 {
+		t.Errorf("mean -100: probability of 0 is %g, expected 1", p)
+	}
+	for _, c := range []struct{ mean, stddev float64 }{
+		{0, 0}, {0, -1}, {math.NaN(), 1}, {math.Inf(1), 1}, {0, math.Inf(1)},
+	} {
+		if _, err := NewDiscreteGaussian(c.mean, c.stddev, 0, NewSequence(0)); err == nil {
+			t.Errorf("mean %g, stddev %g: expected error", c.mean, c.stddev)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18
+
+package apophenia
+
+import "context"
+
+// GenerateIntoChannel calls gen repeatedly in a new goroutine, sending
+// the results to the returned channel, which has a buffer of bufSize
+// values, until ctx is done. Then the goroutine closes the channel and
+// exits, so ranging over the channel finishes once ctx is cancelled. A
+// value may have been generated but not sent when that happens; values
+// already in the buffer are still delivered. The gen function is only
+// called from the goroutine, which must therefore be the only user of
+// anything gen uses until the channel is closed.
+func GenerateIntoChannel[T any](ctx context.Context, gen func() T, bufSize int) <-chan T {
+	ch := make(chan T, bufSize)
+	go func() {
+		defer close(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- gen():
+			}
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,66 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18
+
+package apophenia
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func Test_GenerateIntoChannel(t *testing.T) {
+	before := runtime.NumGoroutine()
+	z, err := NewZipf(1.5, 2, 100, 0, NewSequence(0))
+	if err != nil {
+		t.Fatalf("creating Zipf: %v", err)
+	}
+	check, _ := NewZipf(1.5, 2, 100, 0, NewSequence(0))
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := GenerateIntoChannel(ctx, z.Next, 16)
+	for i := uint64(0); i < 1000; i++ {
+		if got, exp := <-ch, check.Nth(i); got != exp {
+			t.Fatalf("value %d: expected %d, got %d", i, exp, got)
+		}
+	}
+	cancel()
+	// the channel closes after at most the buffered values, plus one
+	// more which might have been in flight.
+	timeout := time.After(5 * time.Second)
+drain:
+	for n := 0; ; n++ {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				break drain
+			}
+			if n > 17 {
+				t.Fatalf("received %d values after cancellation", n)
+			}
+		case <-timeout:
+			t.Fatalf("channel not closed after cancellation")
+		}
+	}
+	// the goroutine exits after closing the channel, so give it a
+	// moment.
+	for i := 0; runtime.NumGoroutine() > before; i++ {
+		if i > 100 {
+			t.Fatalf("goroutine leaked: %d goroutines, started with %d", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
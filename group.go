@@ -0,0 +1,53 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import "encoding/binary"
+
+// SequenceGroup provides independent Sequences identified by name,
+// derived from a single seed, such as one per column of a synthetic
+// table. Each name's Sequence is an AES-based Sequence whose key is a
+// hash of the name, computed using the seed's Sequence, so different
+// names give Sequences as unrelated as Sequences with different seeds,
+// and the same name always gives the same values.
+type SequenceGroup struct {
+	master Sequence
+}
+
+// NewSequenceGroup returns a SequenceGroup derived from seed.
+func NewSequenceGroup(seed int64) *SequenceGroup {
+	return &SequenceGroup{master: NewSequence(seed)}
+}
+
+// NewSequenceGroupFromSeed returns a SequenceGroup derived from a Seed.
+// NewSequenceGroupFromSeed(SeedFromInt64(n)) is the same as
+// NewSequenceGroup(n).
+func NewSequenceGroupFromSeed(seed Seed) *SequenceGroup {
+	return &SequenceGroup{master: NewSequenceFromSeed(seed)}
+}
+
+// Stream returns the Sequence for the given name. Each call returns a
+// new Sequence, starting at the default position, so calling Stream
+// twice with the same name gives two Sequences which produce the same
+// values but can be used, and seeked, independently, including from
+// different goroutines. Stream itself uses the group's Sequence, so it
+// shouldn't be called from more than one goroutine at a time.
+func (g *SequenceGroup) Stream(name string) Sequence {
+	hashed := Hash128([]byte(name), 0, g.master)
+	var key [16]byte
+	binary.LittleEndian.PutUint64(key[:8], hashed.Lo)
+	binary.LittleEndian.PutUint64(key[8:], hashed.Hi)
+	return newAESSequence(key)
+}
@@ -0,0 +1,58 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func Test_SequenceGroup(t *testing.T) {
+	g := NewSequenceGroup(1)
+	a, b := g.Stream("age"), g.Stream("age")
+	for i := 0; i < 100; i++ {
+		if x, y := a.Uint64(), b.Uint64(); x != y {
+			t.Fatalf("value %d: same name gave %#x and %#x", i, x, y)
+		}
+	}
+	if NewSequenceGroup(2).Stream("age").BitsAt(Uint128{}) == g.Stream("age").BitsAt(Uint128{}) {
+		t.Fatalf("different group seeds gave the same stream")
+	}
+	// names which differ slightly still get distinct streams.
+	seen := make(map[Uint128]string)
+	for i := 0; i < 100000; i++ {
+		name := fmt.Sprintf("col%d", i)
+		v := g.Stream(name).BitsAt(Uint128{})
+		if prev, ok := seen[v]; ok {
+			t.Fatalf("names %q and %q gave the same stream", prev, name)
+		}
+		seen[v] = name
+	}
+	// streams are uncorrelated.
+	const n = 100000
+	age, income := g.Stream("age"), g.Stream("income")
+	var sx, sy, sxx, syy, sxy float64
+	for i := 0; i < n; i++ {
+		x, y := unitFloat64(age.Uint64()), unitFloat64(income.Uint64())
+		sx, sy = sx+x, sy+y
+		sxx, syy, sxy = sxx+x*x, syy+y*y, sxy+x*y
+	}
+	r := (n*sxy - sx*sy) / math.Sqrt((n*sxx-sx*sx)*(n*syy-sy*sy))
+	// 5 sigma for the correlation of independent values.
+	if math.Abs(r) > 5/math.Sqrt(n) {
+		t.Errorf("age and income streams have correlation %g", r)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"fmt"
+	"math/big"
+	"math/bits"
+)
+
+// HaltonMaxBase is the largest base NewHalton accepts. A scrambled
+// Halton stores a permutation of the digits for each digit position, so
+// its size grows with the base.
+const HaltonMaxBase = 1 << 16
+
+// Halton produces one dimension of a Halton sequence, a low-discrepancy
+// sequence in [0,1) useful for quasi-Monte Carlo integration. The Nth
+// value is the radical inverse of N in the given base: N's digits in
+// that base, reflected about the decimal point. Combining Haltons with
+// different prime bases gives points which cover a multi-dimensional
+// space much more evenly than independent random values.
+//
+// Unscrambled Halton sequences with large bases are strongly correlated
+// with each other for early indexes. A Halton created with a Sequence is
+// scrambled: each digit position has its own random permutation of the
+// non-zero digits, which breaks up those correlations without affecting
+// the sequence's even coverage of [0,1).
+type Halton struct {
+	base  uint64
+	perms [][]uint64 // perms[j][d] replaces digit d at position j; nil if unscrambled
+}
+
+// NewHalton returns a Halton with the given base, which must be a prime
+// no larger than HaltonMaxBase.
+// If src is nil, the sequence is unscrambled; otherwise, seed and src
+// determine the scrambling.
+func NewHalton(base int, seed uint32, src Sequence) (*Halton, error) {
+	if base < 2 || !big.NewInt(int64(base)).ProbablyPrime(0) {
+		return nil, fmt.Errorf("Halton base must be a prime, got %d", base)
+	}
+	if base > HaltonMaxBase {
+		return nil, fmt.Errorf("Halton base %d too large, limit is %d", base, HaltonMaxBase)
+	}
+	h := &Halton{base: uint64(base)}
+	if src == nil {
+		return h, nil
+	}
+	// enough digit positions for any uint64 index.
+	digits := 0
+	for x := ^uint64(0); x > 0; x /= h.base {
+		digits++
+	}
+	h.perms = make([][]uint64, digits)
+	for j := range h.perms {
+		perm := make([]uint64, base)
+		for d := range perm {
+			perm[d] = uint64(d)
+		}
+		// Fisher-Yates shuffle of the digits other than 0, since
+		// the infinitely many trailing zeroes need to stay zero.
+		for i := uint64(base - 1); i > 1; i-- {
+			r, _ := bits.Mul64(src.BitsAt(OffsetFor(SequenceHalton, seed, uint32(j), i)).Lo, i)
+			k := r + 1
+			perm[i], perm[k] = perm[k], perm[i]
+		}
+		h.perms[j] = perm
+	}
+	return h, nil
+}
+
+// Nth returns the value, in [0,1), for the given index.
+func (h *Halton) Nth(index uint64) float64 {
+	value := 0.0
+	scale := 1 / float64(h.base)
+	for j := 0; index > 0; j++ {
+		digit := index % h.base
+		index /= h.base
+		if h.perms != nil {
+			digit = h.perms[j][digit]
+		}
+		value += float64(digit) * scale
+		scale /= float64(h.base)
+	}
+	return value
+}
@@ -0,0 +1,102 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_HaltonKnownValues(t *testing.T) {
+	cases := []struct {
+		base   int
+		values []float64
+	}{
+		{2, []float64{0, 1.0 / 2, 1.0 / 4, 3.0 / 4, 1.0 / 8, 5.0 / 8, 3.0 / 8, 7.0 / 8}},
+		{3, []float64{0, 1.0 / 3, 2.0 / 3, 1.0 / 9, 4.0 / 9, 7.0 / 9, 2.0 / 9, 5.0 / 9}},
+		{5, []float64{0, 1.0 / 5, 2.0 / 5, 3.0 / 5, 4.0 / 5, 1.0 / 25, 6.0 / 25}},
+	}
+	for _, c := range cases {
+		h, err := NewHalton(c.base, 0, nil)
+		if err != nil {
+			t.Fatalf("creating Halton base %d: %v", c.base, err)
+		}
+		for i, exp := range c.values {
+			if got := h.Nth(uint64(i)); math.Abs(got-exp) > 1e-15 {
+				t.Errorf("base %d, index %d: expected %g, got %g", c.base, i, exp, got)
+			}
+		}
+	}
+	for _, base := range []int{-3, 0, 1, 4, 9, 91, 65537, 2147483647} {
+		if _, err := NewHalton(base, 0, nil); err == nil {
+			t.Errorf("base %d: expected error", base)
+		}
+		if _, err := NewHalton(base, 0, NewSequence(0)); err == nil {
+			t.Errorf("scrambled, base %d: expected error", base)
+		}
+	}
+	// 65521 is the largest prime below the limit.
+	if _, err := NewHalton(65521, 0, NewSequence(0)); err != nil {
+		t.Errorf("base 65521: %v", err)
+	}
+}
+
+// haltonChiSquare computes a chi-square statistic for how evenly the
+// first n points with coordinates from a and b fill a k-by-k grid.
+func haltonChiSquare(a, b *Halton, n uint64, k int) float64 {
+	grid := make([]int, k*k)
+	for i := uint64(1); i <= n; i++ {
+		x, y := int(a.Nth(i)*float64(k)), int(b.Nth(i)*float64(k))
+		grid[x*k+y]++
+	}
+	exp := float64(n) / float64(k*k)
+	chi := 0.0
+	for _, c := range grid {
+		d := float64(c) - exp
+		chi += d * d / exp
+	}
+	return chi
+}
+
+func Test_HaltonScrambled(t *testing.T) {
+	src := NewSequence(0)
+	a, err := NewHalton(47, 0, src)
+	if err != nil {
+		t.Fatalf("creating Halton: %v", err)
+	}
+	b, _ := NewHalton(53, 1, src)
+	// In one dimension, the first base^2 values still hit each
+	// multiple of 1/base^2 exactly once.
+	seen := make([]bool, 47*47)
+	for i := uint64(0); i < 47*47; i++ {
+		x := a.Nth(i)
+		if x < 0 || x >= 1 {
+			t.Fatalf("index %d: value %g out of range", i, x)
+		}
+		cell := int(math.Round(x * 47 * 47))
+		if seen[cell] {
+			t.Fatalf("index %d: value %d/%d already seen", i, cell, 47*47)
+		}
+		seen[cell] = true
+	}
+	// In two dimensions, large bases are correlated without scrambling.
+	plainA, _ := NewHalton(47, 0, nil)
+	plainB, _ := NewHalton(53, 0, nil)
+	plain := haltonChiSquare(plainA, plainB, 1000, 20)
+	scrambled := haltonChiSquare(a, b, 1000, 20)
+	if scrambled >= plain {
+		t.Errorf("scrambled chi-square %g not better than unscrambled %g", scrambled, plain)
+	}
+}
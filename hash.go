@@ -0,0 +1,49 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import "encoding/binary"
+
+// Hash128 deterministically maps an arbitrary key to a 128-bit value,
+// using src as a keyed 128-bit block function. Different seeds give
+// unrelated mappings for the same src.
+//
+// The key is processed in 16-byte blocks, each XORed into a running
+// state which is then replaced by the bits at that offset, starting from
+// an offset which includes the seed and the length of the key. (With an
+// AES-based Sequence, this is CBC-MAC with the length prepended.) It is
+// not intended as a cryptographic hash, just a repeatable one.
+func Hash128(key []byte, seed uint32, src Sequence) Uint128 {
+	state := src.BitsAt(OffsetFor(SequenceHash, seed, 0, uint64(len(key))))
+	var block [16]byte
+	for len(key) > 0 {
+		n := copy(block[:], key)
+		// zero-pad the final block; since the length was included
+		// up front, this can't cause collisions.
+		for i := n; i < len(block); i++ {
+			block[i] = 0
+		}
+		key = key[n:]
+		state.Lo ^= binary.LittleEndian.Uint64(block[:8])
+		state.Hi ^= binary.LittleEndian.Uint64(block[8:])
+		state = src.BitsAt(state)
+	}
+	return state
+}
+
+// Hash64 is Hash128, but yielding only 64 bits.
+func Hash64(key []byte, seed uint32, src Sequence) uint64 {
+	return Hash128(key, seed, src).Lo
+}
@@ -0,0 +1,69 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"fmt"
+	"testing"
+)
+
+func Test_HashCollisions(t *testing.T) {
+	src := NewSequence(0)
+	const keys = 100000
+	seen := make(map[uint64]string, keys)
+	for i := 0; i < keys; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		h := Hash64([]byte(key), 0, src)
+		if prev, ok := seen[h]; ok {
+			t.Fatalf("keys %q and %q both hash to %#x", prev, key, h)
+		}
+		seen[h] = key
+	}
+}
+
+func Test_HashDeterministic(t *testing.T) {
+	keys := [][]byte{
+		nil,
+		{0},
+		{0, 0},
+		[]byte("a"),
+		[]byte("a\x00"),
+		[]byte("exactly sixteen!"),
+		[]byte("exactly sixteen!\x00"),
+		[]byte("a rather longer key which spans several blocks"),
+	}
+	seen := make(map[Uint128]int)
+	for i, key := range keys {
+		h := Hash128(key, 0, NewSequence(0))
+		if again := Hash128(key, 0, NewSequence(0)); again != h {
+			t.Fatalf("key %q: hashed to %s, then %s", key, h, again)
+		}
+		if h.Lo != Hash64(key, 0, NewSequence(0)) {
+			t.Fatalf("key %q: Hash64 doesn't match Hash128", key)
+		}
+		// trailing zero bytes, or an empty key, mustn't collide
+		// with anything else.
+		if prev, ok := seen[h]; ok {
+			t.Fatalf("keys %q and %q both hash to %s", keys[prev], key, h)
+		}
+		seen[h] = i
+		if other := Hash128(key, 1, NewSequence(0)); other == h {
+			t.Fatalf("key %q: seeds 0 and 1 both hash to %s", key, h)
+		}
+		if other := Hash128(key, 0, NewSequence(1)); other == h {
+			t.Fatalf("key %q: sequences 0 and 1 both hash to %s", key, h)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"errors"
+	"fmt"
+)
+
+// HierarchicalPermutation permutes hierarchical keys, such as (tenant,
+// row) pairs, one level at a time: each level has its own independent
+// Permutation of [0,levels[i]), and a key's value at each level is
+// permuted by that level's Permutation alone. Taken together, that's a
+// permutation of the whole Cartesian product of the levels, which keeps
+// keys that share a prefix sharing a (different) prefix.
+type HierarchicalPermutation struct {
+	levels []int64
+	perms  []*Permutation
+}
+
+// NewHierarchicalPermutation creates a HierarchicalPermutation with the
+// given sizes for each level, each of which must be at least 1. Every
+// (seed, level) pair gets a distinct shuffle, so levels of the same size
+// aren't permuted the same way. As with NewPermutationU128, src need not
+// be used again after this returns.
+func NewHierarchicalPermutation(levels []int64, seed uint32, src Sequence) (*HierarchicalPermutation, error) {
+	if src == nil {
+		return nil, errors.New("need a usable PRNG apophenia.Sequence")
+	}
+	if len(levels) == 0 {
+		return nil, errors.New("HierarchicalPermutation needs at least one level")
+	}
+	h := &HierarchicalPermutation{levels: append([]int64(nil), levels...), perms: make([]*Permutation, len(levels))}
+	for i, size := range levels {
+		if size < 1 {
+			return nil, fmt.Errorf("HierarchicalPermutation level %d has size %d, must be at least 1", i, size)
+		}
+		// LHS and BitVecGen use (index, seed) seeds too; bit 32 keeps
+		// these distinct from theirs.
+		perm, err := NewPermutationU128(size, Uint128{Lo: uint64(i), Hi: 1<<32 | uint64(seed)}, src)
+		if err != nil {
+			return nil, err
+		}
+		h.perms[i] = perm
+	}
+	return h, nil
+}
+
+// Levels returns the size of each level.
+func (h *HierarchicalPermutation) Levels() []int64 {
+	return append([]int64(nil), h.levels...)
+}
+
+// Nth returns the key indices maps to, permuting the index at each level
+// by that level's Permutation. It panics if indices doesn't have one
+// index for each level, or an index is out of range for its level.
+func (h *HierarchicalPermutation) Nth(indices []int64) []int64 {
+	if len(indices) != len(h.levels) {
+		panic(fmt.Sprintf("need %d indices for HierarchicalPermutation, got %d", len(h.levels), len(indices)))
+	}
+	out := make([]int64, len(indices))
+	for i, index := range indices {
+		if index < 0 || index >= h.levels[i] {
+			panic(fmt.Sprintf("index %d out of range [0,%d) for level %d", index, h.levels[i], i))
+		}
+		out[i] = h.perms[i].NthStateless(index)
+	}
+	return out
+}
@@ -0,0 +1,99 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"fmt"
+	"testing"
+)
+
+func Test_HierarchicalPermutation(t *testing.T) {
+	levels := []int64{3, 4, 5, 5}
+	h, err := NewHierarchicalPermutation(levels, 1, NewSequence(0))
+	if err != nil {
+		t.Fatalf("creating permutation: %v", err)
+	}
+	// every key in the Cartesian product maps to a distinct key.
+	seen := make(map[string]bool)
+	key := make([]int64, len(levels))
+	var visit func(level int)
+	visit = func(level int) {
+		if level == len(levels) {
+			out := h.Nth(key)
+			for i, v := range out {
+				if v < 0 || v >= levels[i] {
+					t.Fatalf("key %v: level %d value %d out of range", key, i, v)
+				}
+			}
+			k := fmt.Sprint(out)
+			if seen[k] {
+				t.Fatalf("key %v: %v already produced", key, out)
+			}
+			seen[k] = true
+			return
+		}
+		for key[level] = 0; key[level] < levels[level]; key[level]++ {
+			visit(level + 1)
+		}
+	}
+	visit(0)
+	if len(seen) != 3*4*5*5 {
+		t.Fatalf("expected %d distinct keys, got %d", 3*4*5*5, len(seen))
+	}
+
+	// each level's value depends only on that level's index.
+	a := h.Nth([]int64{0, 1, 2, 3})
+	b := h.Nth([]int64{2, 1, 4, 3})
+	if a[1] != b[1] || a[3] != b[3] {
+		t.Errorf("shared indices at levels 1 and 3 gave %v and %v", a, b)
+	}
+	// the two levels of size 5 are shuffled differently.
+	differ := false
+	for i := int64(0); i < 5; i++ {
+		out := h.Nth([]int64{0, 0, i, i})
+		if out[2] != out[3] {
+			differ = true
+		}
+	}
+	if !differ {
+		t.Errorf("levels 2 and 3 have the same shuffle")
+	}
+	if got := h.Levels(); fmt.Sprint(got) != fmt.Sprint(levels) {
+		t.Errorf("expected levels %v, got %v", levels, got)
+	}
+}
+
+func Test_HierarchicalPermutationInvalid(t *testing.T) {
+	src := NewSequence(0)
+	for _, bad := range []struct {
+		levels []int64
+		src    Sequence
+	}{{nil, src}, {[]int64{3, 0}, src}, {[]int64{3, -1}, src}, {[]int64{3}, nil}} {
+		if _, err := NewHierarchicalPermutation(bad.levels, 0, bad.src); err == nil {
+			t.Errorf("levels %v: expected error", bad.levels)
+		}
+	}
+	h, _ := NewHierarchicalPermutation([]int64{3, 4}, 0, src)
+	for _, indices := range [][]int64{{0}, {0, 0, 0}, {3, 0}, {0, -1}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Nth(%v): expected panic", indices)
+				}
+			}()
+			h.Nth(indices)
+		}()
+	}
+}
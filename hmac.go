@@ -0,0 +1,100 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash"
+)
+
+// hmacSequence implements Sequence using HMAC-SHA256 of the offset as a
+// keyed pseudo-random function. It's much slower than the AES-based
+// Sequence, but HMAC-SHA256 is a standard PRF, for cases where the
+// bits need to hold up as a keyed hash, such as audit trails.
+type hmacSequence struct {
+	mac    hash.Hash
+	buf    [16]byte
+	sum    []byte
+	offset Uint128
+}
+
+// NewHMACSHA256Sequence returns a Sequence whose bits at each offset are
+// the first 16 bytes of HMAC-SHA256(key, offset), with the offset
+// encoded as 16 bytes, the low word then the high word, each
+// little-endian, and the result read the same way. The key must not be
+// empty. Seed replaces the key with the seed, encoded as 8
+// little-endian bytes.
+func NewHMACSHA256Sequence(key []byte) (Sequence, error) {
+	if len(key) == 0 {
+		return nil, errors.New("HMAC sequence requires a non-empty key")
+	}
+	s := &hmacSequence{offset: OffsetFor(SequenceRandSource, 0, 0, 0)}
+	s.setKey(key)
+	return s, nil
+}
+
+func (s *hmacSequence) setKey(key []byte) {
+	s.mac = hmac.New(sha256.New, key)
+	s.sum = make([]byte, 0, sha256.Size)
+}
+
+// Seed replaces the key, and resets the position used by Uint64.
+func (s *hmacSequence) Seed(seed int64) {
+	var key [8]byte
+	binary.LittleEndian.PutUint64(key[:], uint64(seed))
+	s.setKey(key[:])
+	s.offset.Lo = 0
+}
+
+// Int63 returns a value in 0..(1<<63)-1.
+func (s *hmacSequence) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+// Uint64 returns a value in 0..(1<<64)-1.
+func (s *hmacSequence) Uint64() uint64 {
+	out := s.BitsAt(s.offset)
+	s.offset.Inc()
+	return out.Lo
+}
+
+// Seek sets the position used by Uint64 and Int63, returning the previous
+// position.
+func (s *hmacSequence) Seek(offset Uint128) (old Uint128) {
+	old, s.offset = s.offset, offset
+	return old
+}
+
+// BitsAt yields the HMAC of offset.
+func (s *hmacSequence) BitsAt(offset Uint128) (out Uint128) {
+	binary.LittleEndian.PutUint64(s.buf[:8], offset.Lo)
+	binary.LittleEndian.PutUint64(s.buf[8:], offset.Hi)
+	s.mac.Reset()
+	s.mac.Write(s.buf[:])
+	s.sum = s.mac.Sum(s.sum[:0])
+	out.Lo, out.Hi = binary.LittleEndian.Uint64(s.sum[:8]), binary.LittleEndian.Uint64(s.sum[8:16])
+	return out
+}
+
+// BitsSliceAt fills dst with the HMACs of consecutive offsets from start.
+func (s *hmacSequence) BitsSliceAt(start Uint128, dst []Uint128) {
+	for i := range dst {
+		dst[i] = s.BitsAt(start)
+		start.Inc()
+	}
+}
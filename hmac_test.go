@@ -0,0 +1,76 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+)
+
+func Test_HMACSHA256Sequence(t *testing.T) {
+	key := []byte("audit key")
+	s, err := NewHMACSHA256Sequence(key)
+	if err != nil {
+		t.Fatalf("creating HMAC sequence: %v", err)
+	}
+	again, _ := NewHMACSHA256Sequence(key)
+	other, _ := NewHMACSHA256Sequence([]byte("other key"))
+	seen := make(map[Uint128]bool)
+	for i := uint64(0); i < 1000; i++ {
+		off := Uint128{Lo: i, Hi: i * 3}
+		got := s.BitsAt(off)
+		if seen[got] {
+			t.Fatalf("offset %s: repeated output %s", off, got)
+		}
+		seen[got] = true
+		if again.BitsAt(off) != got || s.BitsAt(off) != got {
+			t.Fatalf("offset %s: BitsAt not deterministic", off)
+		}
+		if other.BitsAt(off) == got {
+			t.Fatalf("offset %s: different keys gave the same output", off)
+		}
+	}
+	// check against computing the HMAC directly.
+	var msg [16]byte
+	binary.LittleEndian.PutUint64(msg[:8], 5)
+	binary.LittleEndian.PutUint64(msg[8:], 7)
+	mac := hmac.New(sha256.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	exp := Uint128{Lo: binary.LittleEndian.Uint64(sum[:8]), Hi: binary.LittleEndian.Uint64(sum[8:16])}
+	if got := s.BitsAt(Uint128{Lo: 5, Hi: 7}); got != exp {
+		t.Fatalf("expected %s, got %s", exp, got)
+	}
+	if _, err := NewHMACSHA256Sequence(nil); err == nil {
+		t.Errorf("expected error for empty key")
+	}
+}
+
+func BenchmarkHMACSHA256Sequence(b *testing.B) {
+	b.Run("AES", func(b *testing.B) {
+		s := NewSequence(1)
+		for i := 0; i < b.N; i++ {
+			s.BitsAt(Uint128{Lo: uint64(i)})
+		}
+	})
+	b.Run("HMAC", func(b *testing.B) {
+		s, _ := NewHMACSHA256Sequence([]byte("key"))
+		for i := 0; i < b.N; i++ {
+			s.BitsAt(Uint128{Lo: uint64(i)})
+		}
+	})
+}
@@ -0,0 +1,129 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"fmt"
+	"io"
+)
+
+// HybridSequence is a Sequence which, every so many calls, mixes 16 bytes
+// from an entropy source such as crypto/rand.Reader into its AES key.
+// Between mixes, it's deterministic, producing exactly what a Sequence
+// with its current key would; each mix changes the key unpredictably, so
+// values from after a mix can't be reproduced from the original seed.
+//
+// Every value produced counts as a call, so BitsSliceAt counts as
+// len(dst) calls, and a mix can happen partway through it. Seek doesn't
+// count. Since the Sequence interface doesn't allow errors to be
+// returned, the first error reading entropy is available from Err; when
+// a read fails, the key is left unchanged.
+type HybridSequence struct {
+	seq     *aesSequence128
+	entropy io.Reader
+	rekey   uint64
+	calls   uint64 // calls since the last mix
+	err     error
+}
+
+// NewHybridSequence returns a HybridSequence which starts with src's key
+// and position, and then mixes 16 bytes from entropy into its key before
+// every rekey'th call: calls 0 through rekey-1 use the original key, calls
+// rekey through 2*rekey-1 the first mixed key, and so on. A rekey of 0
+// never mixes. It doesn't modify src. src must come from NewSequence, so
+// there's a key to mix into; NewHybridSequence panics otherwise, or if
+// entropy is nil.
+func NewHybridSequence(src Sequence, entropy io.Reader, rekey uint64) *HybridSequence {
+	aes, ok := src.(*aesSequence128)
+	if !ok {
+		panic(fmt.Sprintf("HybridSequence needs a Sequence from NewSequence, got %T", src))
+	}
+	if entropy == nil {
+		panic("HybridSequence needs a non-nil entropy source")
+	}
+	seq := newAESSequence(aes.key)
+	seq.offset = aes.offset
+	return &HybridSequence{seq: seq, entropy: entropy, rekey: rekey}
+}
+
+// Err returns the first error encountered reading entropy, if any.
+func (s *HybridSequence) Err() error {
+	return s.err
+}
+
+// call counts a call, first mixing entropy into the key if it's time.
+func (s *HybridSequence) call() {
+	if s.rekey != 0 && s.calls == s.rekey {
+		s.mix()
+		s.calls = 0
+	}
+	s.calls++
+}
+
+// mix XORs 16 bytes of entropy into the key.
+func (s *HybridSequence) mix() {
+	var buf [16]byte
+	if _, err := io.ReadFull(s.entropy, buf[:]); err != nil {
+		if s.err == nil {
+			s.err = fmt.Errorf("reading entropy: %v", err)
+		}
+		return
+	}
+	key := s.seq.key
+	for i := range key {
+		key[i] ^= buf[i]
+	}
+	s.seq.setKey(key)
+}
+
+// Seed sets the key from seed, discarding any mixed-in entropy, and resets
+// both the position and the count of calls until the next mix.
+func (s *HybridSequence) Seed(seed int64) {
+	s.seq.Seed(seed)
+	s.calls = 0
+}
+
+// Int63 returns a value in 0..(1<<63)-1.
+func (s *HybridSequence) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+// Uint64 returns a value in 0..(1<<64)-1.
+func (s *HybridSequence) Uint64() uint64 {
+	s.call()
+	return s.seq.Uint64()
+}
+
+// Seek sets the position used by Uint64 and Int63, returning the previous
+// position.
+func (s *HybridSequence) Seek(offset Uint128) Uint128 {
+	return s.seq.Seek(offset)
+}
+
+// BitsAt yields the bits at offset, using the current key.
+func (s *HybridSequence) BitsAt(offset Uint128) Uint128 {
+	s.call()
+	return s.seq.BitsAt(offset)
+}
+
+// BitsSliceAt fills dst with the bits at consecutive offsets from start,
+// mixing entropy into the key partway through if it's time.
+func (s *HybridSequence) BitsSliceAt(start Uint128, dst []Uint128) {
+	for i := range dst {
+		s.call()
+		dst[i] = s.seq.BitsAt(start)
+		start.Inc()
+	}
+}
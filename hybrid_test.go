@@ -0,0 +1,156 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"bytes"
+	"testing"
+)
+
+// countingReader counts the reads made from it.
+type countingReader struct {
+	r     *bytes.Reader
+	reads int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	c.reads++
+	return c.r.Read(p)
+}
+
+func Test_HybridSequence(t *testing.T) {
+	const rekey = 5
+	entropy := make([]byte, 16*4)
+	for i := range entropy {
+		entropy[i] = byte(i*37 + 11)
+	}
+	src := NewSequence(7)
+	src.Seek(Uint128{Lo: 100})
+	reader := &countingReader{r: bytes.NewReader(entropy)}
+	h := NewHybridSequence(src, reader, rekey)
+
+	// the expected Sequence for each epoch of rekey calls.
+	key := src.(*aesSequence128).key
+	var epochs []Sequence
+	for epoch := 0; epoch <= 4; epoch++ {
+		epochs = append(epochs, newAESSequence(key))
+		if epoch < 4 {
+			for i := range key {
+				key[i] ^= entropy[epoch*16+i]
+			}
+		}
+	}
+	offset := Uint128{Lo: 100}
+	for call := 0; call < 18; call++ {
+		epoch := call / rekey
+		expected := epochs[epoch]
+		if call%3 == 0 {
+			if got, exp := h.Uint64(), expected.BitsAt(offset).Lo; got != exp {
+				t.Fatalf("call %d: Uint64 expected %#x, got %#x", call, exp, got)
+			}
+			offset.Inc()
+		} else {
+			at := Uint128{Lo: uint64(call), Hi: 3}
+			if got, exp := h.BitsAt(at), expected.BitsAt(at); got != exp {
+				t.Fatalf("call %d: BitsAt expected %s, got %s", call, exp, got)
+			}
+		}
+		if reader.reads != epoch {
+			t.Fatalf("after call %d: expected %d entropy reads, got %d", call, epoch, reader.reads)
+		}
+	}
+	// calls 18 through 21: a slice spanning the mix before call 20 uses
+	// the old key, then the new one.
+	dst := make([]Uint128, 4)
+	h.BitsSliceAt(Uint128{Lo: 1000}, dst)
+	for i := range dst {
+		expected := epochs[3]
+		if i >= 2 {
+			expected = epochs[4]
+		}
+		if exp := expected.BitsAt(Uint128{Lo: 1000 + uint64(i)}); dst[i] != exp {
+			t.Fatalf("BitsSliceAt value %d: expected %s, got %s", i, exp, dst[i])
+		}
+	}
+	if reader.reads != 4 || h.Err() != nil {
+		t.Fatalf("after slice: expected 4 entropy reads and no error, got %d, %v", reader.reads, h.Err())
+	}
+	// calls 22 through 26: the entropy runs out at call 25, which
+	// leaves the key alone.
+	for call := 22; call < 27; call++ {
+		if got, exp := h.Uint64(), epochs[4].BitsAt(offset).Lo; got != exp {
+			t.Fatalf("call %d: Uint64 expected %#x, got %#x", call, exp, got)
+		}
+		offset.Inc()
+	}
+	if h.Err() == nil {
+		t.Fatalf("expected an error after running out of entropy")
+	}
+	// src wasn't modified.
+	if got := src.Seek(Uint128{}); got != (Uint128{Lo: 100}) {
+		t.Errorf("source position changed to %s", got)
+	}
+
+	// reseeding discards the entropy and restarts the count.
+	h = NewHybridSequence(NewSequence(1), bytes.NewReader(entropy), 3)
+	for i := 0; i < 4; i++ {
+		h.Uint64()
+	}
+	h.Seed(2)
+	plain := NewSequence(2)
+	for i := 0; i < 3; i++ {
+		if got, exp := h.Uint64(), plain.Uint64(); got != exp {
+			t.Fatalf("after Seed, value %d: expected %#x, got %#x", i, exp, got)
+		}
+	}
+	if got, exp := h.Uint64(), plain.Uint64(); got == exp {
+		t.Fatalf("after Seed, value 3 should use a mixed key")
+	}
+}
+
+func Test_HybridSequenceNoRekey(t *testing.T) {
+	reader := &countingReader{r: bytes.NewReader(nil)}
+	h := NewHybridSequence(NewSequence(3), reader, 0)
+	plain := NewSequence(3)
+	for i := 0; i < 1000; i++ {
+		if got, exp := h.Uint64(), plain.Uint64(); got != exp {
+			t.Fatalf("value %d: expected %#x, got %#x", i, exp, got)
+		}
+	}
+	if reader.reads != 0 {
+		t.Errorf("rekey 0: expected no entropy reads, got %d", reader.reads)
+	}
+}
+
+func Test_HybridSequenceInvalid(t *testing.T) {
+	reader, _ := NewReaderSequence(bytes.NewReader(make([]byte, 64)))
+	for _, bad := range []struct {
+		src     Sequence
+		entropy *bytes.Reader
+	}{{reader, bytes.NewReader(nil)}, {NewSequence(0), nil}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewHybridSequence(%T, %v): expected panic", bad.src, bad.entropy)
+				}
+			}()
+			if bad.entropy == nil {
+				NewHybridSequence(bad.src, nil, 1)
+			} else {
+				NewHybridSequence(bad.src, bad.entropy, 1)
+			}
+		}()
+	}
+}
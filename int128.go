@@ -14,7 +14,14 @@
 
 package apophenia
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+)
 
 // Uint128 is a pair of uint64, treated as a single
 // object to simplify calling conventions. It's a struct
@@ -36,13 +43,41 @@ func (u *Uint128) Add(value Uint128) {
 	u.Hi += value.Hi
 }
 
+// MulUint64 returns the full 128-bit product of a and b.
+func MulUint64(a, b uint64) (u Uint128) {
+	u.Hi, u.Lo = bits.Mul64(a, b)
+	return u
+}
+
+// AddChecked returns the sum of u and value, and whether the sum
+// overflowed (exceeded 2^128-1). On overflow, the sum wraps around,
+// matching the behavior of Add.
+func (u Uint128) AddChecked(value Uint128) (Uint128, bool) {
+	var carry uint64
+	u.Lo, carry = bits.Add64(u.Lo, value.Lo, 0)
+	u.Hi, carry = bits.Add64(u.Hi, value.Hi, carry)
+	return u, carry != 0
+}
+
+// AddV returns the sum of u and value, without modifying u. Like Add, it
+// wraps around on overflow.
+func (u Uint128) AddV(value Uint128) Uint128 {
+	u.Add(value)
+	return u
+}
+
 // Sub subtracts value from its receiver in place.
 func (u *Uint128) Sub(value Uint128) {
-	u.Lo -= value.Lo
-	if u.Lo > value.Lo {
-		u.Hi--
-	}
-	u.Hi -= value.Hi
+	var borrow uint64
+	u.Lo, borrow = bits.Sub64(u.Lo, value.Lo, 0)
+	u.Hi, _ = bits.Sub64(u.Hi, value.Hi, borrow)
+}
+
+// SubV returns u minus value, without modifying u. Like Sub, it wraps
+// around on underflow.
+func (u Uint128) SubV(value Uint128) Uint128 {
+	u.Sub(value)
+	return u
 }
 
 // And does a bitwise and with value, in place.
@@ -65,6 +100,12 @@ func (u *Uint128) Not() {
 	u.Lo, u.Hi = ^u.Lo, ^u.Hi
 }
 
+// ReverseBits reverses the order of all 128 bits in place, so bit 0
+// becomes bit 127 and vice versa.
+func (u *Uint128) ReverseBits() {
+	u.Lo, u.Hi = bits.Reverse64(u.Hi), bits.Reverse64(u.Lo)
+}
+
 // Mask produces a mask of the lower n bits of u.
 func (u *Uint128) Mask(n uint64) {
 	if n >= 128 {
@@ -97,6 +138,93 @@ func (u Uint128) String() string {
 	return fmt.Sprintf("0x%x%016x", u.Hi, u.Lo)
 }
 
+// MarshalJSON encodes u as a JSON string, in the same hex format String
+// uses.
+func (u Uint128) MarshalJSON() ([]byte, error) {
+	return json.Marshal(u.String())
+}
+
+// UnmarshalJSON decodes a JSON string in the format String produces: 0x
+// followed by up to 32 hex digits.
+func (u *Uint128) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("Uint128 must be a JSON string: %v", err)
+	}
+	digits := strings.TrimPrefix(s, "0x")
+	if len(digits) == len(s) || len(digits) == 0 || len(digits) > 32 {
+		return fmt.Errorf("invalid Uint128 %q: need 0x followed by 1 to 32 hex digits", s)
+	}
+	var out Uint128
+	var err error
+	if len(digits) > 16 {
+		out.Hi, err = strconv.ParseUint(digits[:len(digits)-16], 16, 64)
+		if err != nil {
+			return fmt.Errorf("invalid Uint128 %q: %v", s, err)
+		}
+		digits = digits[len(digits)-16:]
+	}
+	out.Lo, err = strconv.ParseUint(digits, 16, 64)
+	if err != nil {
+		return fmt.Errorf("invalid Uint128 %q: %v", s, err)
+	}
+	*u = out
+	return nil
+}
+
+// Uint128Parse parses s as an unsigned 128-bit integer, in binary with a
+// 0b prefix, octal with 0o, hexadecimal with 0x, or decimal with no
+// prefix, like strconv.ParseUint with base 0, except that a leading 0
+// alone doesn't mean octal, and underscores aren't allowed. The hex form
+// is the one String produces. Values over 2^128-1 are an error.
+func Uint128Parse(s string) (Uint128, error) {
+	digits, base := s, uint64(10)
+	if len(s) > 2 && s[0] == '0' {
+		switch s[1] {
+		case 'b', 'B':
+			digits, base = s[2:], 2
+		case 'o', 'O':
+			digits, base = s[2:], 8
+		case 'x', 'X':
+			digits, base = s[2:], 16
+		}
+	}
+	if len(digits) == 0 {
+		return Uint128{}, fmt.Errorf("invalid Uint128 %q: no digits", s)
+	}
+	var u Uint128
+	for _, c := range digits {
+		var d uint64
+		switch {
+		case c >= '0' && c <= '9':
+			d = uint64(c - '0')
+		case c >= 'a' && c <= 'f':
+			d = uint64(c-'a') + 10
+		case c >= 'A' && c <= 'F':
+			d = uint64(c-'A') + 10
+		default:
+			d = base
+		}
+		if d >= base {
+			return Uint128{}, fmt.Errorf("invalid Uint128 %q: bad base-%d digit %q", s, base, c)
+		}
+		// u = u*base + d, checking for overflow.
+		hiCarry, hi := bits.Mul64(u.Hi, base)
+		loCarry, lo := bits.Mul64(u.Lo, base)
+		hi, carry := bits.Add64(hi, loCarry, 0)
+		if hiCarry != 0 || carry != 0 {
+			return Uint128{}, fmt.Errorf("Uint128 %q out of range", s)
+		}
+		lo, carry = bits.Add64(lo, d, 0)
+		hi, carry = bits.Add64(hi, 0, carry)
+		if carry != 0 {
+			return Uint128{}, fmt.Errorf("Uint128 %q out of range", s)
+		}
+		u = Uint128{Lo: lo, Hi: hi}
+	}
+	return u, nil
+}
+
 // RotateRight rotates u right by n bits.
 func (u *Uint128) RotateRight(n uint64) {
 	if n&64 != 0 {
@@ -187,6 +315,41 @@ func (u *Uint128) Bit(n uint64) uint64 {
 	return (u.Lo >> n) & 1
 }
 
+// Even reports whether u is even, which is to say whether its lowest bit
+// is 0.
+func (u Uint128) Even() bool {
+	return u.Lo&1 == 0
+}
+
+// Odd reports whether u is odd, which is to say whether its lowest bit
+// is 1.
+func (u Uint128) Odd() bool {
+	return u.Lo&1 == 1
+}
+
+// ToFloat64 converts the low-order 53 bits of u.Lo to a float64 in [0,1),
+// the way Zipf and other distributions turn a Sequence's bits into a
+// uniform value.
+func (u Uint128) ToFloat64() float64 {
+	return unitFloat64(u.Lo)
+}
+
+// ToFloat64Full converts u, treated as a 128-bit fraction u/2^128, to a
+// float64 in [0,1), rounding down. Unlike ToFloat64, small values keep
+// a full 53 bits of precision, taken from whichever bits follow the
+// leading one, so values very close to 0 are still evenly spread.
+func (u Uint128) ToFloat64Full() float64 {
+	z := bits.LeadingZeros64(u.Hi)
+	if u.Hi == 0 {
+		if u.Lo == 0 {
+			return 0
+		}
+		z = 64 + bits.LeadingZeros64(u.Lo)
+	}
+	u.ShiftLeft(uint64(z))
+	return math.Ldexp(float64(u.Hi>>11), -53-z)
+}
+
 // Inc increments its receiver in place.
 func (u *Uint128) Inc() {
 	u.Lo++
@@ -15,6 +15,10 @@
 package apophenia
 
 import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"strings"
 	"testing"
 )
 
@@ -70,3 +74,312 @@ func Test_Int128Shift(t *testing.T) {
 		}
 	}
 }
+
+func Test_Int128AddChecked(t *testing.T) {
+	allOnes := Uint128{Lo: ^uint64(0), Hi: ^uint64(0)}
+	cases := []struct {
+		a, b     Uint128
+		sum      Uint128
+		overflow bool
+	}{
+		{a: Uint128{Lo: 1}, b: Uint128{Lo: 2}, sum: Uint128{Lo: 3}},
+		{a: Uint128{Lo: ^uint64(0)}, b: Uint128{Lo: 1}, sum: Uint128{Hi: 1}},
+		{a: Uint128{Lo: 5, Hi: 1}, b: Uint128{Lo: 7, Hi: 2}, sum: Uint128{Lo: 12, Hi: 3}},
+		{a: allOnes, b: Uint128{Lo: 1}, sum: Uint128{}, overflow: true},
+		{a: allOnes, b: Uint128{}, sum: allOnes},
+		{a: Uint128{Hi: 1 << 63}, b: Uint128{Hi: 1 << 63}, sum: Uint128{}, overflow: true},
+		{a: allOnes, b: allOnes, sum: Uint128{Lo: ^uint64(1), Hi: ^uint64(0)}, overflow: true},
+	}
+	for _, c := range cases {
+		sum, overflow := c.a.AddChecked(c.b)
+		if sum != c.sum || overflow != c.overflow {
+			t.Fatalf("%s + %s: expected %s (overflow %t), got %s (overflow %t)",
+				c.a, c.b, c.sum, c.overflow, sum, overflow)
+		}
+		// AddChecked should agree with Add about the result.
+		u := c.a
+		u.Add(c.b)
+		if u != sum {
+			t.Fatalf("%s + %s: Add produced %s, AddChecked produced %s",
+				c.a, c.b, u, sum)
+		}
+	}
+}
+
+func Test_Int128ReverseBits(t *testing.T) {
+	cases := []struct {
+		in, out Uint128
+	}{
+		{in: Uint128{Lo: 1}, out: Uint128{Hi: 1 << 63}},
+		{in: Uint128{Hi: 1 << 63}, out: Uint128{Lo: 1}},
+		{in: Uint128{Lo: 0xF0}, out: Uint128{Hi: 0x0F << 56}},
+		{in: Uint128{}, out: Uint128{}},
+		{in: Uint128{Lo: ^uint64(0)}, out: Uint128{Hi: ^uint64(0)}},
+	}
+	for _, c := range cases {
+		u := c.in
+		u.ReverseBits()
+		if u != c.out {
+			t.Fatalf("reversing %s: expected %s, got %s", c.in, c.out, u)
+		}
+	}
+	src := NewSequence(0)
+	for i := uint64(0); i < 100; i++ {
+		in := src.BitsAt(OffsetFor(SequenceDefault, 0, 0, i))
+		u := in
+		u.ReverseBits()
+		for b := uint64(0); b < 128; b++ {
+			if u.Bit(b) != in.Bit(127-b) {
+				t.Fatalf("reversing %s: bit %d is %d, expected bit %d of input (%d)",
+					in, b, u.Bit(b), 127-b, in.Bit(127-b))
+			}
+		}
+		u.ReverseBits()
+		if u != in {
+			t.Fatalf("reversing %s twice gave %s", in, u)
+		}
+	}
+}
+
+func Test_Int128JSON(t *testing.T) {
+	values := []Uint128{
+		{},
+		{Lo: 1},
+		{Lo: ^uint64(0)},
+		{Hi: 1},
+		{Lo: 0x0123456789abcdef, Hi: 0xfedcba9876543210},
+		{Lo: ^uint64(0), Hi: ^uint64(0)},
+	}
+	for _, u := range values {
+		data, err := json.Marshal(u)
+		if err != nil {
+			t.Fatalf("marshaling %s: %v", u, err)
+		}
+		if exp := `"` + u.String() + `"`; string(data) != exp {
+			t.Fatalf("marshaling %s: expected %s, got %s", u, exp, data)
+		}
+		var out Uint128
+		if err := json.Unmarshal(data, &out); err != nil {
+			t.Fatalf("unmarshaling %s: %v", data, err)
+		}
+		if out != u {
+			t.Fatalf("round trip of %s produced %s", u, out)
+		}
+	}
+	// embedded in a struct, the way a config file would use it
+	type config struct {
+		Offset Uint128 `json:"offset"`
+	}
+	var c config
+	if err := json.Unmarshal([]byte(`{"offset":"0x1f"}`), &c); err != nil {
+		t.Fatalf("unmarshaling config: %v", err)
+	}
+	if c.Offset != (Uint128{Lo: 0x1f}) {
+		t.Fatalf("expected offset 0x1f, got %s", c.Offset)
+	}
+	for _, bad := range []string{`12`, `null`, `{}`, `"12"`, `"0x"`, `"0xg"`, `"0x` + strings.Repeat("1", 33) + `"`} {
+		var u Uint128
+		if err := json.Unmarshal([]byte(bad), &u); err == nil {
+			t.Errorf("unmarshaling %s: expected error, got %s", bad, u)
+		}
+	}
+}
+
+// bigFromUint128 converts u to a big.Int.
+func bigFromUint128(u Uint128) *big.Int {
+	b := new(big.Int).SetUint64(u.Hi)
+	b.Lsh(b, 64)
+	return b.Or(b, new(big.Int).SetUint64(u.Lo))
+}
+
+// uint128FromBig converts the low 128 bits of b to a Uint128.
+func uint128FromBig(b *big.Int) Uint128 {
+	mask := new(big.Int).SetUint64(^uint64(0))
+	lo := new(big.Int).And(b, mask)
+	hi := new(big.Int).Rsh(b, 64)
+	hi.And(hi, mask)
+	return Uint128{Lo: lo.Uint64(), Hi: hi.Uint64()}
+}
+
+func Test_Int128ShiftBig(t *testing.T) {
+	inputs := []Uint128{
+		{Lo: 1},
+		{Hi: 1 << 63},
+		{Lo: 0x0123456789abcdef, Hi: 0xfedcba9876543210},
+		{Lo: ^uint64(0), Hi: ^uint64(0)},
+	}
+	for _, in := range inputs {
+		b := bigFromUint128(in)
+		for _, n := range []uint64{0, 1, 63, 64, 65, 127, 128, 129, 200} {
+			u := in
+			u.ShiftLeft(n)
+			if exp := uint128FromBig(new(big.Int).Lsh(b, uint(n))); u != exp {
+				t.Errorf("shift %s left by %d: expected %s, got %s", in, n, exp, u)
+			}
+			u = in
+			u.ShiftRight(n)
+			if exp := uint128FromBig(new(big.Int).Rsh(b, uint(n))); u != exp {
+				t.Errorf("shift %s right by %d: expected %s, got %s", in, n, exp, u)
+			}
+			if n > 127 {
+				continue
+			}
+			// rotation is the or of the two shifts.
+			left := uint128FromBig(new(big.Int).Lsh(b, uint(n)))
+			right := uint128FromBig(new(big.Int).Rsh(b, uint(128-n)))
+			left.Or(right)
+			u = in
+			u.RotateLeft(n)
+			if u != left {
+				t.Errorf("rotate %s left by %d: expected %s, got %s", in, n, left, u)
+			}
+			u.RotateRight(n)
+			if u != in {
+				t.Errorf("rotate %s left and right by %d: got %s", in, n, u)
+			}
+		}
+	}
+}
+
+func Test_Int128ToFloat64(t *testing.T) {
+	s := NewSequence(0)
+	cases := []Uint128{{}, {Lo: 1}, {Lo: ^uint64(0)}, {Hi: 1}, {Lo: ^uint64(0), Hi: ^uint64(0)}, {Hi: 1 << 63}}
+	for i := uint64(0); i < 10000; i++ {
+		cases = append(cases, s.BitsAt(Uint128{Lo: i}))
+	}
+	for _, u := range cases {
+		f := u.ToFloat64()
+		if f < 0 || f >= 1 {
+			t.Fatalf("%s: ToFloat64 gave %g, out of range", u, f)
+		}
+		if exp := float64(u.Lo&(1<<53-1)) / (1 << 53); f != exp {
+			t.Fatalf("%s: ToFloat64 gave %g, expected %g", u, f, exp)
+		}
+		full := u.ToFloat64Full()
+		if full < 0 || full >= 1 {
+			t.Fatalf("%s: ToFloat64Full gave %g, out of range", u, full)
+		}
+		// compare against a (rounded) conversion of the whole value.
+		approx := (float64(u.Hi) + float64(u.Lo)/(1<<64)) / (1 << 64)
+		if math.Abs(full-approx) > approx*(1.0/(1<<52)) {
+			t.Fatalf("%s: ToFloat64Full gave %g, expected about %g", u, full, approx)
+		}
+	}
+	if got, exp := (Uint128{Lo: 1}).ToFloat64Full(), math.Ldexp(1, -128); got != exp {
+		t.Errorf("smallest value: expected %g, got %g", exp, got)
+	}
+	if got, exp := (Uint128{Hi: 1 << 63}).ToFloat64Full(), 0.5; got != exp {
+		t.Errorf("half: expected %g, got %g", exp, got)
+	}
+}
+
+func Test_Int128AddSubV(t *testing.T) {
+	s := NewSequence(0)
+	cases := [][2]Uint128{
+		{{Lo: 10}, {Lo: 3}},
+		{{Lo: 3}, {Lo: 10}},
+		{{Hi: 1}, {Lo: 1}},
+		{{Lo: ^uint64(0)}, {Lo: 1}},
+		{{}, {Lo: 1}},
+	}
+	for i := uint64(0); i < 1000; i++ {
+		cases = append(cases, [2]Uint128{s.BitsAt(Uint128{Lo: i}), s.BitsAt(Uint128{Lo: i, Hi: 1})})
+	}
+	for _, c := range cases {
+		a, b := c[0], c[1]
+		origA, origB := a, b
+		sum, diff := a.AddV(b), a.SubV(b)
+		if a != origA || b != origB {
+			t.Fatalf("%s, %s: AddV/SubV modified their operands", origA, origB)
+		}
+		want := new(big.Int).Add(bigFromUint128(a), bigFromUint128(b))
+		if exp := uint128FromBig(want); sum != exp {
+			t.Fatalf("%s + %s: expected %s, got %s", a, b, exp, sum)
+		}
+		want.Sub(bigFromUint128(a), bigFromUint128(b))
+		if exp := uint128FromBig(want); diff != exp {
+			t.Fatalf("%s - %s: expected %s, got %s", a, b, exp, diff)
+		}
+		if diff.AddV(b) != a {
+			t.Fatalf("%s - %s + %s: didn't get back %s", a, b, b, a)
+		}
+		// the in-place versions agree.
+		u := a
+		u.Sub(b)
+		if u != diff {
+			t.Fatalf("%s - %s: Sub gave %s, SubV gave %s", a, b, u, diff)
+		}
+	}
+}
+
+func Test_Uint128Parse(t *testing.T) {
+	s := NewSequence(0)
+	values := []Uint128{{}, {Lo: 1}, {Lo: ^uint64(0)}, {Hi: 1}, {Lo: ^uint64(0), Hi: ^uint64(0)}}
+	for i := uint64(0); i < 200; i++ {
+		values = append(values, s.BitsAt(Uint128{Lo: i}))
+	}
+	prefixes := map[int]string{2: "0b", 8: "0o", 10: "", 16: "0x"}
+	for _, u := range values {
+		for base, prefix := range prefixes {
+			text := prefix + bigFromUint128(u).Text(base)
+			got, err := Uint128Parse(text)
+			if err != nil {
+				t.Fatalf("parsing %q: %v", text, err)
+			}
+			if got != u {
+				t.Fatalf("parsing %q: expected %s, got %s", text, u, got)
+			}
+		}
+		if got, err := Uint128Parse(u.String()); err != nil || got != u {
+			t.Fatalf("parsing String() output %q: got %s, %v", u.String(), got, err)
+		}
+	}
+	if got, err := Uint128Parse("0XFF"); err != nil || got != (Uint128{Lo: 255}) {
+		t.Errorf("parsing 0XFF: got %s, %v", got, err)
+	}
+	tooBig := new(big.Int).Lsh(big.NewInt(1), 128)
+	for _, text := range []string{
+		tooBig.String(),
+		"0x" + tooBig.Text(16),
+		"0b" + tooBig.Text(2),
+		"0o" + tooBig.Text(8),
+		"0x1" + strings.Repeat("0", 40),
+	} {
+		if _, err := Uint128Parse(text); err == nil || !strings.Contains(err.Error(), "out of range") {
+			t.Errorf("parsing %q: expected out of range error, got %v", text, err)
+		}
+	}
+	for _, text := range []string{"", "0x", "0b2", "0o8", "12a", "-1", "0x1g", " 1"} {
+		if _, err := Uint128Parse(text); err == nil {
+			t.Errorf("parsing %q: expected error", text)
+		}
+	}
+}
+
+func Test_Uint128EvenOdd(t *testing.T) {
+	for _, c := range []struct {
+		u    Uint128
+		even bool
+	}{
+		{Uint128{}, true},
+		{Uint128{Lo: 1}, false},
+		{Uint128{Lo: 2}, true},
+		{Uint128{Lo: math.MaxUint64}, false},
+		{Uint128{Lo: math.MaxUint64 - 1}, true},
+		{Uint128{Hi: 1}, true},
+		{Uint128{Hi: math.MaxUint64}, true},
+		{Uint128{Lo: 1, Hi: math.MaxUint64}, false},
+		{Uint128{Lo: math.MaxUint64, Hi: math.MaxUint64}, false},
+		{Uint128{Lo: 0, Hi: 3}, true},
+	} {
+		if got := c.u.Even(); got != c.even {
+			t.Errorf("%s: Even() = %t, expected %t", c.u, got, c.even)
+		}
+		if got := c.u.Odd(); got != !c.even {
+			t.Errorf("%s: Odd() = %t, expected %t", c.u, got, !c.even)
+		}
+		if got := c.u.Bit(0) == 0; got != c.u.Even() {
+			t.Errorf("%s: Even() disagrees with Bit(0)", c.u)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.23
+
+package apophenia
+
+import "iter"
+
+// All23 returns an iterator over every value of the permutation, in
+// order, for use with range-over-func. Like All, it does not change the
+// offset Next counts from, but it doesn't allocate a slice of max values.
+func (p *Permutation) All23() iter.Seq[int64] {
+	return func(yield func(int64) bool) {
+		for i := int64(0); i < p.max; i++ {
+			if !yield(p.lo + p.permute(i)) {
+				return
+			}
+		}
+	}
+}
+
+// All23 returns an iterator over the values Next would produce, for use
+// with range-over-func. It never ends on its own, and it advances z just
+// as calling Next would, so the caller must break out of the loop.
+func (z *Zipf) All23() iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		for yield(z.Next()) {
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.23
+
+package apophenia
+
+import "testing"
+
+func Test_PermutationAll23(t *testing.T) {
+	p := PermutationOrBust(1000, 1, "", t)
+	seen := make(map[int64]struct{}, 1000)
+	i := int64(0)
+	for x := range p.All23() {
+		if _, ok := seen[x]; ok {
+			t.Fatalf("duplicate value %d at position %d", x, i)
+		}
+		seen[x] = struct{}{}
+		if exp := p.Nth(i); exp != x {
+			t.Fatalf("position %d: All23 gave %d, Nth gave %d", i, x, exp)
+		}
+		i++
+	}
+	if len(seen) != 1000 {
+		t.Fatalf("expected 1000 values, got %d", len(seen))
+	}
+	count := 0
+	for range p.All23() {
+		count++
+		if count == 10 {
+			break
+		}
+	}
+	if count != 10 {
+		t.Fatalf("expected to stop after 10 values, got %d", count)
+	}
+}
+
+func Test_ZipfAll23(t *testing.T) {
+	z, err := NewZipf(1.3, 1.5, 100, 0, NewSequence(0))
+	if err != nil {
+		t.Fatalf("creating zipf: %v", err)
+	}
+	z2, _ := NewZipf(1.3, 1.5, 100, 0, NewSequence(0))
+	count := 0
+	for x := range z.All23() {
+		if exp := z2.Next(); x != exp {
+			t.Fatalf("value %d: All23 gave %d, Next gave %d", count, x, exp)
+		}
+		count++
+		if count == 100 {
+			break
+		}
+	}
+	// the iterator advanced z, so the next value follows on.
+	if x, exp := z.Next(), z2.Next(); x != exp {
+		t.Fatalf("after break: expected %d, got %d", exp, x)
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import "math"
+
+// LHS returns n points in [0,1)^dimension forming a Latin hypercube
+// sample: in each dimension, exactly one point falls in each interval
+// [k/n, (k+1)/n). Each dimension uses its own Permutation to assign
+// intervals to points, and each point is placed uniformly at random
+// within its interval. It returns nil if n or dimension is less than 1,
+// or src is nil.
+func LHS(n int, dimension int, seed uint32, src Sequence) [][]float64 {
+	if n < 1 || dimension < 1 || src == nil {
+		return nil
+	}
+	points := make([][]float64, n)
+	for i := range points {
+		points[i] = make([]float64, dimension)
+	}
+	for d := 0; d < dimension; d++ {
+		// Every (seed, dimension) pair gets a distinct shuffle.
+		perm, err := NewPermutationU128(int64(n), Uint128{Lo: uint64(d), Hi: uint64(seed)}, src)
+		if err != nil {
+			panic("impossible error: " + err.Error())
+		}
+		for i, p := range points {
+			u := unitFloat64(src.BitsAt(OffsetFor(SequenceLatinHypercube, seed, uint32(d), uint64(i))).Lo)
+			k := float64(perm.Next())
+			// with u close to 1, rounding can land on (k+1)/n, which
+			// belongs to the next interval, or to 1.
+			p[d] = math.Min((k+u)/float64(n), math.Nextafter((k+1)/float64(n), 0))
+		}
+	}
+	return points
+}
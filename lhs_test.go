@@ -0,0 +1,80 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math/big"
+	"testing"
+)
+
+// onesSequence is a Sequence whose BitsAt is always all ones, the
+// largest possible bits for any offset.
+type onesSequence struct {
+	Sequence
+}
+
+func (onesSequence) BitsAt(Uint128) Uint128 {
+	return Uint128{Lo: ^uint64(0), Hi: ^uint64(0)}
+}
+
+func Test_LHS(t *testing.T) {
+	for _, c := range []struct{ n, dimension int }{{1, 1}, {10, 3}, {1000, 5}} {
+		points := LHS(c.n, c.dimension, 0, NewSequence(0))
+		if len(points) != c.n {
+			t.Fatalf("n %d: got %d points", c.n, len(points))
+		}
+		for d := 0; d < c.dimension; d++ {
+			seen := make([]bool, c.n)
+			for i, p := range points {
+				if len(p) != c.dimension {
+					t.Fatalf("point %d has %d dimensions, expected %d", i, len(p), c.dimension)
+				}
+				if p[d] < 0 || p[d] >= 1 {
+					t.Fatalf("point %d, dimension %d: value %g out of range", i, d, p[d])
+				}
+				k := int(p[d] * float64(c.n))
+				if seen[k] {
+					t.Fatalf("n %d, dimension %d: interval %d has more than one point", c.n, d, k)
+				}
+				seen[k] = true
+			}
+		}
+	}
+	// different seeds give different samples.
+	a, b := LHS(100, 2, 0, NewSequence(0)), LHS(100, 2, 1, NewSequence(0))
+	if a[0][0] == b[0][0] && a[1][0] == b[1][0] {
+		t.Errorf("seeds 0 and 1 gave the same sample")
+	}
+	// with the largest uniforms, values would round up to the next
+	// interval without clamping.
+	for _, n := range []int{3, 7, 10, 49, 1000} {
+		points := LHS(n, 1, 0, onesSequence{NewSequence(0)})
+		seen := make([]bool, n)
+		for i, p := range points {
+			// p*n in floating point can round up to the next
+			// interval's start, so compute the interval exactly.
+			prod := new(big.Float).SetPrec(128).SetFloat64(p[0])
+			k64, _ := prod.Mul(prod, big.NewFloat(float64(n))).Int64()
+			k := int(k64)
+			if p[0] >= 1 || seen[k] {
+				t.Fatalf("n %d, point %d: value %g out of range or in a full interval", n, i, p[0])
+			}
+			seen[k] = true
+		}
+	}
+	if LHS(0, 1, 0, NewSequence(0)) != nil || LHS(1, 0, 0, NewSequence(0)) != nil || LHS(1, 1, 0, nil) != nil {
+		t.Errorf("expected nil for invalid inputs")
+	}
+}
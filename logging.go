@@ -0,0 +1,99 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// LoggingSequence wraps a Sequence, passing every call through to it
+// unchanged, and writing a line to an io.Writer for each value it
+// produces, for debugging generators. Lines look like:
+//
+//	BitsAt(0x1000000000000000000000007) = 0x4c0f0e8e0f4b3d4a91c14e5b3f7a2d01
+//	Uint64() = 0x91c14e5b3f7a2d01
+//
+// with offsets and results in the format of Uint128's String method.
+// Int63 is logged as Uint64, since that's what it calls. Since the
+// Sequence interface doesn't allow errors to be returned, the first
+// write error is available from Err, and later lines are not written.
+type LoggingSequence struct {
+	src Sequence
+	w   io.Writer
+	err error
+}
+
+// NewLoggingSequence returns a LoggingSequence wrapping src and logging
+// to w.
+func NewLoggingSequence(src Sequence, w io.Writer) (*LoggingSequence, error) {
+	if src == nil || w == nil {
+		return nil, errors.New("logging sequence requires a non-nil sequence and writer")
+	}
+	return &LoggingSequence{src: src, w: w}, nil
+}
+
+// Err returns the first error encountered writing the log, if any.
+func (s *LoggingSequence) Err() error {
+	return s.err
+}
+
+func (s *LoggingSequence) logf(format string, args ...interface{}) {
+	if s.err != nil {
+		return
+	}
+	_, s.err = fmt.Fprintf(s.w, format, args...)
+}
+
+// Seed reseeds the underlying Sequence.
+func (s *LoggingSequence) Seed(seed int64) {
+	s.src.Seed(seed)
+}
+
+// Int63 returns a value in 0..(1<<63)-1.
+func (s *LoggingSequence) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+// Uint64 returns the underlying Sequence's Uint64, logging it.
+func (s *LoggingSequence) Uint64() uint64 {
+	out := s.src.Uint64()
+	s.logf("Uint64() = %#x\n", out)
+	return out
+}
+
+// Seek seeks the underlying Sequence.
+func (s *LoggingSequence) Seek(offset Uint128) Uint128 {
+	return s.src.Seek(offset)
+}
+
+// BitsAt returns the underlying Sequence's BitsAt, logging the offset
+// and result.
+func (s *LoggingSequence) BitsAt(offset Uint128) Uint128 {
+	out := s.src.BitsAt(offset)
+	s.logf("BitsAt(%s) = %s\n", offset, out)
+	return out
+}
+
+// BitsSliceAt returns the underlying Sequence's BitsSliceAt, logging each
+// offset and result as BitsAt would.
+func (s *LoggingSequence) BitsSliceAt(start Uint128, dst []Uint128) {
+	s.src.BitsSliceAt(start, dst)
+	for i := range dst {
+		s.logf("BitsAt(%s) = %s\n", start, dst[i])
+		start.Inc()
+	}
+}
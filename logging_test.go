@@ -0,0 +1,67 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func Test_LoggingSequence(t *testing.T) {
+	var buf strings.Builder
+	src := NewSequence(1)
+	s, err := NewLoggingSequence(NewSequence(1), &buf)
+	if err != nil {
+		t.Fatalf("creating logging sequence: %v", err)
+	}
+	var exp strings.Builder
+	for i := uint64(0); i < 5; i++ {
+		off := OffsetFor(SequenceUser1, 2, 0, i)
+		got, direct := s.BitsAt(off), src.BitsAt(off)
+		if got != direct {
+			t.Fatalf("offset %s: logged sequence gave %s, expected %s", off, got, direct)
+		}
+		fmt.Fprintf(&exp, "BitsAt(%s) = %s\n", off, direct)
+	}
+	s.Seek(Uint128{Lo: 3})
+	src.Seek(Uint128{Lo: 3})
+	if got, direct := s.Uint64(), src.Uint64(); got != direct {
+		t.Fatalf("Uint64: logged sequence gave %#x, expected %#x", got, direct)
+	} else {
+		fmt.Fprintf(&exp, "Uint64() = %#x\n", direct)
+	}
+	if buf.String() != exp.String() {
+		t.Fatalf("log mismatch:\ngot:\n%s\nexpected:\n%s", buf.String(), exp.String())
+	}
+	if !strings.HasPrefix(buf.String(), "BitsAt(0x2") {
+		t.Errorf("unexpected log format: %q", buf.String())
+	}
+	failing, _ := NewLoggingSequence(NewSequence(1), failingWriter{})
+	failing.BitsAt(Uint128{})
+	if failing.Err() == nil {
+		t.Errorf("expected write error to be recorded")
+	}
+	if _, err := NewLoggingSequence(nil, &buf); err == nil {
+		t.Errorf("expected error for nil sequence")
+	}
+}
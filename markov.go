@@ -0,0 +1,69 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"fmt"
+	"math"
+)
+
+// markovChain is a discrete-time Markov chain over states [0,n), with a
+// fixed matrix of transition probabilities. Since each state depends on
+// the previous one, it can only be stepped through in order.
+type markovChain struct {
+	src   Sequence
+	seed  uint32
+	rows  []*aliasTable // rows[i] picks the state following state i
+	state int
+	step  uint64
+}
+
+// newMarkovChain creates a markovChain starting in state 0.
+// transitions[i][j] is the probability of moving from state i to state
+// j; each row must be non-negative and sum to 1.
+func newMarkovChain(transitions [][]float64, seed uint32, src Sequence) (*markovChain, error) {
+	n := len(transitions)
+	if n == 0 {
+		return nil, fmt.Errorf("need at least one state for Markov chain")
+	}
+	if src == nil {
+		return nil, fmt.Errorf("need a usable PRNG apophenia.Sequence")
+	}
+	m := &markovChain{src: src, seed: seed, rows: make([]*aliasTable, n)}
+	for i, row := range transitions {
+		if len(row) != n {
+			return nil, fmt.Errorf("transition matrix must be square: row %d has %d entries, need %d", i, len(row), n)
+		}
+		sum := 0.0
+		for j, p := range row {
+			if !(p >= 0) || math.IsInf(p, 1) {
+				return nil, fmt.Errorf("transition probability [%d][%d] must be finite and non-negative, got %g", i, j, p)
+			}
+			sum += p
+		}
+		if math.Abs(sum-1) > 1e-9 {
+			return nil, fmt.Errorf("transition probabilities for state %d must sum to 1, got %g", i, sum)
+		}
+		m.rows[i] = newAliasTable(row)
+	}
+	return m, nil
+}
+
+// next moves to the next state, and returns it.
+func (m *markovChain) next() int {
+	m.state = m.rows[m.state].sample(m.src.BitsAt(OffsetFor(SequenceMarkov, m.seed, 0, m.step)))
+	m.step++
+	return m.state
+}
@@ -0,0 +1,63 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_MarkovChain(t *testing.T) {
+	transitions := [][]float64{
+		{0.9, 0.1, 0},
+		{0.5, 0, 0.5},
+		{0, 0.2, 0.8},
+	}
+	m, err := newMarkovChain(transitions, 0, NewSequence(0))
+	if err != nil {
+		t.Fatalf("creating chain: %v", err)
+	}
+	const steps = 200000
+	var counts [3][3]int
+	prev := m.state
+	for i := 0; i < steps; i++ {
+		next := m.next()
+		counts[prev][next]++
+		prev = next
+	}
+	for i, row := range counts {
+		total := row[0] + row[1] + row[2]
+		for j, c := range row {
+			exp := transitions[i][j] * float64(total)
+			if math.Abs(float64(c)-exp) > 5*math.Sqrt(exp)+1 {
+				t.Errorf("transition %d->%d: expected about %.0f, got %d", i, j, exp, c)
+			}
+		}
+	}
+}
+
+func Test_MarkovChainInvalid(t *testing.T) {
+	for _, transitions := range [][][]float64{
+		nil,
+		{{0.5, 0.5}},
+		{{1, 0}, {0.5, 0.4}},
+		{{1, 0}, {-0.5, 1.5}},
+		{{1, 0}, {math.NaN(), 1}},
+	} {
+		if _, err := newMarkovChain(transitions, 0, NewSequence(0)); err == nil {
+			t.Errorf("%v: expected error", transitions)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import "fmt"
+
+// BipartiteMatching is a random one-to-one matching between two sets of
+// n items, such as the rows of two tables to be joined: left item i is
+// matched with right item Match(i). It's a Permutation, with a name
+// saying what it's for.
+type BipartiteMatching struct {
+	perm *Permutation
+}
+
+// NewBipartiteMatching creates a matching between two sets of n items,
+// which must be at least 1.
+func NewBipartiteMatching(n int64, seed uint32, src Sequence) (*BipartiteMatching, error) {
+	p, err := NewPermutation(n, seed, src)
+	if err != nil {
+		return nil, err
+	}
+	return &BipartiteMatching{perm: p}, nil
+}
+
+// Len returns the number of items in each set.
+func (m *BipartiteMatching) Len() int64 {
+	return m.perm.max
+}
+
+// Match returns the right item matched with left item i. It panics if i
+// is not in [0,n).
+func (m *BipartiteMatching) Match(i int64) int64 {
+	if i < 0 || i >= m.perm.max {
+		panic(fmt.Sprintf("item %d out of range [0,%d)", i, m.perm.max))
+	}
+	return m.perm.NthStateless(i)
+}
@@ -0,0 +1,54 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import "testing"
+
+func Test_BipartiteMatching(t *testing.T) {
+	const n = 5000
+	m, err := NewBipartiteMatching(n, 6, NewSequence(0))
+	if err != nil {
+		t.Fatalf("creating matching: %v", err)
+	}
+	if m.Len() != n {
+		t.Fatalf("expected length %d, got %d", n, m.Len())
+	}
+	again, _ := NewBipartiteMatching(n, 6, NewSequence(0))
+	other, _ := NewBipartiteMatching(n, 7, NewSequence(0))
+	matched := make([]bool, n)
+	differ := 0
+	for i := int64(0); i < n; i++ {
+		j := m.Match(i)
+		if j < 0 || j >= n {
+			t.Fatalf("item %d matched with out-of-range %d", i, j)
+		}
+		if matched[j] {
+			t.Fatalf("right item %d matched twice", j)
+		}
+		matched[j] = true
+		if again.Match(i) != j {
+			t.Fatalf("item %d: same seed gave matches %d and %d", i, j, again.Match(i))
+		}
+		if other.Match(i) != j {
+			differ++
+		}
+	}
+	if differ < n/2 {
+		t.Errorf("different seeds agree on %d of %d matches", n-differ, n)
+	}
+	if _, err := NewBipartiteMatching(0, 0, NewSequence(0)); err == nil {
+		t.Errorf("expected error for empty sets")
+	}
+}
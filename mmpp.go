@@ -0,0 +1,75 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"fmt"
+	"math"
+)
+
+// MMPP is a Markov-modulated Poisson process: a Poisson process whose
+// rate switches between a set of values according to a Markov chain.
+// It's a common model for bursty arrivals in teletraffic and network
+// simulation. Time is divided into slots of length dt; in each slot, the
+// number of events is Poisson with mean rate*dt for the current state's
+// rate, and then the chain moves to its next state.
+//
+// The process starts in state 0. Since each state depends on the
+// previous one, an MMPP can only be stepped through in order.
+type MMPP struct {
+	src   Sequence
+	seed  uint32
+	chain *markovChain
+	means []float64 // rates[i] * dt
+	slot  uint64
+}
+
+// NewMMPP creates an MMPP with the given per-state event rates, and a
+// transition matrix giving the probability of moving from state i to
+// state j after each slot. The rates must be finite and non-negative,
+// and each row of the matrix must sum to 1.
+func NewMMPP(rates []float64, transitionMatrix [][]float64, dt float64, seed uint32, src Sequence) (*MMPP, error) {
+	if len(rates) != len(transitionMatrix) {
+		return nil, fmt.Errorf("need one rate per state: got %d rates for %d states", len(rates), len(transitionMatrix))
+	}
+	if math.IsNaN(dt) || math.IsInf(dt, 0) || dt <= 0 {
+		return nil, fmt.Errorf("need finite dt > 0, got %g", dt)
+	}
+	chain, err := newMarkovChain(transitionMatrix, seed, src)
+	if err != nil {
+		return nil, err
+	}
+	means := make([]float64, len(rates))
+	for i, r := range rates {
+		means[i] = r * dt
+		if math.IsNaN(r) || math.IsInf(means[i], 0) || r < 0 {
+			return nil, fmt.Errorf("rate %d must be finite and non-negative, got %g", i, r)
+		}
+	}
+	return &MMPP{src: src, seed: seed, chain: chain, means: means}, nil
+}
+
+// State returns the state the process is in for the next slot.
+func (m *MMPP) State() int {
+	return m.chain.state
+}
+
+// Next returns the number of events in the next time slot.
+func (m *MMPP) Next() uint64 {
+	count := poissonAt(m.means[m.chain.state], OffsetFor(SequenceMMPP, m.seed, 0, m.slot), m.src)
+	m.slot++
+	m.chain.next()
+	return count
+}
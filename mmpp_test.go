@@ -0,0 +1,85 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_MMPPRate(t *testing.T) {
+	rates := []float64{2, 50, 10}
+	transitions := [][]float64{
+		{0.95, 0.05, 0},
+		{0.2, 0.7, 0.1},
+		{0.1, 0, 0.9},
+	}
+	const dt = 0.5
+	m, err := NewMMPP(rates, transitions, dt, 0, NewSequence(0))
+	if err != nil {
+		t.Fatalf("creating MMPP: %v", err)
+	}
+	// find the stationary distribution by power iteration.
+	pi := []float64{1, 0, 0}
+	for iter := 0; iter < 1000; iter++ {
+		next := make([]float64, len(pi))
+		for i := range pi {
+			for j := range pi {
+				next[j] += pi[i] * transitions[i][j]
+			}
+		}
+		pi = next
+	}
+	expRate := 0.0
+	for i, r := range rates {
+		expRate += pi[i] * r
+	}
+	const slots = 500000
+	total := 0.0
+	for i := 0; i < slots; i++ {
+		total += float64(m.Next())
+	}
+	rate := total / (slots * dt)
+	// State changes make consecutive slots correlated, so allow more
+	// slack than independent samples would need.
+	if math.Abs(rate-expRate) > 0.02*expRate {
+		t.Errorf("expected marginal rate about %g, got %g", expRate, rate)
+	}
+}
+
+func Test_MMPPInvalid(t *testing.T) {
+	ok := [][]float64{{1}}
+	src := NewSequence(0)
+	cases := []struct {
+		rates       []float64
+		transitions [][]float64
+		dt          float64
+	}{
+		{[]float64{1, 2}, ok, 1},
+		{[]float64{-1}, ok, 1},
+		{[]float64{math.NaN()}, ok, 1},
+		{[]float64{1}, ok, 0},
+		{[]float64{1}, ok, math.Inf(1)},
+		{[]float64{1}, [][]float64{{0.5}}, 1},
+	}
+	for _, c := range cases {
+		if _, err := NewMMPP(c.rates, c.transitions, c.dt, 0, src); err == nil {
+			t.Errorf("rates %v, transitions %v, dt %g: expected error", c.rates, c.transitions, c.dt)
+		}
+	}
+	if _, err := NewMMPP([]float64{1}, ok, 1, 0, nil); err == nil {
+		t.Errorf("expected error for nil Sequence")
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// MultiStream provides a number of independent Sequences derived from a
+// single seed, for generating data in parallel: for instance, one
+// goroutine per column of a table. Each stream is a separate AES-based
+// Sequence with its own key, taken from the bits of the seed's Sequence
+// at an offset determined by the stream ID, so streams are as unrelated
+// as Sequences with different seeds.
+//
+// A Sequence from NewSequence is only safe for concurrent use through
+// BitsAt and BitsSliceAt, since Uint64 and Seek share a position, but
+// each stream is a separate Sequence, so different goroutines can use
+// different streams freely.
+type MultiStream struct {
+	streams []Sequence
+}
+
+// NewMultiStream returns a MultiStream with the given number of streams.
+func NewMultiStream(numStreams int, seed int64) *MultiStream {
+	return newMultiStream(numStreams, NewSequence(seed))
+}
+
+// NewMultiStreamFromSeed returns a MultiStream with the given number of
+// streams, derived from a Seed. NewMultiStreamFromSeed(n,
+// SeedFromInt64(s)) is the same as NewMultiStream(n, s).
+func NewMultiStreamFromSeed(numStreams int, seed Seed) *MultiStream {
+	return newMultiStream(numStreams, NewSequenceFromSeed(seed))
+}
+
+// newMultiStream derives the streams' keys from master.
+func newMultiStream(numStreams int, master Sequence) *MultiStream {
+	if numStreams < 0 {
+		numStreams = 0
+	}
+	m := &MultiStream{streams: make([]Sequence, numStreams)}
+	var key [16]byte
+	for id := range m.streams {
+		bits := master.BitsAt(OffsetFor(SequenceStreamKey, 0, 0, uint64(id)))
+		binary.LittleEndian.PutUint64(key[:8], bits.Lo)
+		binary.LittleEndian.PutUint64(key[8:], bits.Hi)
+		m.streams[id] = newAESSequence(key)
+	}
+	return m
+}
+
+// Len returns the number of streams.
+func (m *MultiStream) Len() int {
+	return len(m.streams)
+}
+
+// Stream returns the Sequence for the given stream ID, which must be in
+// [0,Len()). The same ID always yields the same Sequence object.
+func (m *MultiStream) Stream(id int) Sequence {
+	if id < 0 || id >= len(m.streams) {
+		panic(fmt.Sprintf("stream %d out of range [0,%d)", id, len(m.streams)))
+	}
+	return m.streams[id]
+}
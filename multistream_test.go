@@ -0,0 +1,82 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math/bits"
+	"sync"
+	"testing"
+)
+
+func Test_MultiStream(t *testing.T) {
+	const streams, samples = 8, 10000
+	m := NewMultiStream(streams, 1)
+	if m.Len() != streams {
+		t.Fatalf("expected %d streams, got %d", streams, m.Len())
+	}
+	// generate from all streams in parallel, as intended.
+	outputs := make([][]Uint128, streams)
+	var wg sync.WaitGroup
+	for id := 0; id < streams; id++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			s := m.Stream(id)
+			out := make([]Uint128, samples)
+			for i := range out {
+				out[i] = s.BitsAt(OffsetFor(SequenceDefault, 0, 0, uint64(i)))
+			}
+			outputs[id] = out
+		}(id)
+	}
+	wg.Wait()
+	for i := 0; i < samples; i++ {
+		for a := 0; a < streams; a++ {
+			for b := a + 1; b < streams; b++ {
+				if outputs[a][i] == outputs[b][i] {
+					t.Fatalf("streams %d and %d agree at index %d", a, b, i)
+				}
+			}
+		}
+	}
+	// bits of different streams at the same index should agree about
+	// half the time.
+	for b := 1; b < streams; b++ {
+		same := 0
+		for i := 0; i < samples; i++ {
+			x := outputs[0][i]
+			x.Xor(outputs[b][i])
+			x.Not()
+			same += bitsSet(x)
+		}
+		exp := 64.0 * samples
+		if d := float64(same) - exp; d*d > 25*exp/2 {
+			t.Errorf("streams 0 and %d share %d of %d bits", b, same, 128*samples)
+		}
+	}
+	again := NewMultiStream(streams, 1)
+	if again.Stream(3).BitsAt(Uint128{}) != m.Stream(3).BitsAt(Uint128{}) {
+		t.Errorf("same seed gave different streams")
+	}
+	other := NewMultiStream(streams, 2)
+	if other.Stream(3).BitsAt(Uint128{}) == m.Stream(3).BitsAt(Uint128{}) {
+		t.Errorf("different seeds gave the same stream")
+	}
+}
+
+// bitsSet counts the bits set in u.
+func bitsSet(u Uint128) int {
+	return bits.OnesCount64(u.Lo) + bits.OnesCount64(u.Hi)
+}
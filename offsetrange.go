@@ -0,0 +1,126 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"fmt"
+	"sync"
+)
+
+// OffsetRange describes a part of the offset space used for some
+// purpose: the offsets produced by OffsetFor with a given class and
+// iterations from MinIter through MaxIter, inclusive, for any seed and
+// id. Two users of the same Sequence whose ranges overlap may get the
+// same bits, and so produce correlated results.
+type OffsetRange struct {
+	Name             string
+	Class            SequenceClass
+	MinIter, MaxIter uint32 // at most 1<<24 - 1
+}
+
+func (r OffsetRange) String() string {
+	return fmt.Sprintf("%s (class %d, iterations %d-%d)", r.Name, r.Class, r.MinIter, r.MaxIter)
+}
+
+// CheckOffsetOverlap reports whether a and b overlap.
+func CheckOffsetOverlap(a, b OffsetRange) bool {
+	return a.Class == b.Class && a.MinIter <= b.MaxIter && b.MinIter <= a.MaxIter
+}
+
+// allIters is the largest iteration OffsetFor can express without
+// running into the class bits.
+const allIters = 1<<24 - 1
+
+// builtinOffsetRanges are the ranges apophenia itself uses. Each built-in
+// class claims all of its iterations. SequenceDefault, SequenceUser1,
+// and SequenceUser2 are left for users.
+var builtinOffsetRanges = []OffsetRange{
+	{"Permutation keys", SequencePermutationK, 0, allIters},
+	{"Permutation rounds", SequencePermutationF, 0, allIters},
+	{"Weighted", SequenceWeighted, 0, allIters},
+	{"Uniform", SequenceLinear, 0, allIters},
+	{"Zipf", SequenceZipfU, 0, allIters},
+	{"rand.Source", SequenceRandSource, 0, allIters},
+	{"Benford", SequenceBenford, 0, allIters},
+	{"YuleSimon", SequenceYuleSimon, 0, allIters},
+	{"PowerLaw", SequencePowerLaw, 0, allIters},
+	{"Hash", SequenceHash, 0, allIters},
+	{"DynamicWeighted", SequenceDynamicWeighted, 0, allIters},
+	{"coupon collector", SequenceCouponCollector, 0, allIters},
+	{"Poisson", SequencePoisson, 0, allIters},
+	{"Markov chain", SequenceMarkov, 0, allIters},
+	{"MMPP", SequenceMMPP, 0, allIters},
+	{"Halton", SequenceHalton, 0, allIters},
+	{"Sobol", SequenceSobol, 0, allIters},
+	{"Latin hypercube", SequenceLatinHypercube, 0, allIters},
+	{"BipartiteGraph", SequenceBipartite, 0, allIters},
+	{"RandomTree", SequenceRandomTree, 0, allIters},
+	{"PoissonProcess", SequencePoissonProcess, 0, allIters},
+	{"Exponential", SequenceExponential, 0, allIters},
+	{"RenewalProcess", SequenceRenewal, 0, allIters},
+	{"StratifiedSampler", SequenceStratified, 0, allIters},
+	{"mixture", SequenceMixture, 0, allIters},
+	{"MultiStream keys", SequenceStreamKey, 0, allIters},
+	{"Bernoulli", SequenceBernoulli, 0, allIters},
+	{"RunLength", SequenceRunLength, 0, allIters},
+	{"PoissonCluster", SequencePoissonCluster, 0, allIters},
+	{"Uint64At", SequenceUint64, 0, allIters},
+	{"AvalancheScore", SequenceAvalanche, 0, allIters},
+	{"ParetoFraction", SequenceParetoFraction, 0, allIters},
+	{"BigPermutation", SequenceBigPermutation, 0, allIters},
+	{"ZipfTable", SequenceZipfTable, 0, allIters},
+	{"BytesPermutation", SequenceBytesPermutation, 0, allIters},
+	{"EstimateFixedPoints", SequenceFixedPoints, 0, allIters},
+	{"OrderStatistic", SequenceOrderStatistic, 0, allIters},
+	{"DiscreteGaussian", SequenceGaussian, 0, allIters},
+}
+
+var (
+	registeredRangesMu sync.Mutex
+	// registeredRanges holds the built-in ranges, plus any added by
+	// RegisterOffsetRange.
+	registeredRanges []OffsetRange
+)
+
+func init() {
+	for i, a := range builtinOffsetRanges {
+		for _, b := range builtinOffsetRanges[:i] {
+			if CheckOffsetOverlap(a, b) {
+				panic(fmt.Sprintf("built-in offset ranges overlap: %s and %s", a, b))
+			}
+		}
+	}
+	registeredRanges = append(registeredRanges, builtinOffsetRanges...)
+}
+
+// RegisterOffsetRange records that r is in use, returning an error if it
+// overlaps a built-in range or one registered previously, or isn't a
+// valid range. Nothing else consults the registered ranges; registering
+// them is a way for a program combining several generators to check
+// that they don't collide, typically in an init function.
+func RegisterOffsetRange(r OffsetRange) error {
+	if r.MinIter > r.MaxIter || r.MaxIter > allIters {
+		return fmt.Errorf("invalid offset range %s", r)
+	}
+	registeredRangesMu.Lock()
+	defer registeredRangesMu.Unlock()
+	for _, existing := range registeredRanges {
+		if CheckOffsetOverlap(r, existing) {
+			return fmt.Errorf("offset range %s overlaps %s", r, existing)
+		}
+	}
+	registeredRanges = append(registeredRanges, r)
+	return nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import "testing"
+
+func Test_OffsetRanges(t *testing.T) {
+	seen := make(map[SequenceClass]string)
+	for i, a := range builtinOffsetRanges {
+		for _, b := range builtinOffsetRanges[:i] {
+			if CheckOffsetOverlap(a, b) {
+				t.Errorf("built-in ranges overlap: %s and %s", a, b)
+			}
+		}
+		switch a.Class {
+		case SequenceDefault, SequenceUser1, SequenceUser2:
+			t.Errorf("built-in range %s uses a class reserved for users", a)
+		}
+		if prev, ok := seen[a.Class]; ok {
+			t.Errorf("class %d used by both %s and %s", a.Class, prev, a.Name)
+		}
+		seen[a.Class] = a.Name
+	}
+	// leave registeredRanges as we found it.
+	registeredRangesMu.Lock()
+	saved := append([]OffsetRange(nil), registeredRanges...)
+	registeredRangesMu.Unlock()
+	defer func() {
+		registeredRangesMu.Lock()
+		registeredRanges = saved
+		registeredRangesMu.Unlock()
+	}()
+	if err := RegisterOffsetRange(OffsetRange{"first", SequenceUser1, 0, 99}); err != nil {
+		t.Fatalf("registering first range: %v", err)
+	}
+	if err := RegisterOffsetRange(OffsetRange{"second", SequenceUser1, 100, 199}); err != nil {
+		t.Fatalf("registering adjacent range: %v", err)
+	}
+	if err := RegisterOffsetRange(OffsetRange{"other class", SequenceUser2, 50, 150}); err != nil {
+		t.Fatalf("registering range in another class: %v", err)
+	}
+	for _, r := range []OffsetRange{
+		{"overlapping", SequenceUser1, 150, 250},
+		{"inside", SequenceUser1, 10, 20},
+		{"Zipf again", SequenceZipfU, 5, 5},
+		{"backwards", SequenceUser2, 9, 1},
+		{"too many", SequenceUser2, 1000, 1 << 24},
+	} {
+		if err := RegisterOffsetRange(r); err == nil {
+			t.Errorf("registering %s: expected error", r)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import "fmt"
+
+// Iteration bases for the two gamma variates OrderStatistic divides to
+// get a beta variate. Each uses a couple of iterations per attempt, so
+// these leave plenty of room.
+const (
+	orderStatisticIterX = 0
+	orderStatisticIterY = 1 << 22
+)
+
+// OrderStatistic returns the nth smallest of m independent Uniform(0,1)
+// values, for 1 <= n <= m, without generating the other m-1; for
+// instance, the low end of a range query covering a given fraction of m
+// rows. That's a Beta(n, m-n+1) variate, with mean n/(m+1), computed from
+// two gamma variates as X/(X+Y). The value depends only on n, m, seed,
+// and index, so it's fully seekable; different indexes give independent
+// values. It panics if n is 0 or more than m.
+func OrderStatistic(n, m uint64, seed uint32, index uint64, src Sequence) float64 {
+	if n < 1 || n > m {
+		panic(fmt.Sprintf("order statistic %d of %d values out of range", n, m))
+	}
+	x := gammaAt(float64(n), OffsetFor(SequenceOrderStatistic, seed, orderStatisticIterX, index), src)
+	y := gammaAt(float64(m-n+1), OffsetFor(SequenceOrderStatistic, seed, orderStatisticIterY, index), src)
+	return x / (x + y)
+}
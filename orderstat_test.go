@@ -0,0 +1,73 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_OrderStatistic(t *testing.T) {
+	samples := uint64(20000)
+	if testing.Short() {
+		samples = 5000
+	}
+	src := NewSequence(0)
+	for _, c := range []struct{ n, m uint64 }{{1, 1}, {1, 10}, {5, 10}, {10, 10}, {3, 1000}, {500000, 1000000}} {
+		n, m := float64(c.n), float64(c.m)
+		mean := n / (m + 1)
+		variance := n * (m - n + 1) / ((m + 1) * (m + 1) * (m + 2))
+		sum, below := 0.0, 0
+		for i := uint64(0); i < samples; i++ {
+			v := OrderStatistic(c.n, c.m, 1, i, src)
+			if !(v >= 0 && v <= 1) {
+				t.Fatalf("n %d, m %d, index %d: %g out of range", c.n, c.m, i, v)
+			}
+			if v < mean {
+				below++
+			}
+			sum += v
+		}
+		got := sum / float64(samples)
+		if d := math.Abs(got - mean); d > 5*math.Sqrt(variance/float64(samples)) {
+			t.Errorf("n %d, m %d: expected mean %g, got %g", c.n, c.m, mean, got)
+		}
+		if c.n == 1 && c.m == 1 {
+			// a single uniform is below 1/2 half the time.
+			if d := math.Abs(float64(below) - float64(samples)/2); d > 5*math.Sqrt(float64(samples)/4) {
+				t.Errorf("n 1, m 1: %d of %d below 1/2", below, samples)
+			}
+		}
+	}
+	// seekable: the same arguments give the same value, and the seed
+	// matters.
+	a := OrderStatistic(3, 7, 2, 99, src)
+	if b := OrderStatistic(3, 7, 2, 99, NewSequence(0)); a != b {
+		t.Errorf("same arguments gave %g and %g", a, b)
+	}
+	if b := OrderStatistic(3, 7, 3, 99, src); a == b {
+		t.Errorf("seeds 2 and 3 both gave %g", a)
+	}
+	for _, bad := range []struct{ n, m uint64 }{{0, 5}, {6, 5}, {0, 0}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("n %d, m %d: expected panic", bad.n, bad.m)
+				}
+			}()
+			OrderStatistic(bad.n, bad.m, 0, 0, src)
+		}()
+	}
+}
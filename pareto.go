@@ -0,0 +1,93 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"fmt"
+	"math"
+)
+
+// ParetoFraction produces values in [0,n) following the "80/20 rule":
+// the most common fraction k of the values account for a fraction 1-k of
+// the results, so with k of 0.2, the values below 0.2n make up 80% of
+// the results. Value 0 is the most common, and frequency decreases with
+// value. The distribution is self-similar, so the rule also holds within
+// the most common fraction k, and so on: with k of 0.2, 4% of the
+// values account for 64% of the results.
+//
+// This is the self-similar distribution from Gray et al., "Quickly
+// Generating Billion-Record Synthetic Databases" (SIGMOD 1994): value
+// floor(n * u^(log k / log(1-k))) for uniform u. It's used rather than
+// calibrating a Zipf because with v of 1 and large n, even a Zipf with q
+// just over 1 (the smallest it allows) is more skewed than 80/20.
+type ParetoFraction struct {
+	src      Sequence
+	seed     uint32
+	n        uint64
+	exponent float64 // log k / log(1-k)
+	idx      uint64
+}
+
+// NewParetoFraction creates a ParetoFraction producing values in [0,n),
+// where a fraction k, in (0,0.5], of the values produce 1-k of the
+// results. A k of 0.5 is uniform.
+func NewParetoFraction(k float64, n uint64, seed uint32, src Sequence) (*ParetoFraction, error) {
+	if !(k > 0 && k <= 0.5) {
+		return nil, fmt.Errorf("need k in (0,0.5] (got %g) for Pareto fraction distribution", k)
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("need n > 0 for Pareto fraction distribution")
+	}
+	if src == nil {
+		return nil, fmt.Errorf("need a usable PRNG apophenia.Sequence")
+	}
+	return &ParetoFraction{src: src, seed: seed, n: n, exponent: math.Log(k) / math.Log(1-k)}, nil
+}
+
+// NewParetoFractionFromSeed is NewParetoFraction with seed.Uint32() as
+// the seed.
+func NewParetoFractionFromSeed(k float64, n uint64, seed Seed, src Sequence) (*ParetoFraction, error) {
+	return NewParetoFraction(k, n, seed.Uint32(), src)
+}
+
+// Nth returns the value for the given index. After calling Nth(x), Next
+// returns the same value as Nth(x+1).
+func (p *ParetoFraction) Nth(index uint64) uint64 {
+	p.idx = index + 1
+	u := p.src.BitsAt(OffsetFor(SequenceParetoFraction, p.seed, 0, index)).ToFloat64()
+	v := uint64(float64(p.n) * math.Pow(u, p.exponent))
+	// u^exponent is less than 1, but the product can round up to n.
+	if v >= p.n {
+		v = p.n - 1
+	}
+	return v
+}
+
+// Next returns the value after the last one requested, or the value for
+// index 0 if none have been requested.
+func (p *ParetoFraction) Next() uint64 {
+	return p.Nth(p.idx)
+}
+
+// Probability returns the probability of the given value.
+func (p *ParetoFraction) Probability(value uint64) float64 {
+	if value >= p.n {
+		return 0
+	}
+	// P(result < x) = (x/n)^(1/exponent).
+	c := 1 / p.exponent
+	n := float64(p.n)
+	return math.Pow(float64(value+1)/n, c) - math.Pow(float64(value)/n, c)
+}
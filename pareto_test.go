@@ -0,0 +1,64 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_ParetoFraction(t *testing.T) {
+	const n, draws = 10000, 10000
+	src := NewSequence(0)
+	for _, k := range []float64{0.1, 0.2, 0.3, 0.5} {
+		p, err := NewParetoFraction(k, n, 1, src)
+		if err != nil {
+			t.Fatalf("k %g: %v", k, err)
+		}
+		top := 0
+		for i := 0; i < draws; i++ {
+			v := p.Next()
+			if v >= n {
+				t.Fatalf("k %g: value %d out of range", k, v)
+			}
+			if v < uint64(k*n) {
+				top++
+			}
+		}
+		// 5 sigma.
+		got := float64(top) / draws
+		if sigma := math.Sqrt(k * (1 - k) / draws); math.Abs(got-(1-k)) > 5*sigma {
+			t.Errorf("k %g: top %g%% of values in %.1f%% of draws, expected %g%%", k, k*100, got*100, (1-k)*100)
+		}
+		total, top2 := 0.0, 0.0
+		for v := uint64(0); v < n; v++ {
+			total += p.Probability(v)
+			if v < uint64(k*n) {
+				top2 += p.Probability(v)
+			}
+		}
+		if math.Abs(total-1) > 1e-9 || math.Abs(top2-(1-k)) > 1e-9 {
+			t.Errorf("k %g: probabilities sum to %g, top fraction to %g", k, total, top2)
+		}
+	}
+	for _, k := range []float64{0, -0.1, 0.6, 1, math.NaN()} {
+		if _, err := NewParetoFraction(k, n, 0, src); err == nil {
+			t.Errorf("k %g: expected error", k)
+		}
+	}
+	if _, err := NewParetoFraction(0.2, 0, 0, src); err == nil {
+		t.Errorf("n 0: expected error")
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import "fmt"
+
+// PermutationHashMap is a permutation of [0,max) which computes values
+// from a Permutation on demand, but lets individual values be
+// overridden, for use as a test double: a test can force a specific
+// position to yield a specific value, and everything else still behaves
+// like the underlying Permutation. Overrides are stored in a map, so
+// memory use is proportional to the number of overrides, not to max.
+//
+// Overriding keeps the mapping a bijection. Making position a yield
+// value v also makes the position which used to yield v yield a's old
+// value, so the two swap.
+type PermutationHashMap struct {
+	perm      *Permutation
+	overrides map[int64]int64 // position -> value, where overridden
+	positions map[int64]int64 // value -> position, where overridden
+}
+
+// NewPermutationHashMap creates a PermutationHashMap with no overrides,
+// which yields the same values NewPermutation would produce for the
+// given max, seed, and Sequence.
+func NewPermutationHashMap(max int64, seed uint32, src Sequence) (*PermutationHashMap, error) {
+	p, err := NewPermutation(max, seed, src)
+	if err != nil {
+		return nil, err
+	}
+	return &PermutationHashMap{
+		perm:      p,
+		overrides: make(map[int64]int64),
+		positions: make(map[int64]int64),
+	}, nil
+}
+
+func (m *PermutationHashMap) checkRange(what string, n int64) {
+	if n < 0 || n >= m.perm.max {
+		panic(fmt.Sprintf("%s %d out of range [0,%d)", what, n, m.perm.max))
+	}
+}
+
+// Nth returns the value at position n. It panics if n is not in [0,max).
+func (m *PermutationHashMap) Nth(n int64) int64 {
+	m.checkRange("position", n)
+	if v, ok := m.overrides[n]; ok {
+		return v
+	}
+	return m.perm.permute(n)
+}
+
+// Position returns the position which yields value. It panics if value
+// is not in [0,max).
+func (m *PermutationHashMap) Position(value int64) int64 {
+	m.checkRange("value", value)
+	if pos, ok := m.positions[value]; ok {
+		return pos
+	}
+	// If the position the Permutation maps to value had been overridden,
+	// value would have moved somewhere else and be in positions.
+	return m.perm.unpermute(value)
+}
+
+// Set makes position n yield value, and the position which previously
+// yielded value yield n's previous value. It panics if n or value is not
+// in [0,max).
+func (m *PermutationHashMap) Set(n, value int64) {
+	old := m.Nth(n)
+	other := m.Position(value)
+	if other == n {
+		return
+	}
+	m.set(n, value)
+	m.set(other, old)
+}
+
+// set records that pos yields value, dropping the override if that's
+// what the Permutation would yield anyway.
+func (m *PermutationHashMap) set(pos, value int64) {
+	if m.perm.permute(pos) == value {
+		delete(m.overrides, pos)
+		delete(m.positions, value)
+		return
+	}
+	m.overrides[pos] = value
+	m.positions[value] = pos
+}
+
+// Overrides returns the number of positions which currently don't yield
+// the Permutation's value.
+func (m *PermutationHashMap) Overrides() int {
+	return len(m.overrides)
+}
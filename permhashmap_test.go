@@ -0,0 +1,85 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import "testing"
+
+func Test_PermutationHashMap(t *testing.T) {
+	const max = 1000000000000000
+	queries := int64(1000000)
+	if testing.Short() {
+		queries = 10000
+	}
+	m, err := NewPermutationHashMap(max, 2, NewSequence(0))
+	if err != nil {
+		t.Fatalf("creating permutation: %v", err)
+	}
+	m.Set(5, 12345)
+	m.Set(99, 5)
+	seen := make(map[int64]int64, queries)
+	for n := int64(0); n < queries; n++ {
+		// spread the queries over the whole range.
+		pos := n * (max / queries)
+		v := m.Nth(pos)
+		if v < 0 || v >= max {
+			t.Fatalf("position %d: value %d out of range", pos, v)
+		}
+		if prev, ok := seen[v]; ok {
+			t.Fatalf("positions %d and %d both yield %d", prev, pos, v)
+		}
+		seen[v] = pos
+		if n%1000 == 0 && m.Position(v) != pos {
+			t.Fatalf("value %d: expected position %d, got %d", v, pos, m.Position(v))
+		}
+	}
+	if m.Nth(5) != 12345 || m.Nth(99) != 5 {
+		t.Fatalf("overrides not applied: Nth(5) = %d, Nth(99) = %d", m.Nth(5), m.Nth(99))
+	}
+}
+
+func Test_PermutationHashMapSet(t *testing.T) {
+	const max = 200
+	m, err := NewPermutationHashMap(max, 0, NewSequence(0))
+	if err != nil {
+		t.Fatalf("creating permutation: %v", err)
+	}
+	src := NewSequence(1)
+	for i := uint64(0); i < 500; i++ {
+		r := src.BitsAt(Uint128{Lo: i})
+		n, v := int64(r.Lo%max), int64(r.Hi%max)
+		m.Set(n, v)
+		if m.Nth(n) != v {
+			t.Fatalf("set %d to %d, got %d", n, v, m.Nth(n))
+		}
+		seen := make([]bool, max)
+		for pos := int64(0); pos < max; pos++ {
+			got := m.Nth(pos)
+			if seen[got] {
+				t.Fatalf("after %d sets: value %d repeated", i+1, got)
+			}
+			seen[got] = true
+			if m.Position(got) != pos {
+				t.Fatalf("after %d sets: value %d at %d, Position gives %d", i+1, got, pos, m.Position(got))
+			}
+		}
+	}
+	// putting everything back removes the overrides.
+	for pos := int64(0); pos < max; pos++ {
+		m.Set(pos, m.perm.permute(pos))
+	}
+	if m.Overrides() != 0 {
+		t.Errorf("expected no overrides after restoring, got %d", m.Overrides())
+	}
+}
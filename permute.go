@@ -15,8 +15,12 @@
 package apophenia
 
 import (
+	"encoding/binary"
 	"errors"
+	"fmt"
+	"math"
 	"math/bits"
+	"sync"
 )
 
 // PermutationGenerator provides a way to pass integer IDs through a permutation
@@ -61,47 +65,206 @@ import (
 // We could probably trim rounds to 64 or so and not lose much data.
 type Permutation struct {
 	src      Sequence
+	round    RoundFunc // nil means src.BitsAt
 	permSeed uint32
 	max      int64
+	lo       int64 // added to every value, for NewPermutationRange
 	counter  int64
 	rounds   int
-	bits     Uint128
 	k        []uint64
 }
 
+// RoundFunc yields the pseudo-random bits a Permutation uses for a given
+// offset, in place of the Sequence's BitsAt. The src parameter is the
+// Sequence the Permutation was created with, which may be nil if the
+// RoundFunc doesn't need one. A RoundFunc must be deterministic; the
+// Permutation is only a permutation if the same offset always produces
+// the same bits.
+type RoundFunc func(offset Uint128, src Sequence) Uint128
+
+// SequenceRoundFunc is the default RoundFunc, which just uses src.BitsAt.
+func SequenceRoundFunc(offset Uint128, src Sequence) Uint128 {
+	return src.BitsAt(offset)
+}
+
 // NewPermutation creates a Permutation which generates values in [0,max),
 // from a given Sequence and seed value.
 //
 // The seed parameter selects different shuffles, and is useful if you need
 // to generate multiple distinct shuffles from the same underlying sequence.
 // Treat it as a secondary seed.
-func NewPermutation(max int64, seed uint32, src Sequence) (*Permutation, error) {
+func NewPermutation(max int64, seed uint32, src Sequence, opts ...PermutationOption) (*Permutation, error) {
+	var config permutationConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return newPermutation(max, seed, 0, src, nil, config.workers)
+}
+
+// NewPermutationFromSeed is NewPermutation with a Seed. Permutations only
+// use 32 bits of secondary seed, so this uses seed.Uint32(); the
+// Sequence is what a full Seed should go into.
+func NewPermutationFromSeed(max int64, seed Seed, src Sequence, opts ...PermutationOption) (*Permutation, error) {
+	return NewPermutation(max, seed.Uint32(), src, opts...)
+}
+
+// NewPermutationRange creates a Permutation like NewPermutation, but
+// generating values in [lo,hi) rather than [0,max). Its values are the
+// ones NewPermutation(hi-lo, seed, src) would produce, plus lo.
+// NextFloat64 and NthFloat64 still yield values in [0,1), and it can't be
+// encoded with GobEncode or MarshalText.
+func NewPermutationRange(lo, hi int64, seed uint32, src Sequence) (*Permutation, error) {
+	if lo >= hi {
+		return nil, fmt.Errorf("Permutation range needs lo < hi, got [%d,%d)", lo, hi)
+	}
+	max := hi - lo
+	if max < 0 {
+		return nil, fmt.Errorf("Permutation range [%d,%d) has more than 2^63-1 values", lo, hi)
+	}
+	p, err := newPermutation(max, seed, 0, src, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	p.lo = lo
+	return p, nil
+}
+
+// permutationConfig holds the settings PermutationOptions change.
+type permutationConfig struct {
+	workers int
+}
+
+// PermutationOption is an optional setting for NewPermutation.
+type PermutationOption func(*permutationConfig)
+
+// WithParallelInit computes the Permutation's per-round keys using the
+// given number of goroutines, which can be noticeably faster for very
+// large max values, which need hundreds of rounds. It has no effect on
+// the resulting Permutation. Each goroutine needs its own copy of the
+// Sequence, so this only works with Sequences from NewSequence and
+// related functions; with other Sequences, or a workers count under 2,
+// initialization is sequential.
+func WithParallelInit(workers int) PermutationOption {
+	return func(c *permutationConfig) {
+		c.workers = workers
+	}
+}
+
+// NewPermutationU128 creates a Permutation like NewPermutation, but with a
+// 128-bit seed, for users who need more than 2^32 distinct shuffles from
+// one Sequence. The seed is hashed, using src, into the key of a new
+// Sequence which the Permutation then uses in place of src, so src need
+// not be used again after this returns.
+func NewPermutationU128(max int64, seed Uint128, src Sequence) (*Permutation, error) {
+	if src == nil {
+		return nil, errors.New("need a usable PRNG apophenia.Sequence")
+	}
+	var key [16]byte
+	binary.LittleEndian.PutUint64(key[:8], seed.Lo)
+	binary.LittleEndian.PutUint64(key[8:], seed.Hi)
+	hashed := Hash128(key[:], 0, src)
+	binary.LittleEndian.PutUint64(key[:8], hashed.Lo)
+	binary.LittleEndian.PutUint64(key[8:], hashed.Hi)
+	return newPermutation(max, 0, 0, newAESSequence(key), nil, 0)
+}
+
+// NewPermutationWithRoundFunc creates a Permutation like NewPermutation,
+// but using f rather than src.BitsAt to generate the K values and round
+// functions, and with a specified number of rounds. If rounds is 0, the
+// default of 6 log N is used. If f is nil, SequenceRoundFunc is used,
+// in which case src must not be nil; otherwise src is only passed along
+// to f.
+func NewPermutationWithRoundFunc(max int64, seed uint32, rounds int, src Sequence, f RoundFunc) (*Permutation, error) {
+	if rounds < 0 {
+		return nil, fmt.Errorf("rounds must not be negative, got %d", rounds)
+	}
+	if f == nil && src == nil {
+		return nil, errors.New("need either a Sequence or a RoundFunc")
+	}
+	return newPermutation(max, seed, rounds, src, f, 0)
+}
+
+func newPermutation(max int64, seed uint32, rounds int, src Sequence, f RoundFunc, workers int) (*Permutation, error) {
 	if max < 1 {
-		return nil, errors.New("period must be positive")
+		return nil, fmt.Errorf("Permutation max must be at least 1, got %d", max)
+	}
+	if rounds == 0 {
+		// number of rounds to get "good" results is roughly 6 log N.
+		bits := 64 - bits.LeadingZeros64(uint64(max))
+		rounds = 6 * bits
 	}
-	// number of rounds to get "good" results is roughly 6 log N.
-	bits := 64 - bits.LeadingZeros64(uint64(max))
-	p := Permutation{max: max, rounds: 6 * bits, counter: 0}
+	p := Permutation{max: max, rounds: rounds, counter: 0}
 
 	p.src = src
+	p.round = f
 	p.k = make([]uint64, p.rounds)
 	p.permSeed = seed
+	if aes, ok := src.(*aesSequence128); ok && f == nil && workers > 1 {
+		p.initKParallel(workers, aes.key)
+	} else {
+		p.initK()
+	}
+	return &p, nil
+}
+
+// initK computes the per-round keys, sequentially.
+func (p *Permutation) initK() {
+	for i := range p.k {
+		p.k[i] = p.computeK(uint64(i), p.src)
+	}
+}
+
+// initKParallel computes the per-round keys using the given number of
+// goroutines, each with its own Sequence using the given AES key, so
+// it's only usable when p's Sequence is an aesSequence128.
+func (p *Permutation) initKParallel(workers int, key [16]byte) {
+	if workers > len(p.k) {
+		workers = len(p.k)
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			src := newAESSequence(key)
+			for i := w; i < len(p.k); i += workers {
+				p.k[i] = p.computeK(uint64(i), src)
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+// computeK computes the key for round i, using src in place of p.src.
+func (p *Permutation) computeK(i uint64, src Sequence) uint64 {
 	// Naive modulo arithmetic gives a slight bias towards the low
 	// end of the range. Let's avoid that.
 	maxMultiple := (^uint64(0) / uint64(p.max)) * uint64(p.max)
-	for i := uint64(0); i < uint64(p.rounds); i++ {
-		offset := OffsetFor(SequencePermutationK, p.permSeed, 0, i)
-		bits := p.src.BitsAt(offset)
-		// Skip things outside this range, so the range of
-		// accepted values is an even multiple of p.max, so
-		// all values in the range are equally likely.
-		for bits.Lo >= maxMultiple {
-			offset.Hi++
-			bits = p.src.BitsAt(offset)
+	bitsAt := func(offset Uint128) Uint128 {
+		if p.round != nil {
+			return p.round(offset, src)
 		}
-		p.k[i] = p.src.BitsAt(offset).Lo % uint64(p.max)
+		return src.BitsAt(offset)
 	}
-	return &p, nil
+	offset := OffsetFor(SequencePermutationK, p.permSeed, 0, i)
+	bits := bitsAt(offset)
+	// Skip things outside this range, so the range of
+	// accepted values is an even multiple of p.max, so
+	// all values in the range are equally likely.
+	for bits.Lo >= maxMultiple {
+		offset.Hi++
+		bits = bitsAt(offset)
+	}
+	return bits.Lo % uint64(p.max)
+}
+
+// bitsAt yields the bits for the given offset, from the RoundFunc if
+// there is one, or from the underlying Sequence otherwise.
+func (p *Permutation) bitsAt(offset Uint128) Uint128 {
+	if p.round != nil {
+		return p.round(offset, p.src)
+	}
+	return p.src.BitsAt(offset)
 }
 
 // Next generates the next value from the permutation.
@@ -126,10 +289,78 @@ func (p *Permutation) Nth(n int64) (ret int64) {
 	return ret
 }
 
+// NextFloat64 returns the next value from the permutation, divided by
+// max, giving a value in [0,1). Over a full cycle of the permutation,
+// it produces each multiple of 1/max exactly once.
+func (p *Permutation) NextFloat64() float64 {
+	return p.toFloat64(p.Next())
+}
+
+// NthFloat64 returns Nth(n) divided by max, giving a value in [0,1). Like
+// Nth, it changes the offset Next counts from.
+func (p *Permutation) NthFloat64(n int64) float64 {
+	return p.toFloat64(p.Nth(n))
+}
+
+// toFloat64 divides v by max. For max over 2^53, values near max can
+// round to 1, so those become the largest float64 below 1.
+func (p *Permutation) toFloat64(v int64) float64 {
+	f := float64(v-p.lo) / float64(p.max)
+	if f >= 1 {
+		f = math.Nextafter(1, 0)
+	}
+	return f
+}
+
+// NthStateless returns the same value Nth(n) would, but without changing
+// the offset Next counts from. The Permutation itself isn't modified, so
+// NthStateless can be called concurrently if the underlying Sequence's
+// BitsAt (or the RoundFunc) is safe for concurrent use, as it is for the
+// Sequence from NewSequence.
+func (p *Permutation) NthStateless(n int64) int64 {
+	if n < 0 {
+		n = p.max + (n % p.max)
+	}
+	return p.lo + p.permute(int64(uint64(n)%uint64(p.max)))
+}
+
+// All returns every value of the permutation, in order; All()[n] is the
+// same value Nth(n) would return. It does not change the offset Next
+// counts from. Since it allocates a slice of max values, it's only
+// sensible for permutations small enough to hold in memory, at which
+// point you might prefer rand.Perm.
+func (p *Permutation) All() []int64 {
+	out := make([]int64, p.max)
+	for i := range out {
+		out[i] = p.lo + p.permute(int64(i))
+	}
+	return out
+}
+
 func (p *Permutation) nextValue() int64 {
 	p.counter = int64(uint64(p.counter) % uint64(p.max))
-	x := uint64(p.counter)
+	x := p.counter
 	p.counter++
+	return p.lo + p.permute(x)
+}
+
+// String describes p's parameters and position, for debugging, in the
+// form "Permutation(max=1000, seed=7, rounds=60, counter=42)". For a
+// Permutation from NewPermutationRange, max is replaced by the range, as
+// in "range=[10,1010)".
+func (p *Permutation) String() string {
+	size := fmt.Sprintf("max=%d", p.max)
+	if p.lo != 0 {
+		size = fmt.Sprintf("range=[%d,%d)", p.lo, p.lo+p.max)
+	}
+	return fmt.Sprintf("Permutation(%s, seed=%d, rounds=%d, counter=%d)", size, p.permSeed, p.rounds, p.counter)
+}
+
+// permute computes the value at position pos, which must be in [0,max),
+// without modifying p.
+func (p *Permutation) permute(pos int64) int64 {
+	x := uint64(pos)
+	var bits Uint128
 	// a value which can't possibly be the next value we need, so we
 	// always hash on the first pass.
 	prev := uint64(p.max) + 1
@@ -147,12 +378,76 @@ func (p *Permutation) nextValue() int64 {
 		}
 		if xCaret != prev {
 			offset.Lo = xCaret
-			p.bits = p.src.BitsAt(offset)
+			bits = p.bitsAt(offset)
 			prev = xCaret
 		}
-		if p.bits.Bit(i) != 0 {
+		if bits.Bit(i) != 0 {
 			x = xPrime
 		}
 	}
 	return int64(x)
 }
+
+// unpermute computes the position which yields value, which must be in
+// [0,max), without modifying p. Each round either leaves x alone or
+// replaces it with k-x, and the choice depends only on the pair {x, k-x},
+// so each round is its own inverse, and running the rounds backwards
+// inverts the permutation.
+func (p *Permutation) unpermute(value int64) int64 {
+	x := uint64(value)
+	for i := p.rounds - 1; i >= 0; i-- {
+		xPrime := (p.k[i] + uint64(p.max) - x) % uint64(p.max)
+		xCaret := x
+		if xPrime > xCaret {
+			xCaret = xPrime
+		}
+		offset := OffsetFor(SequencePermutationF, p.permSeed, uint32(i>>7), xCaret)
+		if bits := p.bitsAt(offset); bits.Bit(uint64(i)) != 0 {
+			x = xPrime
+		}
+	}
+	return int64(x)
+}
+
+// Cycle returns the cycle of the permutation containing start: start,
+// then the value at position start, then the value at that position, and
+// so on, until the next value would be start again. A fixed point yields
+// a cycle of length one. Cycle panics if start is out of range, or if
+// the cycle doesn't close within max steps, which would mean the
+// Permutation isn't actually a permutation. For a Permutation from
+// NewPermutationRange, values and positions are both in [lo,hi): the
+// value at position x is Nth(x-lo), and start must be in [lo,hi).
+func (p *Permutation) Cycle(start int64) []int64 {
+	if start < p.lo || start-p.lo >= p.max {
+		panic(fmt.Sprintf("cycle start %d out of range [%d,%d)", start, p.lo, p.lo+p.max))
+	}
+	cycle := []int64{start}
+	first := start - p.lo
+	for x := p.permute(first); x != first; x = p.permute(x) {
+		if int64(len(cycle)) >= p.max {
+			panic(fmt.Sprintf("cycle starting at %d did not close within %d steps", start, p.max))
+		}
+		cycle = append(cycle, p.lo+x)
+	}
+	return cycle
+}
+
+// ValidateBijection checks that p actually produces each value in
+// [0,max) exactly once, returning an error describing the first
+// out-of-range or repeated value found. It computes every value, taking
+// time and space proportional to max, so it's for tests and benchmarks,
+// such as checking a RoundFunc, rather than for production use.
+func ValidateBijection(p *Permutation) error {
+	seen := make([]bool, p.max)
+	for pos, v := range p.All() {
+		v -= p.lo
+		if v < 0 || v >= p.max {
+			return fmt.Errorf("position %d yields %d, out of range [0,%d)", pos, v, p.max)
+		}
+		if seen[v] {
+			return fmt.Errorf("position %d yields %d, which an earlier position also yielded", pos, v)
+		}
+		seen[v] = true
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build apophenia_debug
+
+package apophenia
+
+// PermutationStep records one round of a Permutation's computation of a
+// value, as reported by DebugTrace.
+type PermutationStep struct {
+	Round  int    // round index
+	Before int64  // x before the round, plus the Permutation's lo
+	After  int64  // x after the round, plus the Permutation's lo
+	K      uint64 // k[Round], the round's key
+	Swap   bool   // the swap bit: whether x was replaced by k[Round]-x
+}
+
+// DebugTrace returns the state of each round of the computation of
+// NthStateless(n), for figuring out why a permutation did something
+// unexpected. The After of the last step is the value NthStateless(n)
+// returns. For a Permutation from NewPermutationRange, the rounds work
+// on values in [0,hi-lo), which Before and After report shifted by lo,
+// so they're in [lo,hi); K is unshifted. Like NthStateless, it doesn't
+// change the offset Next counts from. It only exists in builds with the
+// apophenia_debug tag.
+func (p *Permutation) DebugTrace(n int64) []PermutationStep {
+	if n < 0 {
+		n = p.max + (n % p.max)
+	}
+	// this follows permute exactly, recording as it goes.
+	x := uint64(n) % uint64(p.max)
+	steps := make([]PermutationStep, p.rounds)
+	var bits Uint128
+	prev := uint64(p.max) + 1
+	offset := OffsetFor(SequencePermutationF, p.permSeed, 0, 0)
+	for i := uint64(0); i < uint64(p.rounds); i++ {
+		if i > 0 && i&127 == 0 {
+			offset.Hi++
+			prev = uint64(p.max) + 1
+		}
+		xPrime := (p.k[i] + uint64(p.max) - x) % uint64(p.max)
+		xCaret := x
+		if xPrime > xCaret {
+			xCaret = xPrime
+		}
+		if xCaret != prev {
+			offset.Lo = xCaret
+			bits = p.bitsAt(offset)
+			prev = xCaret
+		}
+		step := PermutationStep{Round: int(i), Before: p.lo + int64(x), K: p.k[i], Swap: bits.Bit(i) != 0}
+		if step.Swap {
+			x = xPrime
+		}
+		step.After = p.lo + int64(x)
+		steps[i] = step
+	}
+	return steps
+}
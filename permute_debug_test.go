@@ -0,0 +1,55 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build apophenia_debug
+
+package apophenia
+
+import "testing"
+
+func Test_PermutationDebugTrace(t *testing.T) {
+	src := NewSequence(0)
+	for _, c := range []struct{ lo, max int64 }{
+		{0, 1}, {0, 2}, {0, 17}, {0, 1000}, {0, 1 << 40}, {-50, 17}, {1 << 50, 1000},
+	} {
+		lo, max := c.lo, c.max
+		p, err := NewPermutationRange(lo, lo+max, 3, src)
+		if err != nil {
+			t.Fatalf("creating permutation: %v", err)
+		}
+		for _, n := range []int64{0, 1, max / 2, max - 1, -1} {
+			trace := p.DebugTrace(n)
+			if len(trace) != p.rounds {
+				t.Fatalf("lo %d, max %d, n %d: expected %d steps, got %d", lo, max, n, p.rounds, len(trace))
+			}
+			for i, step := range trace {
+				if i > 0 && step.Before != trace[i-1].After {
+					t.Fatalf("lo %d, max %d, n %d: round %d starts at %d, previous ended at %d",
+						lo, max, n, i, step.Before, trace[i-1].After)
+				}
+				exp := step.Before
+				if step.Swap {
+					exp = lo + int64((step.K+uint64(max)-uint64(step.Before-lo))%uint64(max))
+				}
+				if step.After != exp {
+					t.Fatalf("lo %d, max %d, n %d: round %d (swap %t) went from %d to %d, expected %d",
+						lo, max, n, i, step.Swap, step.Before, step.After, exp)
+				}
+			}
+			if got, exp := trace[len(trace)-1].After, p.NthStateless(n); got != exp {
+				t.Errorf("lo %d, max %d, n %d: trace ends at %d, NthStateless gives %d", lo, max, n, got, exp)
+			}
+		}
+	}
+}
@@ -16,6 +16,8 @@ package apophenia
 
 import (
 	"fmt"
+	"math/rand"
+	"strings"
 	"testing"
 )
 
@@ -55,6 +57,19 @@ func Test_PermuteCycle(t *testing.T) {
 	}
 }
 
+func Test_PermuteInvalidMax(t *testing.T) {
+	for _, max := range []int64{-1, 0, -1 << 63} {
+		expected := fmt.Sprintf("Permutation max must be at least 1, got %d", max)
+		if p := PermutationOrBust(max, 0, expected, t); p != nil {
+			t.Fatalf("max %d: expected error %q, got permutation", max, expected)
+		}
+		_, err := NewPermutationWithRoundFunc(max, 0, 0, NewSequence(0), nil)
+		if err == nil || err.Error() != expected {
+			t.Fatalf("max %d with round func: expected error %q, got %v", max, expected, err)
+		}
+	}
+}
+
 func TestPermuteSeed(t *testing.T) {
 	size := int64(129)
 	seeds := int64(8)
@@ -129,6 +144,98 @@ func Test_PermuteNth(t *testing.T) {
 	}
 }
 
+func Test_PermuteAll(t *testing.T) {
+	size := int64(129)
+	p := PermutationOrBust(size, 0, "", t)
+	first := p.Next()
+	all := p.All()
+	if int64(len(all)) != size {
+		t.Fatalf("expected %d values, got %d", size, len(all))
+	}
+	if all[0] != first {
+		t.Fatalf("All()[0] is %d, first Next() was %d", all[0], first)
+	}
+	// All shouldn't have disturbed Next.
+	for i := int64(1); i < size; i++ {
+		if n := p.Next(); n != all[i] {
+			t.Fatalf("Next() call %d gave %d, All()[%d] is %d", i, n, i, all[i])
+		}
+	}
+}
+
+func Test_PermuteNthStateless(t *testing.T) {
+	size := int64(1000)
+	p := PermutationOrBust(size, 3, "", t)
+	q := PermutationOrBust(size, 3, "", t)
+	for i := int64(0); i < size; i++ {
+		// interleave NthStateless of other positions between Nth
+		// and Next calls; they shouldn't disturb the counter.
+		stateless := p.NthStateless(size - 1 - i)
+		if i%2 == 0 {
+			if got, exp := p.Nth(i), q.Nth(i); got != exp {
+				t.Fatalf("Nth(%d): expected %d, got %d", i, exp, got)
+			}
+		} else {
+			if got, exp := p.Next(), q.Next(); got != exp {
+				t.Fatalf("Next() at %d: expected %d, got %d", i, exp, got)
+			}
+		}
+		if exp := q.NthStateless(size - 1 - i); stateless != exp {
+			t.Fatalf("NthStateless(%d): expected %d, got %d", size-1-i, exp, stateless)
+		}
+	}
+	if got, exp := p.NthStateless(-1), p.NthStateless(size-1); got != exp {
+		t.Fatalf("NthStateless(-1): expected %d, got %d", exp, got)
+	}
+	if got, exp := p.NthStateless(17), p.Nth(17); got != exp {
+		t.Fatalf("NthStateless(17): expected %d, Nth gave %d", got, exp)
+	}
+}
+
+func Test_PermuteU128(t *testing.T) {
+	size := int64(1000)
+	src := NewSequence(0)
+	seeds := []Uint128{{}, {Lo: 1}, {Hi: 1}, {Hi: 2}, {Lo: 1, Hi: 1}}
+	outputs := make([][]int64, len(seeds))
+	for i, seed := range seeds {
+		p, err := NewPermutationU128(size, seed, src)
+		if err != nil {
+			t.Fatalf("creating permutation with seed %s: %v", seed, err)
+		}
+		outputs[i] = p.All()
+		seen := make(map[int64]struct{}, size)
+		for _, v := range outputs[i] {
+			seen[v] = struct{}{}
+		}
+		if int64(len(seen)) != size {
+			t.Fatalf("seed %s: expected %d distinct values, got %d", seed, size, len(seen))
+		}
+		again, _ := NewPermutationU128(size, seed, NewSequence(0))
+		for j, v := range again.All() {
+			if v != outputs[i][j] {
+				t.Fatalf("seed %s: position %d not repeatable: %d vs %d", seed, j, outputs[i][j], v)
+			}
+		}
+	}
+	for i := range seeds {
+		for j := i + 1; j < len(seeds); j++ {
+			same := 0
+			for k := range outputs[i] {
+				if outputs[i][k] == outputs[j][k] {
+					same++
+				}
+			}
+			// two random permutations agree in about one place.
+			if same > 10 {
+				t.Errorf("seeds %s and %s agree in %d of %d positions", seeds[i], seeds[j], same, size)
+			}
+		}
+	}
+	if _, err := NewPermutationU128(size, Uint128{}, nil); err == nil {
+		t.Fatalf("expected error with nil Sequence")
+	}
+}
+
 func Benchmark_PermuteCycle(b *testing.B) {
 	sizes := []int64{5, 63, 1000000, (1 << 19)}
 	for _, size := range sizes {
@@ -140,3 +247,322 @@ func Benchmark_PermuteCycle(b *testing.B) {
 		})
 	}
 }
+
+func Test_PermuteRoundFunc(t *testing.T) {
+	identity := func(offset Uint128, src Sequence) Uint128 {
+		return offset
+	}
+	sizes := []int64{1, 8, 23, 64, 1000}
+	for _, size := range sizes {
+		p, err := NewPermutationWithRoundFunc(size, 0, 0, nil, identity)
+		if err != nil {
+			t.Fatalf("size %d: unexpected error: %v", size, err)
+		}
+		seen := make(map[int64]struct{}, size)
+		for i := int64(0); i < size; i++ {
+			n := p.Next()
+			if n < 0 || n >= size {
+				t.Fatalf("size %d: out-of-range value %d", size, n)
+			}
+			if _, ok := seen[n]; ok {
+				t.Fatalf("size %d: got duplicate entry %d", size, n)
+			}
+			seen[n] = struct{}{}
+		}
+	}
+	// the default round func should reproduce NewPermutation exactly.
+	size := int64(129)
+	p1 := PermutationOrBust(size, 0, "", t)
+	p2, err := NewPermutationWithRoundFunc(size, 0, 0, NewSequence(0), SequenceRoundFunc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p3, err := NewPermutationWithRoundFunc(size, 0, 0, NewSequence(0), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := int64(0); i < size; i++ {
+		v1, v2, v3 := p1.Next(), p2.Next(), p3.Next()
+		if v1 != v2 || v1 != v3 {
+			t.Fatalf("index %d: NewPermutation gave %d, SequenceRoundFunc gave %d, nil RoundFunc gave %d",
+				i, v1, v2, v3)
+		}
+	}
+	if _, err := NewPermutationWithRoundFunc(size, 0, 0, nil, nil); err == nil {
+		t.Fatalf("expected error with neither Sequence nor RoundFunc")
+	}
+	if _, err := NewPermutationWithRoundFunc(size, 0, -1, NewSequence(0), nil); err == nil {
+		t.Fatalf("expected error with negative rounds")
+	}
+}
+
+func Test_PermuteCycles(t *testing.T) {
+	sizes := []int64{1, 2, 8, 23, 64, 1000}
+	for _, size := range sizes {
+		for seed := int64(0); seed < 4; seed++ {
+			p := PermutationOrBust(size, seed, "", t)
+			values := make([]int64, size)
+			for i := range values {
+				values[i] = p.Nth(int64(i))
+			}
+			seen := make(map[int64]int64, size)
+			for start := int64(0); start < size; start++ {
+				if _, ok := seen[start]; ok {
+					continue
+				}
+				cycle := p.Cycle(start)
+				for i, x := range cycle {
+					if prev, ok := seen[x]; ok {
+						t.Fatalf("size %d, seed %d: %d in cycles starting at %d and %d",
+							size, seed, x, prev, start)
+					}
+					seen[x] = start
+					// each value should be the permutation's value at
+					// the previous position.
+					next := cycle[(i+1)%len(cycle)]
+					if values[x] != next {
+						t.Fatalf("size %d, seed %d: cycle from %d has %d after %d, but value at %d is %d",
+							size, seed, start, next, x, x, values[x])
+					}
+				}
+				if values[start] == start && len(cycle) != 1 {
+					t.Fatalf("size %d, seed %d: fixed point %d has cycle %v", size, seed, start, cycle)
+				}
+			}
+			if int64(len(seen)) != size {
+				t.Fatalf("size %d, seed %d: cycles covered %d values", size, seed, len(seen))
+			}
+		}
+	}
+}
+
+func Test_PermuteRangeCycles(t *testing.T) {
+	src := NewSequence(0)
+	for _, lo := range []int64{-40, 7, 1 << 50} {
+		p, err := NewPermutationRange(lo, lo+100, 1, src)
+		if err != nil {
+			t.Fatalf("creating permutation: %v", err)
+		}
+		seen := make(map[int64]bool, 100)
+		for start := lo; start < lo+100; start++ {
+			if seen[start] {
+				continue
+			}
+			cycle := p.Cycle(start)
+			for i, x := range cycle {
+				if x < lo || x >= lo+100 || seen[x] {
+					t.Fatalf("lo %d: cycle from %d has bad or repeated value %d", lo, start, x)
+				}
+				seen[x] = true
+				if next := cycle[(i+1)%len(cycle)]; p.NthStateless(x-lo) != next {
+					t.Fatalf("lo %d: cycle from %d has %d after %d, but value at %d is %d",
+						lo, start, next, x, x, p.NthStateless(x-lo))
+				}
+			}
+		}
+		if len(seen) != 100 {
+			t.Fatalf("lo %d: cycles covered %d values", lo, len(seen))
+		}
+		for _, bad := range []int64{lo - 1, lo + 100} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("lo %d: Cycle(%d) didn't panic", lo, bad)
+					}
+				}()
+				p.Cycle(bad)
+			}()
+		}
+	}
+}
+
+// BenchmarkPermutationVsRandPerm compares generating an entire shuffle
+// with a Permutation, one value at a time or all at once, against
+// rand.Perm, which is much faster but not seekable.
+func BenchmarkPermutationVsRandPerm(b *testing.B) {
+	sizes := []int{8, 64, 1024, 65536}
+	perElement := func(b *testing.B, size int) {
+		b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*size), "ns/element")
+	}
+	for _, size := range sizes {
+		b.Run(fmt.Sprintf("Size%d/Next", size), func(b *testing.B) {
+			p := PermutationOrBust(int64(size), 0, "", b)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for j := 0; j < size; j++ {
+					_ = p.Next()
+				}
+			}
+			perElement(b, size)
+		})
+		b.Run(fmt.Sprintf("Size%d/All", size), func(b *testing.B) {
+			p := PermutationOrBust(int64(size), 0, "", b)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = p.All()
+			}
+			perElement(b, size)
+		})
+		b.Run(fmt.Sprintf("Size%d/RandPerm", size), func(b *testing.B) {
+			r := rand.New(rand.NewSource(0))
+			for i := 0; i < b.N; i++ {
+				_ = r.Perm(size)
+			}
+			perElement(b, size)
+		})
+	}
+}
+
+func Test_PermutationParallelInit(t *testing.T) {
+	src := NewSequence(0)
+	for _, max := range []int64{1, 10, 1 << 40, 1<<63 - 1} {
+		for _, workers := range []int{0, 1, 2, 7, 1000} {
+			seq, err := NewPermutation(max, 9, src)
+			if err != nil {
+				t.Fatalf("creating permutation: %v", err)
+			}
+			par, err := NewPermutation(max, 9, src, WithParallelInit(workers))
+			if err != nil {
+				t.Fatalf("creating permutation with %d workers: %v", workers, err)
+			}
+			if len(seq.k) != len(par.k) {
+				t.Fatalf("max %d, %d workers: %d keys, expected %d", max, workers, len(par.k), len(seq.k))
+			}
+			for i := range seq.k {
+				if seq.k[i] != par.k[i] {
+					t.Fatalf("max %d, %d workers: key %d is %d, expected %d", max, workers, i, par.k[i], seq.k[i])
+				}
+			}
+		}
+	}
+	// other Sequences fall back to sequential initialization.
+	xor := NewXORSequence(NewSequence(1), NewSequence(2))
+	seq, _ := NewPermutation(1<<40, 0, xor)
+	par, _ := NewPermutation(1<<40, 0, xor, WithParallelInit(4))
+	for i := int64(0); i < 100; i++ {
+		if seq.Nth(i) != par.Nth(i) {
+			t.Fatalf("XOR sequence: value %d differs with parallel init", i)
+		}
+	}
+}
+
+func Test_ValidateBijection(t *testing.T) {
+	for _, max := range []int64{1, 2, 100, 4096} {
+		p, err := NewPermutation(max, 1, NewSequence(0))
+		if err != nil {
+			t.Fatalf("creating permutation: %v", err)
+		}
+		if err := ValidateBijection(p); err != nil {
+			t.Errorf("max %d: %v", max, err)
+		}
+	}
+	// A RoundFunc which isn't deterministic breaks the bijection.
+	var calls uint64
+	unstable := func(offset Uint128, src Sequence) Uint128 {
+		calls++
+		return Uint128{Lo: calls * 0x9e3779b97f4a7c15, Hi: calls * 0xbf58476d1ce4e5b9}
+	}
+	p, err := NewPermutationWithRoundFunc(1000, 0, 0, nil, unstable)
+	if err != nil {
+		t.Fatalf("creating permutation: %v", err)
+	}
+	err = ValidateBijection(p)
+	if err == nil {
+		t.Fatalf("expected error for unstable round function")
+	}
+	if !strings.Contains(err.Error(), "also yielded") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func Test_PermutationFloat64(t *testing.T) {
+	const max = 1000
+	p, err := NewPermutation(max, 4, NewSequence(0))
+	if err != nil {
+		t.Fatalf("creating permutation: %v", err)
+	}
+	// a full cycle hits each [i/max, (i+1)/max) exactly once.
+	seen := make([]bool, max)
+	for i := 0; i < max; i++ {
+		f := p.NextFloat64()
+		if f < 0 || f >= 1 {
+			t.Fatalf("value %d: %g out of range", i, f)
+		}
+		cell := int(f * max)
+		if seen[cell] {
+			t.Fatalf("value %d: cell %d already seen", i, cell)
+		}
+		seen[cell] = true
+	}
+	if got, exp := p.NthFloat64(17), float64(p.Nth(17))/max; got != exp {
+		t.Errorf("NthFloat64(17): expected %g, got %g", exp, got)
+	}
+	if got, exp := p.NextFloat64(), float64(p.Nth(18))/max; got != exp {
+		t.Errorf("NextFloat64 after NthFloat64(17): expected %g, got %g", exp, got)
+	}
+	// even with a huge max, values near max stay below 1.
+	big, _ := NewPermutation(1<<63-1, 0, NewSequence(0))
+	if f := big.toFloat64(1<<63 - 2); f >= 1 {
+		t.Errorf("max 2^63-1: largest value gave %g", f)
+	}
+}
+
+func Test_PermutationRange(t *testing.T) {
+	for _, r := range []struct{ lo, hi int64 }{{-50, 150}, {1000, 1001}, {-30, -3}, {1 << 40, 1<<40 + 500}} {
+		p, err := NewPermutationRange(r.lo, r.hi, 2, NewSequence(0))
+		if err != nil {
+			t.Fatalf("creating permutation [%d,%d): %v", r.lo, r.hi, err)
+		}
+		plain, _ := NewPermutation(r.hi-r.lo, 2, NewSequence(0))
+		seen := make(map[int64]bool)
+		for i := int64(0); i < r.hi-r.lo; i++ {
+			v := p.Next()
+			if v < r.lo || v >= r.hi {
+				t.Fatalf("[%d,%d): value %d out of range", r.lo, r.hi, v)
+			}
+			if seen[v] {
+				t.Fatalf("[%d,%d): value %d repeated", r.lo, r.hi, v)
+			}
+			seen[v] = true
+			if exp := plain.Next() + r.lo; v != exp {
+				t.Fatalf("[%d,%d): value %d: expected %d, got %d", r.lo, r.hi, i, exp, v)
+			}
+			if got := p.NthStateless(i); got != v {
+				t.Fatalf("[%d,%d): NthStateless(%d): expected %d, got %d", r.lo, r.hi, i, v, got)
+			}
+		}
+		if int64(len(seen)) != r.hi-r.lo {
+			t.Fatalf("[%d,%d): expected %d distinct values, got %d", r.lo, r.hi, r.hi-r.lo, len(seen))
+		}
+		if got, exp := p.Nth(-1), plain.Nth(-1)+r.lo; got != exp {
+			t.Errorf("[%d,%d): Nth(-1): expected %d, got %d", r.lo, r.hi, exp, got)
+		}
+		if f := p.NthFloat64(0); f < 0 || f >= 1 {
+			t.Errorf("[%d,%d): NthFloat64(0) = %g, out of [0,1)", r.lo, r.hi, f)
+		}
+		if err := ValidateBijection(p); err != nil {
+			t.Errorf("[%d,%d): %v", r.lo, r.hi, err)
+		}
+	}
+	for _, r := range []struct{ lo, hi int64 }{{5, 3}, {4, 4}, {-1 << 63, 1<<63 - 1}} {
+		if _, err := NewPermutationRange(r.lo, r.hi, 0, NewSequence(0)); err == nil {
+			t.Errorf("[%d,%d): expected error", r.lo, r.hi)
+		}
+	}
+	p, _ := NewPermutationRange(1, 10, 0, NewSequence(0))
+	if _, err := p.GobEncode(); err == nil {
+		t.Errorf("expected error encoding a range Permutation")
+	}
+}
+
+func Test_PermutationString(t *testing.T) {
+	p, _ := NewPermutation(1000, 7, NewSequence(0))
+	p.Nth(41)
+	if got, exp := p.String(), "Permutation(max=1000, seed=7, rounds=60, counter=42)"; got != exp {
+		t.Errorf("expected %q, got %q", exp, got)
+	}
+	r, _ := NewPermutationRange(10, 1010, 0, NewSequence(0))
+	if got, exp := fmt.Sprint(r), "Permutation(range=[10,1010), seed=0, rounds=60, counter=0)"; got != exp {
+		t.Errorf("expected %q, got %q", exp, got)
+	}
+}
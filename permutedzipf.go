@@ -0,0 +1,61 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"fmt"
+	"math"
+)
+
+// PermutedZipf produces Zipf-distributed values whose frequency doesn't
+// follow their numeric order: it picks a rank using a Zipf, then maps
+// the rank to a value using a Permutation of [0,max]. The most common
+// value is therefore some arbitrary value, not 0, and the hot values are
+// scattered across the range rather than contiguous, which is closer to
+// real access patterns. Each rank always maps to the same value.
+type PermutedZipf struct {
+	zipf *Zipf
+	perm *Permutation
+}
+
+// NewPermutedZipf creates a PermutedZipf with the given q, v, and max, as
+// for NewZipf, producing values in [0,max]. The seed selects both the
+// Zipf sequence and the Permutation.
+func NewPermutedZipf(q, v float64, max uint64, seed uint32, src Sequence) (*PermutedZipf, error) {
+	if max >= math.MaxInt64 {
+		return nil, fmt.Errorf("max %d too large for a permuted Zipf distribution", max)
+	}
+	z, err := NewZipf(q, v, max, seed, src)
+	if err != nil {
+		return nil, err
+	}
+	p, err := NewPermutation(int64(max)+1, seed, src)
+	if err != nil {
+		return nil, err
+	}
+	return &PermutedZipf{zipf: z, perm: p}, nil
+}
+
+// Nth returns the value for the given index. As with Zipf, after calling
+// Nth(x), Next returns the same value as Nth(x+1).
+func (p *PermutedZipf) Nth(index uint64) uint64 {
+	return uint64(p.perm.NthStateless(int64(p.zipf.Nth(index))))
+}
+
+// Next returns the value after the last one requested, or the value for
+// index 0 if none have been requested.
+func (p *PermutedZipf) Next() uint64 {
+	return uint64(p.perm.NthStateless(int64(p.zipf.Next())))
+}
@@ -0,0 +1,67 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import "testing"
+
+func Test_PermutedZipf(t *testing.T) {
+	const max, n = 19, 100000
+	p, err := NewPermutedZipf(1.5, 2, max, 4, NewSequence(0))
+	if err != nil {
+		t.Fatalf("creating PermutedZipf: %v", err)
+	}
+	// map values back to ranks.
+	ranks := make(map[uint64]uint64)
+	for rank := int64(0); rank <= max; rank++ {
+		ranks[uint64(p.perm.NthStateless(rank))] = uint64(rank)
+	}
+	counts := make([]int, max+1)
+	scattered := false
+	for i := uint64(0); i < n; i++ {
+		v := p.Next()
+		rank, ok := ranks[v]
+		if !ok {
+			t.Fatalf("index %d: value %d out of range", i, v)
+		}
+		if rank != p.zipf.Nth(i) {
+			t.Fatalf("index %d: value %d has rank %d, Zipf gave rank %d", i, v, rank, p.zipf.Nth(i))
+		}
+		if v != rank {
+			scattered = true
+		}
+		counts[rank]++
+	}
+	if !scattered {
+		t.Errorf("every value equal to its rank; permutation isn't doing anything")
+	}
+	chi := 0.0
+	for rank, c := range counts {
+		exp := n * p.zipf.Probability(uint64(rank))
+		chi += (float64(c) - exp) * (float64(c) - exp) / exp
+	}
+	// 19 degrees of freedom; 50 is about p = 0.0001.
+	if chi > 50 {
+		t.Errorf("rank histogram chi-square %.1f too high: %v", chi, counts)
+	}
+	if got, exp := p.Nth(17), p.Nth(17); got != exp {
+		t.Errorf("Nth(17) gave %d, then %d", exp, got)
+	}
+	if got, exp := p.Next(), uint64(p.perm.NthStateless(int64(p.zipf.Nth(18)))); got != exp {
+		t.Errorf("Next after Nth(17): expected %d, got %d", exp, got)
+	}
+	if _, err := NewPermutedZipf(1, 2, max, 0, NewSequence(0)); err == nil {
+		t.Errorf("expected error for q of 1")
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"fmt"
+	"math"
+)
+
+// poissonInversionMax is the largest lambda for which we sample by
+// inversion. Inversion takes time proportional to lambda, so past this
+// we switch to transformed rejection.
+const poissonInversionMax = 10
+
+// Poisson produces values following the Poisson distribution with mean
+// lambda, the number of events in an interval when events occur
+// independently at a constant average rate.
+type Poisson struct {
+	src    Sequence
+	seed   uint32
+	lambda float64
+}
+
+// NewPoisson returns a Poisson with the given mean, which must be
+// non-negative and finite.
+func NewPoisson(lambda float64, seed uint32, src Sequence) (*Poisson, error) {
+	if math.IsNaN(lambda) || math.IsInf(lambda, 0) || lambda < 0 {
+		return nil, fmt.Errorf("need finite lambda >= 0 (got %g) for Poisson distribution", lambda)
+	}
+	if src == nil {
+		return nil, fmt.Errorf("need a usable PRNG apophenia.Sequence")
+	}
+	return &Poisson{src: src, seed: seed, lambda: lambda}, nil
+}
+
+// NewPoissonFromSeed is NewPoisson with seed.Uint32() as the seed.
+func NewPoissonFromSeed(lambda float64, seed Seed, src Sequence) (*Poisson, error) {
+	return NewPoisson(lambda, seed.Uint32(), src)
+}
+
+// Nth returns the value for the given index.
+func (p *Poisson) Nth(index uint64) uint64 {
+	return poissonAt(p.lambda, OffsetFor(SequencePoisson, p.seed, 0, index), p.src)
+}
+
+// Probability returns the probability of the given value.
+func (p *Poisson) Probability(value uint64) float64 {
+	return poissonProbability(p.lambda, value)
+}
+
+// poissonProbability returns the probability of k events for a Poisson
+// distribution with mean lambda.
+func poissonProbability(lambda float64, k uint64) float64 {
+	if lambda == 0 {
+		if k == 0 {
+			return 1
+		}
+		return 0
+	}
+	lg, _ := math.Lgamma(float64(k) + 1)
+	return math.Exp(float64(k)*math.Log(lambda) - lambda - lg)
+}
+
+// poissonAt samples a Poisson value with mean lambda using the bits at
+// offset. If more bits are needed, it increments offset.Hi, which is to
+// say the iteration part of the offset, as Zipf does.
+func poissonAt(lambda float64, offset Uint128, src Sequence) uint64 {
+	if lambda == 0 {
+		return 0
+	}
+	if lambda < poissonInversionMax {
+		// Walk up the CDF until it passes u.
+		u := unitFloat64(src.BitsAt(offset).Lo)
+		p := math.Exp(-lambda)
+		cdf := p
+		k := uint64(0)
+		// The cap only matters if rounding leaves cdf short of u.
+		for u >= cdf && k < 1000 {
+			k++
+			p *= lambda / float64(k)
+			cdf += p
+		}
+		return k
+	}
+	// PTRS, the transformed rejection with squeeze method of:
+	//
+	// "The transformed rejection method for generating Poisson random
+	// variables"
+	// W. Hormann [1993]
+	slam := math.Sqrt(lambda)
+	logLambda := math.Log(lambda)
+	b := 0.931 + 2.53*slam
+	a := -0.059 + 0.02483*b
+	invAlpha := 1.1239 + 1.1328/(b-3.4)
+	vr := 0.9277 - 3.6224/(b-2)
+	for {
+		bits := src.BitsAt(offset)
+		offset.Hi++
+		u := unitFloat64(bits.Lo) - 0.5
+		v := unitFloat64(bits.Hi)
+		us := 0.5 - math.Abs(u)
+		k := math.Floor((2*a/us+b)*u + lambda + 0.43)
+		if us >= 0.07 && v <= vr {
+			return uint64(k)
+		}
+		if k < 0 || (us < 0.013 && v > us) {
+			continue
+		}
+		lg, _ := math.Lgamma(k + 1)
+		if math.Log(v)+math.Log(invAlpha)-math.Log(a/(us*us)+b) <= -lambda+k*logLambda-lg {
+			return uint64(k)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_Poisson(t *testing.T) {
+	const samples = 100000
+	for _, lambda := range []float64{0, 0.5, 3, 9.9, 10, 25, 1000} {
+		p, err := NewPoisson(lambda, 0, NewSequence(0))
+		if err != nil {
+			t.Fatalf("creating poisson: %v", err)
+		}
+		counts := make(map[uint64]int)
+		sum, sumSq := 0.0, 0.0
+		for i := uint64(0); i < samples; i++ {
+			k := p.Nth(i)
+			counts[k]++
+			sum += float64(k)
+			sumSq += float64(k) * float64(k)
+		}
+		mean := sum / samples
+		variance := sumSq/samples - mean*mean
+		// the mean and variance are both lambda.
+		if math.Abs(mean-lambda) > 5*math.Sqrt(lambda/samples) {
+			t.Errorf("lambda %g: mean %g", lambda, mean)
+		}
+		if math.Abs(variance-lambda) > 0.02*lambda {
+			t.Errorf("lambda %g: variance %g", lambda, variance)
+		}
+		// check the frequencies of values near the mean.
+		for k := uint64(lambda); k < uint64(lambda)+3; k++ {
+			exp := p.Probability(k) * samples
+			if math.Abs(float64(counts[k])-exp) > 5*math.Sqrt(exp)+1 {
+				t.Errorf("lambda %g: value %d: expected about %.0f, got %d", lambda, k, exp, counts[k])
+			}
+		}
+	}
+}
+
+func Test_PoissonInvalid(t *testing.T) {
+	for _, lambda := range []float64{-1, math.NaN(), math.Inf(1)} {
+		if _, err := NewPoisson(lambda, 0, NewSequence(0)); err == nil {
+			t.Errorf("lambda %g: expected error", lambda)
+		}
+	}
+	if _, err := NewPoisson(1, 0, nil); err == nil {
+		t.Errorf("expected error for nil Sequence")
+	}
+}
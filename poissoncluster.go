@@ -0,0 +1,102 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// poissonClusterIterCentroid is the iteration used for cluster centroids;
+// cluster sizes use iterations from 0, and may use more than one.
+const poissonClusterIterCentroid = 1 << 22
+
+// PoissonCluster lays out spatially clustered data: a number of clusters,
+// each with a Poisson-distributed number of members, whose IDs are
+// contiguous around a centroid in a domain of IDs. The domain is divided
+// into one equal region per cluster, and each cluster's centroid is
+// uniformly random within its region, so clusters never share members.
+// A cluster's size is capped at the width of its region.
+type PoissonCluster struct {
+	src         Sequence
+	seed        uint32
+	numClusters int64
+	rate        float64
+	width       uint64 // width of each cluster's region
+}
+
+// NewPoissonCluster creates a PoissonCluster with numClusters clusters,
+// each with a mean of clusterRate members, placed in the IDs
+// [0,domainSize). The domain must have at least one ID per cluster;
+// any IDs left over when it's divided evenly are never used.
+func NewPoissonCluster(numClusters int64, clusterRate float64, domainSize uint64, seed uint32, src Sequence) (*PoissonCluster, error) {
+	if numClusters < 1 {
+		return nil, fmt.Errorf("need at least one cluster, got %d", numClusters)
+	}
+	if math.IsNaN(clusterRate) || math.IsInf(clusterRate, 0) || clusterRate < 0 {
+		return nil, fmt.Errorf("need finite cluster rate >= 0 (got %g) for Poisson clusters", clusterRate)
+	}
+	if domainSize < uint64(numClusters) {
+		return nil, fmt.Errorf("domain of %d IDs too small for %d clusters", domainSize, numClusters)
+	}
+	if src == nil {
+		return nil, fmt.Errorf("need a usable PRNG apophenia.Sequence")
+	}
+	return &PoissonCluster{src: src, seed: seed, numClusters: numClusters, rate: clusterRate, width: domainSize / uint64(numClusters)}, nil
+}
+
+// Len returns the number of clusters.
+func (c *PoissonCluster) Len() int64 {
+	return c.numClusters
+}
+
+// Centroid returns the centroid of the given cluster. It panics if
+// clusterID is out of range.
+func (c *PoissonCluster) Centroid(clusterID int64) uint64 {
+	if clusterID < 0 || clusterID >= c.numClusters {
+		panic(fmt.Sprintf("cluster %d out of range [0,%d)", clusterID, c.numClusters))
+	}
+	offset := OffsetFor(SequencePoissonCluster, c.seed, poissonClusterIterCentroid, uint64(clusterID))
+	pos, _ := bits.Mul64(c.src.BitsAt(offset).Lo, c.width)
+	return uint64(clusterID)*c.width + pos
+}
+
+// Members returns the member IDs of the given cluster, in order: a
+// contiguous run of IDs including the centroid, and as nearly centered on
+// it as its region allows. It panics if clusterID is out of range.
+func (c *PoissonCluster) Members(clusterID int64) []uint64 {
+	centroid := c.Centroid(clusterID)
+	size := poissonAt(c.rate, OffsetFor(SequencePoissonCluster, c.seed, 0, uint64(clusterID)), c.src)
+	if size > c.width {
+		size = c.width
+	}
+	if size == 0 {
+		return nil
+	}
+	regionStart := uint64(clusterID) * c.width
+	start := regionStart
+	if centroid-regionStart > size/2 {
+		start = centroid - size/2
+	}
+	if start > regionStart+c.width-size {
+		start = regionStart + c.width - size
+	}
+	members := make([]uint64, size)
+	for i := range members {
+		members[i] = start + uint64(i)
+	}
+	return members
+}
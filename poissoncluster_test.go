@@ -0,0 +1,69 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_PoissonCluster(t *testing.T) {
+	const clusters, rate = 10000, 4.5
+	c, err := NewPoissonCluster(clusters, rate, 1000000, 2, NewSequence(0))
+	if err != nil {
+		t.Fatalf("creating clusters: %v", err)
+	}
+	owner := make(map[uint64]int64)
+	total := 0
+	for id := int64(0); id < clusters; id++ {
+		members := c.Members(id)
+		centroid := c.Centroid(id)
+		if len(members) > 0 && (centroid < members[0] || centroid > members[len(members)-1]) {
+			t.Fatalf("cluster %d: centroid %d outside members %d..%d",
+				id, centroid, members[0], members[len(members)-1])
+		}
+		for _, m := range members {
+			if prev, ok := owner[m]; ok {
+				t.Fatalf("member %d in clusters %d and %d", m, prev, id)
+			}
+			owner[m] = id
+		}
+		total += len(members)
+	}
+	// 5 sigma; the sum of Poisson values is Poisson.
+	if exp := clusters * rate; math.Abs(float64(total)-exp) > 5*math.Sqrt(exp) {
+		t.Errorf("expected about %.0f members, got %d", exp, total)
+	}
+	// tiny regions cap cluster sizes.
+	small, err := NewPoissonCluster(100, 50, 300, 0, NewSequence(0))
+	if err != nil {
+		t.Fatalf("creating small clusters: %v", err)
+	}
+	for id := int64(0); id < 100; id++ {
+		members := small.Members(id)
+		if len(members) != 3 || members[0] != uint64(id)*3 {
+			t.Fatalf("small cluster %d: expected members %d..%d, got %v", id, id*3, id*3+2, members)
+		}
+	}
+	for _, args := range []struct {
+		n      int64
+		rate   float64
+		domain uint64
+	}{{0, 1, 10}, {10, -1, 100}, {10, math.NaN(), 100}, {10, 1, 9}} {
+		if _, err := NewPoissonCluster(args.n, args.rate, args.domain, 0, NewSequence(0)); err == nil {
+			t.Errorf("%d clusters, rate %g, domain %d: expected error", args.n, args.rate, args.domain)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// Iteration bases for the independent variates used by PoissonProcess.
+// Each gamma variate uses a couple of iterations per attempt, so these
+// leave plenty of room.
+const (
+	poissonProcessIterX   = 1 << 22 // first gamma of a beta variate
+	poissonProcessIterY   = 2 << 22 // second gamma of a beta variate
+	poissonProcessIterTop = 3 << 22 // gaps between power-of-two arrivals
+)
+
+// PoissonProcess produces the arrival times of a Poisson process with a
+// given rate: events whose inter-arrival times are independent and
+// exponentially distributed with mean 1/rate. The time of the nth
+// arrival follows a Gamma(n, rate) distribution.
+//
+// Arrival times are seekable, and consistent with each other: NthArrival
+// is strictly increasing in n, and the times have exactly the joint
+// distribution of a Poisson process, no matter which arrivals are
+// requested or in what order. This works by bisection. The arrivals at
+// powers of two are separated by independent gamma-distributed gaps.
+// Given the times of arrivals a and b, the time of an arrival m between
+// them divides the interval according to a Beta(m-a, b-m) distribution.
+// So the nth arrival is found by computing a chain of O(log n) such
+// splits, each determined by the seed and the arrival being split at.
+type PoissonProcess struct {
+	src  Sequence
+	seed uint32
+	rate float64
+}
+
+// NewPoissonProcess returns a PoissonProcess with the given rate, which
+// must be positive and finite.
+func NewPoissonProcess(rate float64, seed uint32, src Sequence) (*PoissonProcess, error) {
+	if math.IsNaN(rate) || math.IsInf(rate, 0) || rate <= 0 {
+		return nil, fmt.Errorf("need finite rate > 0 (got %g) for Poisson process", rate)
+	}
+	if src == nil {
+		return nil, fmt.Errorf("need a usable PRNG apophenia.Sequence")
+	}
+	return &PoissonProcess{src: src, seed: seed, rate: rate}, nil
+}
+
+// gap returns the time between arrival 2^(i-1) and 2^i, or between the
+// start and the first arrival if i is 0, in units of 1/rate.
+func (p *PoissonProcess) gap(i int) float64 {
+	shape := 1.0
+	if i > 0 {
+		shape = math.Ldexp(1, i-1)
+	}
+	return gammaAt(shape, OffsetFor(SequencePoissonProcess, p.seed, poissonProcessIterTop, uint64(i)), p.src)
+}
+
+// split returns the fraction of the interval between arrivals m-half and
+// m+half at which arrival m happens: a Beta(half, half) variate.
+func (p *PoissonProcess) split(m uint64, half float64) float64 {
+	x := gammaAt(half, OffsetFor(SequencePoissonProcess, p.seed, poissonProcessIterX, m), p.src)
+	y := gammaAt(half, OffsetFor(SequencePoissonProcess, p.seed, poissonProcessIterY, m), p.src)
+	return x / (x + y)
+}
+
+// NthArrival returns the time of the nth arrival, counting from 1; the
+// "0th arrival" is at time 0. It takes time proportional to log n.
+func (p *PoissonProcess) NthArrival(n uint64) float64 {
+	if n == 0 {
+		return 0
+	}
+	// lo is the power of two at or below n, and span is the distance
+	// from lo to the next power of two.
+	j := 63 - bits.LeadingZeros64(n)
+	lo, span := uint64(1)<<uint(j), uint64(1)<<uint(j)
+	tLo := 0.0
+	for i := 0; i <= j; i++ {
+		tLo += p.gap(i)
+	}
+	tHi := tLo + p.gap(j+1)
+	for lo != n {
+		span /= 2
+		m := lo + span
+		tM := tLo + (tHi-tLo)*p.split(m, float64(span))
+		if n < m {
+			tHi = tM
+		} else {
+			lo, tLo = m, tM
+		}
+	}
+	return tLo / p.rate
+}
@@ -0,0 +1,79 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_PoissonProcessIncreasing(t *testing.T) {
+	p, err := NewPoissonProcess(2.5, 0, NewSequence(0))
+	if err != nil {
+		t.Fatalf("creating Poisson process: %v", err)
+	}
+	const n = 10000
+	prev := p.NthArrival(0)
+	gaps := 0.0
+	for i := uint64(1); i <= n; i++ {
+		next := p.NthArrival(i)
+		if next <= prev {
+			t.Fatalf("arrival %d at %g, not after arrival %d at %g", i, next, i-1, prev)
+		}
+		gaps += next - prev
+		prev = next
+	}
+	// the mean gap between arrivals is 1/rate.
+	if mean := gaps / n; math.Abs(mean-0.4) > 5*0.4/math.Sqrt(n) {
+		t.Errorf("mean inter-arrival time %g, expected about 0.4", mean)
+	}
+	// and the result doesn't depend on order of requests.
+	for _, i := range []uint64{5000, 17, 1 << 62, 3} {
+		a := p.NthArrival(i)
+		q, _ := NewPoissonProcess(2.5, 0, NewSequence(0))
+		if b := q.NthArrival(i); a != b {
+			t.Errorf("arrival %d: got %g, then %g", i, a, b)
+		}
+	}
+}
+
+func Test_PoissonProcessMean(t *testing.T) {
+	const rate, trials = 4.0, 2000
+	src := NewSequence(0)
+	for _, n := range []uint64{1, 2, 7, 100, 12345} {
+		sum := 0.0
+		for seed := uint32(0); seed < trials; seed++ {
+			p, _ := NewPoissonProcess(rate, seed, src)
+			sum += p.NthArrival(n)
+		}
+		// Gamma(n, rate) has mean n/rate and standard deviation
+		// sqrt(n)/rate.
+		exp, sd := float64(n)/rate, math.Sqrt(float64(n))/rate
+		if mean := sum / trials; math.Abs(mean-exp) > 5*sd/math.Sqrt(trials) {
+			t.Errorf("arrival %d: mean time %g, expected about %g", n, mean, exp)
+		}
+	}
+}
+
+func Test_PoissonProcessInvalid(t *testing.T) {
+	for _, rate := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		if _, err := NewPoissonProcess(rate, 0, NewSequence(0)); err == nil {
+			t.Errorf("rate %g: expected error", rate)
+		}
+	}
+	if _, err := NewPoissonProcess(1, 0, nil); err == nil {
+		t.Errorf("expected error for nil Sequence")
+	}
+}
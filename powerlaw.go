@@ -0,0 +1,59 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"fmt"
+	"math"
+)
+
+// PowerLaw produces continuous values in [xMin,∞) following a power law
+// (a Pareto type I distribution), with the probability density at x
+// proportional to x^-exponent. Where Zipf produces integer ranks, this
+// produces real-valued quantities, such as file sizes or request
+// latencies, with similarly heavy tails.
+//
+// Values are computed by inverting the cumulative distribution function,
+// as xMin * (1-u)^(-1/(exponent-1)), so each value uses exactly one value
+// from the underlying Sequence, and any value can be computed directly
+// from its index.
+type PowerLaw struct {
+	src      Sequence
+	seed     uint32
+	xMin     float64
+	negInvA1 float64 // -1/(exponent-1)
+}
+
+// NewPowerLaw returns a PowerLaw with the given exponent, which must be
+// greater than 1, and minimum value xMin, which must be positive.
+func NewPowerLaw(exponent, xMin float64, seed uint32, src Sequence) (*PowerLaw, error) {
+	if math.IsNaN(exponent) || math.IsNaN(xMin) || math.IsInf(exponent, 0) || math.IsInf(xMin, 0) {
+		return nil, fmt.Errorf("exponent (%g) and xMin (%g) must be finite for power law distribution", exponent, xMin)
+	}
+	if exponent <= 1 || xMin <= 0 {
+		return nil, fmt.Errorf("need exponent > 1 (got %g) and xMin > 0 (got %g) for power law distribution", exponent, xMin)
+	}
+	if src == nil {
+		return nil, fmt.Errorf("need a usable PRNG apophenia.Sequence")
+	}
+	return &PowerLaw{src: src, seed: seed, xMin: xMin, negInvA1: -1 / (exponent - 1)}, nil
+}
+
+// Nth returns the value for the given index.
+func (p *PowerLaw) Nth(index uint64) float64 {
+	bits := p.src.BitsAt(OffsetFor(SequencePowerLaw, p.seed, 0, index))
+	u := unitFloat64(bits.Lo)
+	return p.xMin * math.Exp(p.negInvA1*math.Log1p(-u))
+}
@@ -0,0 +1,68 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"sort"
+	"testing"
+)
+
+func Test_PowerLawTail(t *testing.T) {
+	const samples = 100000
+	for _, exponent := range []float64{1.5, 2, 3.5} {
+		p, err := NewPowerLaw(exponent, 2, 0, NewSequence(0))
+		if err != nil {
+			t.Fatalf("exponent %g: unexpected error: %v", exponent, err)
+		}
+		values := make([]float64, samples)
+		for i := range values {
+			values[i] = p.Nth(uint64(i))
+			if values[i] < 2 {
+				t.Fatalf("exponent %g: value %g below xMin", exponent, values[i])
+			}
+		}
+		sort.Float64s(values)
+		// The complementary CDF should be a straight line on a log-log
+		// plot, with slope 1-exponent. Fit a line to the empirical
+		// CCDF, skipping the very noisy extreme tail.
+		var sx, sy, sxx, sxy, n float64
+		for i := 0; i < samples*999/1000; i += 10 {
+			x := math.Log(values[i])
+			y := math.Log(float64(samples-i) / samples)
+			sx += x
+			sy += y
+			sxx += x * x
+			sxy += x * y
+			n++
+		}
+		slope := (n*sxy - sx*sy) / (n*sxx - sx*sx)
+		if exp := 1 - exponent; math.Abs(slope-exp) > 0.05*math.Abs(exp) {
+			t.Errorf("exponent %g: expected log-log slope %g, got %g", exponent, exp, slope)
+		}
+	}
+}
+
+func Test_PowerLawInvalid(t *testing.T) {
+	cases := [][2]float64{{1, 1}, {0.5, 1}, {2, 0}, {2, -1}, {math.NaN(), 1}, {2, math.Inf(1)}}
+	for _, c := range cases {
+		if _, err := NewPowerLaw(c[0], c[1], 0, NewSequence(0)); err == nil {
+			t.Errorf("exponent %g, xMin %g: expected error", c[0], c[1])
+		}
+	}
+	if _, err := NewPowerLaw(2, 1, 0, nil); err == nil {
+		t.Errorf("expected error for nil sequence")
+	}
+}
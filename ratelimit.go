@@ -0,0 +1,68 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimitedGen wraps a generator, such as a Zipf, and delivers its
+// values at a fixed rate, for load testing at a controlled throughput.
+type RateLimitedGen struct {
+	gen interface{ Next() uint64 }
+}
+
+// NewRateLimitedGen returns a RateLimitedGen producing values from gen.
+func NewRateLimitedGen(gen interface{ Next() uint64 }) *RateLimitedGen {
+	return &RateLimitedGen{gen: gen}
+}
+
+// Chan returns a channel which delivers values from the generator at
+// the given rate, in values per second, until ctx is done, at which
+// point the channel is closed. Pacing uses a time.Ticker, so if the
+// receiver falls behind, ticks are dropped rather than delivering a
+// burst of values to catch up. The generator's Next is only called
+// from the goroutine feeding the channel, which must therefore be the
+// only user of the generator until the channel is closed. Chan panics
+// if rate is not positive.
+func (r *RateLimitedGen) Chan(ctx context.Context, rate float64) <-chan uint64 {
+	if !(rate > 0) {
+		panic("rate must be positive")
+	}
+	interval := time.Duration(float64(time.Second) / rate)
+	if interval < 1 {
+		interval = 1
+	}
+	ch := make(chan uint64)
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- r.gen.Next():
+			}
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,65 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_RateLimitedGen(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping timing test in short mode")
+	}
+	z, err := NewZipf(1.3, 1.5, 100, 0, NewSequence(0))
+	if err != nil {
+		t.Fatalf("creating zipf: %v", err)
+	}
+	check, _ := NewZipf(1.3, 1.5, 100, 0, NewSequence(0))
+	const rate = 200
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	count := 0
+	for v := range NewRateLimitedGen(z).Chan(ctx, rate) {
+		if exp := check.Next(); v != exp {
+			t.Fatalf("value %d: expected %d, got %d", count, exp, v)
+		}
+		count++
+	}
+	if count < rate*9/10 || count > rate*11/10 {
+		t.Errorf("expected about %d values in one second, got %d", rate, count)
+	}
+}
+
+func Test_RateLimitedGenCancel(t *testing.T) {
+	z, _ := NewZipf(1.3, 1.5, 100, 0, NewSequence(0))
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := NewRateLimitedGen(z).Chan(ctx, 1000)
+	<-ch
+	cancel()
+	// the channel closes, possibly after one value already in flight.
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("channel not closed after cancel")
+		}
+	}
+}
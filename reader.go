@@ -0,0 +1,102 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ReaderSequence is a Sequence which just reads its bits from an
+// io.Reader, 16 bytes at a time, ignoring the offsets it's asked for.
+// It exists so that tests of code which takes a Sequence can supply
+// known values; it is not seekable, and is not useful as a source of
+// pseudo-random numbers.
+//
+// Each call to BitsAt, Uint64, or Int63 consumes 16 bytes, which are
+// interpreted as two little-endian uint64 values, Lo followed by Hi.
+// Because the Sequence interface doesn't allow errors to be returned,
+// a failed read yields zero bits, and the error is available from Err.
+// The same applies to attempts to seek anywhere other than the current
+// position, or to reseed.
+type ReaderSequence struct {
+	r      io.Reader
+	buf    [16]byte
+	offset Uint128 // number of 16-byte blocks read so far
+	err    error
+}
+
+// NewReaderSequence returns a ReaderSequence reading from r.
+func NewReaderSequence(r io.Reader) (Sequence, error) {
+	if r == nil {
+		return nil, errors.New("reader sequence requires a non-nil reader")
+	}
+	return &ReaderSequence{r: r}, nil
+}
+
+// Err returns the first error encountered, if any.
+func (s *ReaderSequence) Err() error {
+	return s.err
+}
+
+func (s *ReaderSequence) setErr(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// Seed can't reseed a reader, so it just records an error.
+func (s *ReaderSequence) Seed(seed int64) {
+	s.setErr(errors.New("can't reseed a ReaderSequence"))
+}
+
+// Int63 returns a value in 0..(1<<63)-1.
+func (s *ReaderSequence) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+// Uint64 returns a value in 0..(1<<64)-1.
+func (s *ReaderSequence) Uint64() uint64 {
+	return s.BitsAt(s.offset).Lo
+}
+
+// Seek yields the current position, which is the number of 16-byte
+// blocks read so far. Seeking to any other position records an error.
+func (s *ReaderSequence) Seek(offset Uint128) (old Uint128) {
+	if offset != s.offset {
+		s.setErr(fmt.Errorf("can't seek ReaderSequence from %s to %s", s.offset, offset))
+	}
+	return s.offset
+}
+
+// BitsAt reads the next 16 bytes from the reader, ignoring offset.
+func (s *ReaderSequence) BitsAt(offset Uint128) (out Uint128) {
+	if _, err := io.ReadFull(s.r, s.buf[:]); err != nil {
+		s.setErr(err)
+		return out
+	}
+	s.offset.Inc()
+	out.Lo, out.Hi = binary.LittleEndian.Uint64(s.buf[:8]), binary.LittleEndian.Uint64(s.buf[8:])
+	return out
+}
+
+// BitsSliceAt reads 16 bytes for each entry of dst, ignoring start.
+func (s *ReaderSequence) BitsSliceAt(start Uint128, dst []Uint128) {
+	for i := range dst {
+		dst[i] = s.BitsAt(start)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func Test_ReaderSequence(t *testing.T) {
+	data := []byte{
+		0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08,
+		0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18,
+		0xff, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0x80,
+		0x2a, 0, 0, 0, 0, 0, 0, 0,
+	}
+	seq, err := NewReaderSequence(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rs := seq.(*ReaderSequence)
+	// offset is ignored
+	got := seq.BitsAt(OffsetFor(SequenceZipfU, 3, 0, 17))
+	exp := Uint128{Lo: 0x0807060504030201, Hi: 0x1817161514131211}
+	if got != exp {
+		t.Fatalf("first block: expected %s, got %s", exp, got)
+	}
+	// seeking to the current position is fine.
+	if old := seq.Seek(Uint128{Lo: 1}); old != (Uint128{Lo: 1}) || rs.Err() != nil {
+		t.Fatalf("seek to current position: got %s, err %v", old, rs.Err())
+	}
+	if v := seq.Uint64(); v != 0xff {
+		t.Fatalf("second block: expected 0xff, got %#x", v)
+	}
+	// only 8 bytes left, so this read fails.
+	if got := seq.BitsAt(Uint128{}); got != (Uint128{}) {
+		t.Fatalf("short read: expected zero bits, got %s", got)
+	}
+	if rs.Err() != io.ErrUnexpectedEOF {
+		t.Fatalf("short read: expected %v, got %v", io.ErrUnexpectedEOF, rs.Err())
+	}
+
+	seq, _ = NewReaderSequence(bytes.NewReader(data))
+	rs = seq.(*ReaderSequence)
+	seq.Seek(Uint128{Lo: 5})
+	if rs.Err() == nil {
+		t.Fatalf("expected error seeking ReaderSequence")
+	}
+	seq, _ = NewReaderSequence(bytes.NewReader(data))
+	rs = seq.(*ReaderSequence)
+	seq.Seed(1)
+	if rs.Err() == nil {
+		t.Fatalf("expected error seeding ReaderSequence")
+	}
+	if _, err := NewReaderSequence(nil); err == nil {
+		t.Fatalf("expected error for nil reader")
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import "errors"
+
+// RenewalProcess produces the arrival times of a renewal process, in
+// which the times between arrivals are independent draws from some
+// distribution. With exponential inter-arrival times, this is a Poisson
+// process; other distributions can model workloads with more regular,
+// or burstier, arrivals.
+//
+// Unlike PoissonProcess, the time of the nth arrival can't be computed
+// directly for general distributions, so NthArrival sums n inter-arrival
+// times. It picks up from the last arrival computed if it can, so
+// iterating through arrivals in order takes constant time per arrival,
+// but seeking backwards starts over from the beginning.
+type RenewalProcess struct {
+	interArrival ContinuousDistribution
+	start        uint64 // index of interArrival for the first arrival
+	lastN        uint64
+	lastTime     float64
+}
+
+// NewRenewalProcess returns a RenewalProcess whose inter-arrival times
+// come from interArrival. The seed and src select which values of
+// interArrival are used, so different seeds with the same interArrival
+// give different processes.
+func NewRenewalProcess(interArrival ContinuousDistribution, seed uint32, src Sequence) (*RenewalProcess, error) {
+	if interArrival == nil {
+		return nil, errors.New("need an inter-arrival distribution for renewal process")
+	}
+	if src == nil {
+		return nil, errors.New("need a usable PRNG apophenia.Sequence")
+	}
+	start := src.BitsAt(OffsetFor(SequenceRenewal, seed, 0, 0)).Lo
+	return &RenewalProcess{interArrival: interArrival, start: start}, nil
+}
+
+// NthArrival returns the time of the nth arrival, counting from 1; the
+// "0th arrival" is at time 0. It takes time proportional to n, or to the
+// distance from the last arrival computed if that was earlier.
+func (r *RenewalProcess) NthArrival(n uint64) float64 {
+	if n < r.lastN {
+		r.lastN, r.lastTime = 0, 0
+	}
+	for r.lastN < n {
+		r.lastTime += r.interArrival.Nth(r.start + r.lastN)
+		r.lastN++
+	}
+	return r.lastTime
+}
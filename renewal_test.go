@@ -0,0 +1,100 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_RenewalProcessExponential(t *testing.T) {
+	const rate = 2.0
+	e, err := NewExponential(rate, 0, NewSequence(0))
+	if err != nil {
+		t.Fatalf("creating exponential: %v", err)
+	}
+	r, err := NewRenewalProcess(e, 0, NewSequence(0))
+	if err != nil {
+		t.Fatalf("creating renewal process: %v", err)
+	}
+	// With exponential gaps, the number of arrivals in each unit of
+	// time is Poisson, with mean and variance both equal to rate.
+	const intervals = 20000
+	counts := make([]float64, intervals)
+	prev := r.NthArrival(0)
+	for n := uint64(1); ; n++ {
+		at := r.NthArrival(n)
+		if at < prev {
+			t.Fatalf("arrival %d at %g, before previous arrival at %g", n, at, prev)
+		}
+		if at >= intervals {
+			break
+		}
+		counts[int(at)]++
+		prev = at
+	}
+	sum, sumSq := 0.0, 0.0
+	for _, c := range counts {
+		sum += c
+		sumSq += c * c
+	}
+	mean := sum / intervals
+	variance := sumSq/intervals - mean*mean
+	if math.Abs(mean-rate) > 5*math.Sqrt(rate/intervals) {
+		t.Errorf("mean arrivals per unit time %g, expected about %g", mean, rate)
+	}
+	if math.Abs(variance-rate) > 0.05*rate {
+		t.Errorf("variance of arrivals per unit time %g, expected about %g", variance, rate)
+	}
+	// seeking backwards gives the same answers.
+	forward := r.NthArrival(500)
+	if back := r.NthArrival(10); back >= forward {
+		t.Errorf("arrival 10 at %g, not before arrival 500 at %g", back, forward)
+	}
+	if again := r.NthArrival(500); again != forward {
+		t.Errorf("arrival 500 at %g, then %g", forward, again)
+	}
+}
+
+func Test_RenewalProcessMatchesPoisson(t *testing.T) {
+	// The nth arrival time has the same distribution as a Poisson
+	// process's: Gamma(n, rate).
+	const rate, n, trials = 4.0, 50, 2000
+	src := NewSequence(0)
+	e, _ := NewExponential(rate, 0, src)
+	renewal, poisson := 0.0, 0.0
+	for seed := uint32(0); seed < trials; seed++ {
+		r, _ := NewRenewalProcess(e, seed, src)
+		renewal += r.NthArrival(n)
+		p, _ := NewPoissonProcess(rate, seed, src)
+		poisson += p.NthArrival(n)
+	}
+	sd := math.Sqrt(n) / rate / math.Sqrt(trials)
+	for _, mean := range []float64{renewal / trials, poisson / trials} {
+		if math.Abs(mean-n/rate) > 5*sd {
+			t.Errorf("mean arrival time %g, expected about %g", mean, n/rate)
+		}
+	}
+}
+
+func Test_RenewalProcessInvalid(t *testing.T) {
+	if _, err := NewRenewalProcess(nil, 0, NewSequence(0)); err == nil {
+		t.Errorf("expected error for nil distribution")
+	}
+	e, _ := NewExponential(1, 0, NewSequence(0))
+	if _, err := NewRenewalProcess(e, 0, nil); err == nil {
+		t.Errorf("expected error for nil Sequence")
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import "fmt"
+
+// RoundRobin assigns indexes to partitions evenly, like index %
+// numPartitions, but in a shuffled order, so consecutive indexes don't
+// go to consecutive partitions. Each run of numPartitions consecutive
+// indexes, starting from a multiple of numPartitions, goes to every
+// partition exactly once, in the order given by a Permutation; the same
+// order repeats for each run.
+type RoundRobin struct {
+	perm *Permutation
+	n    uint64
+}
+
+// NewRoundRobin returns a RoundRobin over numPartitions partitions. It
+// panics if numPartitions is less than 1 or src is nil.
+func NewRoundRobin(numPartitions int64, seed uint32, src Sequence) *RoundRobin {
+	if src == nil {
+		panic("need a usable PRNG apophenia.Sequence")
+	}
+	p, err := NewPermutation(numPartitions, seed, src)
+	if err != nil {
+		panic(fmt.Sprintf("can't create round robin: %v", err))
+	}
+	return &RoundRobin{perm: p, n: uint64(numPartitions)}
+}
+
+// Nth returns the partition for the given index.
+func (r *RoundRobin) Nth(index uint64) int64 {
+	return r.perm.NthStateless(int64(index % r.n))
+}
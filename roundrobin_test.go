@@ -0,0 +1,54 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import "testing"
+
+func Test_RoundRobin(t *testing.T) {
+	const partitions, cycles = 37, 100
+	r := NewRoundRobin(partitions, 2, NewSequence(0))
+	counts := make([]int, partitions)
+	adjacent := 0
+	prev := int64(-1)
+	for i := uint64(0); i < partitions*cycles; i++ {
+		p := r.Nth(i)
+		if p < 0 || p >= partitions {
+			t.Fatalf("index %d: partition %d out of range", i, p)
+		}
+		counts[p]++
+		// every run of partitions indexes hits each partition once.
+		if counts[p] != int(i/partitions)+1 {
+			t.Fatalf("index %d: partition %d hit %d times", i, p, counts[p])
+		}
+		if p == prev+1 {
+			adjacent++
+		}
+		prev = p
+	}
+	for p, c := range counts {
+		if c != cycles {
+			t.Errorf("partition %d: %d indexes, expected %d", p, c, cycles)
+		}
+	}
+	if adjacent > partitions*cycles/4 {
+		t.Errorf("%d of %d consecutive indexes went to consecutive partitions", adjacent, partitions*cycles)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic for zero partitions")
+		}
+	}()
+	NewRoundRobin(0, 0, NewSequence(0))
+}
@@ -0,0 +1,103 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// RunLength generates run-length encoded rows: a series of segments of
+// set bits, with the set bits making up a given density of the row
+// overall. Segment n lies within the slot [n*w, (n+1)*w) for a fixed
+// slot width w, so segments never overlap, are in order of index, and
+// any segment can be computed without the ones before it.
+//
+// Segment lengths are in [1,maxLen], averaging density*w, and each
+// segment is placed uniformly at random within its slot. For densities
+// up to about 1/2, lengths are spread over the whole range, and the slot
+// width is chosen to match; for higher densities, slots are maxLen wide,
+// and lengths are concentrated closer to maxLen.
+type RunLength struct {
+	src    Sequence
+	seed   uint32
+	maxLen uint64
+	width  uint64
+	mean   float64 // mean segment length
+	spread float64 // lengths are mean +/- spread, before rounding
+}
+
+// NewRunLength creates a RunLength producing segments of up to maxLen set
+// bits, with an overall density of set bits in (0,1]. Some densities
+// can't be produced exactly for small maxLen; with maxLen 1, for
+// instance, the density has to be 1/n for some n. Those are errors.
+func NewRunLength(density float64, maxLen uint64, seed uint32, src Sequence) (*RunLength, error) {
+	if !(density > 0 && density <= 1) {
+		return nil, fmt.Errorf("need density in (0,1] (got %g) for run lengths", density)
+	}
+	if maxLen < 1 {
+		return nil, errors.New("need maxLen >= 1 for run lengths")
+	}
+	if src == nil {
+		return nil, errors.New("need a usable PRNG apophenia.Sequence")
+	}
+	// The mean length, density*width, has to be in [1,maxLen], so the
+	// width has to be an integer in [1/density, maxLen/density]. The
+	// slack allows for rounding in densities like 1/3.
+	minWidth := math.Ceil(1 / density * (1 - 1e-12))
+	maxWidth := math.Floor(float64(maxLen) / density * (1 + 1e-12))
+	if minWidth > maxWidth {
+		return nil, fmt.Errorf("density %g not possible with segments of up to %d bits", density, maxLen)
+	}
+	width := math.Round((float64(maxLen) + 1) / (2 * density))
+	width = math.Max(minWidth, math.Min(width, maxWidth))
+	if width >= 1<<63 {
+		return nil, fmt.Errorf("density %g too low for segments of up to %d bits", density, maxLen)
+	}
+	r := &RunLength{src: src, seed: seed, maxLen: maxLen, width: uint64(width)}
+	if r.width < maxLen {
+		r.width = maxLen
+	}
+	// Using the integer width we actually got keeps the density exact;
+	// the clamp only absorbs rounding.
+	r.mean = math.Max(1, math.Min(density*float64(r.width), float64(maxLen)))
+	r.spread = math.Min(r.mean-1, float64(maxLen)-r.mean)
+	return r, nil
+}
+
+// Width returns the width of the slot each segment is placed in.
+func (r *RunLength) Width() uint64 {
+	return r.width
+}
+
+// Nth returns the start and length of the segment with the given index.
+// The start wraps around if index*Width() exceeds 2^64.
+func (r *RunLength) Nth(index uint64) (start, length uint64) {
+	bits1 := r.src.BitsAt(OffsetFor(SequenceRunLength, r.seed, 0, index))
+	// A uniform value in mean +/- spread, randomly rounded up or down so
+	// the expected length is exactly mean.
+	x := r.mean - r.spread + 2*r.spread*unitFloat64(bits1.Lo)
+	length = uint64(math.Floor(x + unitFloat64(bits1.Hi)))
+	if length < 1 {
+		length = 1
+	} else if length > r.maxLen {
+		length = r.maxLen
+	}
+	bits2 := r.src.BitsAt(OffsetFor(SequenceRunLength, r.seed, 1, index))
+	pos, _ := bits.Mul64(bits2.Lo, r.width-length+1)
+	return index*r.width + pos, length
+}
@@ -0,0 +1,73 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_RunLength(t *testing.T) {
+	src := NewSequence(0)
+	const n = 100000
+	for _, c := range []struct {
+		density float64
+		maxLen  uint64
+	}{
+		{0.001, 10}, {0.05, 1}, {1.0 / 3, 1}, {0.2, 100}, {0.5, 64}, {0.9, 20}, {1, 8},
+		{0.3, 2}, {0.4, 2}, {0.7, 2},
+	} {
+		r, err := NewRunLength(c.density, c.maxLen, 1, src)
+		if err != nil {
+			t.Fatalf("creating RunLength(%g, %d): %v", c.density, c.maxLen, err)
+		}
+		var end, total, sumSq float64
+		for i := uint64(0); i < n; i++ {
+			start, length := r.Nth(i)
+			if length < 1 || length > c.maxLen {
+				t.Fatalf("%g/%d, index %d: length %d out of range", c.density, c.maxLen, i, length)
+			}
+			if float64(start) < end {
+				t.Fatalf("%g/%d, index %d: segment at %d overlaps previous segment ending at %.0f",
+					c.density, c.maxLen, i, start, end)
+			}
+			end = float64(start + length)
+			total += float64(length)
+			sumSq += float64(length) * float64(length)
+		}
+		// 5 sigma, using the observed variance of the lengths.
+		mean := total / n
+		sigma := math.Sqrt((sumSq/n-mean*mean)/n) / float64(r.Width())
+		got := total / (n * float64(r.Width()))
+		if math.Abs(got-c.density) > 5*sigma+1e-12 {
+			t.Errorf("%g/%d: expected density %g, got %g", c.density, c.maxLen, c.density, got)
+		}
+	}
+	for _, density := range []float64{0, -0.5, 1.5, math.NaN()} {
+		if _, err := NewRunLength(density, 10, 0, src); err == nil {
+			t.Errorf("density %g: expected error", density)
+		}
+	}
+	if _, err := NewRunLength(0.5, 0, 0, src); err == nil {
+		t.Errorf("maxLen 0: expected error")
+	}
+	// with maxLen 1, every segment is one bit, so the density is 1/width;
+	// these would come out as 1/3.
+	for _, density := range []float64{0.3, 0.4} {
+		if _, err := NewRunLength(density, 1, 0, src); err == nil {
+			t.Errorf("density %g, maxLen 1: expected error", density)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18
+
+package apophenia
+
+import "fmt"
+
+// SampleWithoutReplacement returns k distinct elements of population,
+// chosen using the first k values of a Permutation of its indexes, in
+// the order the Permutation produces them. Distinct here means distinct
+// positions; if population contains duplicate values, so may the result.
+// It's an error for k to be negative or greater than len(population).
+func SampleWithoutReplacement[T any](population []T, k int, seed uint32, src Sequence) ([]T, error) {
+	if k < 0 || k > len(population) {
+		return nil, fmt.Errorf("can't sample %d elements from population of %d", k, len(population))
+	}
+	if k == 0 {
+		return []T{}, nil
+	}
+	p, err := NewPermutation(int64(len(population)), seed, src)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]T, k)
+	for i := range out {
+		out[i] = population[p.Next()]
+	}
+	return out, nil
+}
+
+// NewPermutationOf creates a Permutation of the indexes of data, so its
+// max is len(data), and each value it produces is a valid index into
+// data. The data itself isn't used or retained. It's an error for data to
+// be empty.
+func NewPermutationOf[T any](seed uint32, src Sequence, data []T) (*Permutation, error) {
+	return NewPermutation(int64(len(data)), seed, src)
+}
@@ -0,0 +1,99 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18
+
+package apophenia
+
+import (
+	"fmt"
+	"testing"
+)
+
+func Test_SampleWithoutReplacement(t *testing.T) {
+	src := NewSequence(0)
+	population := make([]string, 50)
+	index := make(map[string]int)
+	for i := range population {
+		population[i] = fmt.Sprintf("feature%d", i)
+		index[population[i]] = i
+	}
+	for _, k := range []int{0, 1, 10, 50} {
+		sample, err := SampleWithoutReplacement(population, k, 3, src)
+		if err != nil {
+			t.Fatalf("k %d: %v", k, err)
+		}
+		if len(sample) != k {
+			t.Fatalf("k %d: got %d elements", k, len(sample))
+		}
+		seen := make(map[string]bool)
+		for _, s := range sample {
+			if _, ok := index[s]; !ok {
+				t.Fatalf("k %d: %q not in population", k, s)
+			}
+			if seen[s] {
+				t.Fatalf("k %d: %q selected twice", k, s)
+			}
+			seen[s] = true
+		}
+	}
+	// same seed, same sample; different seed, different sample.
+	a, _ := SampleWithoutReplacement(population, 10, 3, src)
+	b, _ := SampleWithoutReplacement(population, 10, 3, src)
+	c, _ := SampleWithoutReplacement(population, 10, 4, src)
+	if fmt.Sprint(a) != fmt.Sprint(b) {
+		t.Errorf("same seed gave %v and %v", a, b)
+	}
+	if fmt.Sprint(a) == fmt.Sprint(c) {
+		t.Errorf("different seeds both gave %v", a)
+	}
+	for _, k := range []int{-1, 51} {
+		if _, err := SampleWithoutReplacement(population, k, 3, src); err == nil {
+			t.Errorf("k %d: expected error", k)
+		}
+	}
+	if _, err := SampleWithoutReplacement([]int{}, 1, 0, src); err == nil {
+		t.Errorf("empty population: expected error")
+	}
+}
+
+func Test_NewPermutationOf(t *testing.T) {
+	words := []string{"alpha", "beta", "gamma", "delta", "epsilon"}
+	p, err := NewPermutationOf(4, NewSequence(0), words)
+	if err != nil {
+		t.Fatalf("creating permutation: %v", err)
+	}
+	if p.max != int64(len(words)) {
+		t.Fatalf("expected max %d, got %d", len(words), p.max)
+	}
+	plain, _ := NewPermutation(int64(len(words)), 4, NewSequence(0))
+	seen := make(map[string]bool)
+	for range words {
+		i, exp := p.Next(), plain.Next()
+		if i != exp {
+			t.Fatalf("expected index %d, got %d", exp, i)
+		}
+		seen[words[i]] = true
+	}
+	if len(seen) != len(words) {
+		t.Fatalf("expected all %d words, got %d", len(words), len(seen))
+	}
+	big, _ := NewPermutationOf(0, NewSequence(0), make([]struct{}, 100000))
+	if big.max != 100000 {
+		t.Errorf("expected max 100000, got %d", big.max)
+	}
+	if _, err := NewPermutationOf(0, NewSequence(0), []int{}); err == nil {
+		t.Errorf("expected error for empty slice")
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// SamplerBuilder composes a Distribution from weighted components. Each
+// value is drawn by picking a component in proportion to the weights,
+// then drawing that component's value for the same index. For instance,
+// a mixture of three Zipfs over different ranges:
+//
+//	d, err := NewSampler(src, seed).
+//		ZipfComponent(1.1, 1, 100, 0.5).
+//		ZipfComponent(1.5, 2, 1000, 0.3).
+//		UniformComponent(5000, 6000, 0.2).
+//		Build()
+//
+// Errors in any component are reported by Build.
+type SamplerBuilder struct {
+	src        Sequence
+	seed       uint32
+	components []Distribution
+	weights    []float64
+	err        error
+}
+
+// NewSampler returns a SamplerBuilder with no components. The seed is
+// used both for choosing components and for the components themselves,
+// so a single component with weight 1 produces exactly the values it
+// would on its own.
+func NewSampler(src Sequence, seed uint32) *SamplerBuilder {
+	return &SamplerBuilder{src: src, seed: seed}
+}
+
+// add adds a component, unless there's already been an error.
+func (b *SamplerBuilder) add(d Distribution, err error, weight float64) *SamplerBuilder {
+	if b.err != nil {
+		return b
+	}
+	if err != nil {
+		b.err = fmt.Errorf("component %d: %v", len(b.components), err)
+		return b
+	}
+	if !(weight >= 0) || math.IsInf(weight, 1) {
+		b.err = fmt.Errorf("component %d: weight must be finite and non-negative, got %g", len(b.components), weight)
+		return b
+	}
+	b.components = append(b.components, d)
+	b.weights = append(b.weights, weight)
+	return b
+}
+
+// ZipfComponent adds a Zipf component, as from NewZipf, with the given
+// weight.
+func (b *SamplerBuilder) ZipfComponent(q, v float64, max uint64, weight float64) *SamplerBuilder {
+	z, err := NewZipf(q, v, max, b.seed, b.src)
+	return b.add(z, err, weight)
+}
+
+// UniformComponent adds a component uniformly distributed over [lo,hi),
+// with the given weight.
+func (b *SamplerBuilder) UniformComponent(lo, hi uint64, weight float64) *SamplerBuilder {
+	if hi <= lo {
+		return b.add(nil, fmt.Errorf("empty uniform range [%d,%d)", lo, hi), weight)
+	}
+	u, err := NewUniform(hi-lo, b.seed, b.src)
+	if err != nil {
+		return b.add(nil, err, weight)
+	}
+	return b.add(&shifted{Distribution: u, by: lo}, nil, weight)
+}
+
+// Build returns the composed Distribution, or the first error found
+// while adding components.
+func (b *SamplerBuilder) Build() (Distribution, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.src == nil {
+		return nil, errors.New("need a usable PRNG apophenia.Sequence")
+	}
+	total := 0.0
+	for _, w := range b.weights {
+		total += w
+	}
+	if !(total > 0) {
+		return nil, errors.New("need at least one component with non-zero weight")
+	}
+	m := &mixture{
+		src:        b.src,
+		seed:       b.seed,
+		components: append([]Distribution(nil), b.components...),
+		weights:    make([]float64, len(b.weights)),
+		table:      newAliasTable(b.weights),
+	}
+	for i, w := range b.weights {
+		m.weights[i] = w / total
+	}
+	return m, nil
+}
+
+// mixture is a weighted mixture of Distributions.
+type mixture struct {
+	src        Sequence
+	seed       uint32
+	components []Distribution
+	weights    []float64 // normalized to sum to 1
+	table      *aliasTable
+}
+
+// Nth returns the value for the given index.
+func (m *mixture) Nth(index uint64) uint64 {
+	if len(m.components) == 1 {
+		return m.components[0].Nth(index)
+	}
+	c := m.table.sample(m.src.BitsAt(OffsetFor(SequenceMixture, m.seed, 0, index)))
+	return m.components[c].Nth(index)
+}
+
+// Probability returns the probability of the given value.
+func (m *mixture) Probability(value uint64) float64 {
+	p := 0.0
+	for i, c := range m.components {
+		p += m.weights[i] * c.Probability(value)
+	}
+	return p
+}
+
+// shifted is a Distribution whose values are all offset by a constant.
+type shifted struct {
+	Distribution
+	by uint64
+}
+
+// Nth returns the value for the given index.
+func (s *shifted) Nth(index uint64) uint64 {
+	return s.Distribution.Nth(index) + s.by
+}
+
+// Probability returns the probability of the given value.
+func (s *shifted) Probability(value uint64) float64 {
+	if value < s.by {
+		return 0
+	}
+	return s.Distribution.Probability(value - s.by)
+}
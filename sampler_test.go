@@ -0,0 +1,93 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_SamplerSingleComponent(t *testing.T) {
+	src := NewSequence(0)
+	d, err := NewSampler(src, 3).ZipfComponent(1.3, 1.5, 100, 2).Build()
+	if err != nil {
+		t.Fatalf("building sampler: %v", err)
+	}
+	z, _ := NewZipf(1.3, 1.5, 100, 3, src)
+	for i := uint64(0); i < 1000; i++ {
+		if a, b := d.Nth(i), z.Nth(i); a != b {
+			t.Fatalf("index %d: sampler gave %d, zipf gave %d", i, a, b)
+		}
+	}
+	for v := uint64(0); v < 100; v++ {
+		if a, b := d.Probability(v), z.Probability(v); math.Abs(a-b) > 1e-15 {
+			t.Fatalf("value %d: sampler probability %g, zipf probability %g", v, a, b)
+		}
+	}
+}
+
+func Test_SamplerMixture(t *testing.T) {
+	// weights 1:3 don't need to sum to 1.
+	d, err := NewSampler(NewSequence(0), 0).
+		UniformComponent(0, 10, 1).
+		UniformComponent(100, 110, 3).
+		Build()
+	if err != nil {
+		t.Fatalf("building sampler: %v", err)
+	}
+	const samples = 100000
+	low := 0
+	for i := uint64(0); i < samples; i++ {
+		v := d.Nth(i)
+		switch {
+		case v < 10:
+			low++
+		case v >= 100 && v < 110:
+		default:
+			t.Fatalf("index %d: value %d outside both components", i, v)
+		}
+	}
+	exp := 0.25 * samples
+	if math.Abs(float64(low)-exp) > 5*math.Sqrt(exp) {
+		t.Errorf("expected about %.0f values from the first component, got %d", exp, low)
+	}
+	total := 0.0
+	for v := uint64(0); v < 200; v++ {
+		total += d.Probability(v)
+	}
+	if math.Abs(total-1) > 1e-12 {
+		t.Errorf("probabilities sum to %g", total)
+	}
+	if p := d.Probability(5); math.Abs(p-0.025) > 1e-15 {
+		t.Errorf("value 5: expected probability 0.025, got %g", p)
+	}
+}
+
+func Test_SamplerInvalid(t *testing.T) {
+	src := NewSequence(0)
+	builders := map[string]*SamplerBuilder{
+		"empty":       NewSampler(src, 0),
+		"zero weight": NewSampler(src, 0).UniformComponent(0, 10, 0),
+		"bad weight":  NewSampler(src, 0).UniformComponent(0, 10, -1),
+		"bad zipf":    NewSampler(src, 0).ZipfComponent(0.5, 1, 10, 1).UniformComponent(0, 10, 1),
+		"bad range":   NewSampler(src, 0).UniformComponent(10, 10, 1),
+		"nil source":  NewSampler(nil, 0),
+	}
+	for name, b := range builders {
+		if _, err := b.Build(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// Seed is a general-purpose seed value, which can be derived from
+// integers, strings, arbitrary bytes, or times, and then used to create
+// a Sequence with NewSequenceFromSeed, or to derive the secondary uint32
+// seeds used by Permutation, Zipf, and so on with Uint32.
+//
+// The constructors which take int64 or uint32 seeds are kept. Sequence
+// constructors with int64 seeds have FromSeed variants, such as
+// NewSequenceFromSeed, which give the same results for
+// SeedFromInt64(n) as the originals do for n. Permutation and the
+// Distribution types have FromSeed variants, such as NewZipfFromSeed,
+// which pass Seed.Uint32 to the originals; other types take Uint32()
+// directly.
+type Seed [32]byte
+
+// SeedFromInt64 returns a Seed holding n, in little-endian order, in its
+// first 8 bytes, with the rest zero.
+func SeedFromInt64(n int64) (s Seed) {
+	binary.LittleEndian.PutUint64(s[:8], uint64(n))
+	return s
+}
+
+// SeedFromBytes returns a Seed which is the SHA-256 hash of b.
+func SeedFromBytes(b []byte) Seed {
+	return Seed(sha256.Sum256(b))
+}
+
+// SeedFromString returns a Seed which is the SHA-256 hash of str.
+func SeedFromString(str string) Seed {
+	return SeedFromBytes([]byte(str))
+}
+
+// SeedFromTime returns a Seed holding the Unix time of t, as seconds and
+// nanoseconds, so distinct instants give distinct seeds. This isn't a
+// way to get unpredictable seeds; it's a way to get repeatable ones
+// from timestamps.
+func SeedFromTime(t time.Time) (s Seed) {
+	binary.LittleEndian.PutUint64(s[:8], uint64(t.Unix()))
+	binary.LittleEndian.PutUint32(s[8:12], uint32(t.Nanosecond()))
+	// distinguish from SeedFromInt64 of the same number of seconds
+	s[31] = 't'
+	return s
+}
+
+// Uint32 folds s into a uint32, suitable for the secondary seed
+// parameters of Permutation, Zipf, and the like.
+func (s Seed) Uint32() (out uint32) {
+	for i := 0; i < len(s); i += 4 {
+		out ^= binary.LittleEndian.Uint32(s[i : i+4])
+	}
+	return out
+}
+
+// NewSequenceFromSeed generates a sequence initialized with the given
+// seed. The two halves of the seed are XORed together to form a 128-bit
+// AES key.
+func NewSequenceFromSeed(seed Seed) Sequence {
+	var key [16]byte
+	for i := range key {
+		key[i] = seed[i] ^ seed[i+16]
+	}
+	return newAESSequence(key)
+}
+
+// NewSequenceFromUUID generates a sequence using the 16 bytes of a UUID,
+// such as a database row ID, as its AES key. Any UUID works, not just
+// random (version 4) ones; since AES keys don't need to be random,
+// sequential or time-based UUIDs give sequences as unrelated as any
+// other distinct keys.
+func NewSequenceFromUUID(uuid [16]byte) Sequence {
+	return newAESSequence(uuid)
+}
+
+// NewSequenceFromHex generates a sequence using a 128-bit AES key written
+// as 32 hex digits, such as a seed in a test fixture. The digits give the
+// key's bytes in order, so NewSequence(n) is the same as the hex for n's
+// little-endian bytes followed by zeros; "00000000000000000000000000000000"
+// is NewSequence(0).
+func NewSequenceFromHex(hexKey string) (Sequence, error) {
+	if len(hexKey) != 32 {
+		return nil, fmt.Errorf("hex key must be 32 hex digits, got %d characters", len(hexKey))
+	}
+	var key [16]byte
+	if _, err := hex.Decode(key[:], []byte(hexKey)); err != nil {
+		return nil, fmt.Errorf("invalid hex key %q: %v", hexKey, err)
+	}
+	return newAESSequence(key), nil
+}
@@ -0,0 +1,165 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+)
+
+func Test_SeedStable(t *testing.T) {
+	// These values must never change, or existing users' seeds will
+	// produce different results.
+	if s := SeedFromInt64(0); s != (Seed{}) {
+		t.Fatalf("SeedFromInt64(0): expected all zeroes, got %x", s)
+	}
+	cases := []struct {
+		name string
+		seed Seed
+		exp  string
+	}{
+		{"int64", SeedFromInt64(0x0102030405060708),
+			"0807060504030201000000000000000000000000000000000000000000000000"},
+		{"int64(-1)", SeedFromInt64(-1),
+			"ffffffffffffffff000000000000000000000000000000000000000000000000"},
+		{"string", SeedFromString("apophenia"),
+			"2bed9f2a120583f47f1ba3f4f56bcb73064574184bca0885fe00b4dfc0e3b555"},
+		{"time", SeedFromTime(time.Unix(1566864000, 5)),
+			"8072645d00000000050000000000000000000000000000000000000000000074"},
+	}
+	for _, c := range cases {
+		if got := hex.EncodeToString(c.seed[:]); got != c.exp {
+			t.Errorf("%s: expected %s, got %s", c.name, c.exp, got)
+		}
+	}
+	if SeedFromString("x") != SeedFromBytes([]byte("x")) {
+		t.Errorf("SeedFromString and SeedFromBytes disagree")
+	}
+	if SeedFromTime(time.Unix(5, 0)) == SeedFromInt64(5) {
+		t.Errorf("SeedFromTime and SeedFromInt64 collide")
+	}
+}
+
+func Test_SeedSequence(t *testing.T) {
+	for _, n := range []int64{0, 1, -1, 1 << 40} {
+		a, b := NewSequence(n), NewSequenceFromSeed(SeedFromInt64(n))
+		for i := uint64(0); i < 10; i++ {
+			off := OffsetFor(SequenceDefault, 0, 0, i)
+			if x, y := a.BitsAt(off), b.BitsAt(off); x != y {
+				t.Fatalf("seed %d, offset %s: NewSequence gave %s, NewSequenceFromSeed gave %s",
+					n, off, x, y)
+			}
+		}
+		if a.Uint64() != b.Uint64() {
+			t.Fatalf("seed %d: Uint64 differs", n)
+		}
+	}
+	a, b := NewSequenceFromSeed(SeedFromString("a")), NewSequenceFromSeed(SeedFromString("b"))
+	if a.BitsAt(Uint128{}) == b.BitsAt(Uint128{}) {
+		t.Fatalf("distinct string seeds gave the same sequence")
+	}
+	if SeedFromInt64(7).Uint32() != 7 {
+		t.Fatalf("SeedFromInt64(7).Uint32(): expected 7, got %d", SeedFromInt64(7).Uint32())
+	}
+}
+
+func Test_SeedConstructors(t *testing.T) {
+	src := NewSequence(0)
+	seed := SeedFromString("constructors")
+	z1, _ := NewZipf(1.2, 2, 1000, seed.Uint32(), src)
+	z2, err := NewZipfFromSeed(1.2, 2, 1000, seed, src)
+	if err != nil {
+		t.Fatalf("NewZipfFromSeed: %v", err)
+	}
+	p1, _ := NewPermutation(1000, seed.Uint32(), src)
+	p2, err := NewPermutationFromSeed(1000, seed, src)
+	if err != nil {
+		t.Fatalf("NewPermutationFromSeed: %v", err)
+	}
+	for i := int64(0); i < 100; i++ {
+		if a, b := z1.Nth(uint64(i)), z2.Nth(uint64(i)); a != b {
+			t.Fatalf("zipf index %d: %d vs %d", i, a, b)
+		}
+		if a, b := p1.Nth(i), p2.Nth(i); a != b {
+			t.Fatalf("permutation index %d: %d vs %d", i, a, b)
+		}
+	}
+	if _, err := NewUniformFromSeed(0, seed, src); err == nil {
+		t.Errorf("NewUniformFromSeed(0): expected error")
+	}
+	g1, g2 := NewSequenceGroup(9), NewSequenceGroupFromSeed(SeedFromInt64(9))
+	m1, m2 := NewMultiStream(3, 9), NewMultiStreamFromSeed(3, SeedFromInt64(9))
+	off := OffsetFor(SequenceDefault, 0, 0, 5)
+	if g1.Stream("x").BitsAt(off) != g2.Stream("x").BitsAt(off) {
+		t.Errorf("NewSequenceGroupFromSeed differs from NewSequenceGroup")
+	}
+	if m1.Stream(2).BitsAt(off) != m2.Stream(2).BitsAt(off) {
+		t.Errorf("NewMultiStreamFromSeed differs from NewMultiStream")
+	}
+}
+
+func Test_SequenceFromUUID(t *testing.T) {
+	// a version 1 UUID and its successor, which differ in one bit.
+	u1 := [16]byte{0x6b, 0xa7, 0xb8, 0x10, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+	u2 := u1
+	u2[15]++
+	a, b, c := NewSequenceFromUUID(u1), NewSequenceFromUUID(u1), NewSequenceFromUUID(u2)
+	for i := uint64(0); i < 100; i++ {
+		off := OffsetFor(SequenceDefault, 0, 0, i)
+		x, y, z := a.BitsAt(off), b.BitsAt(off), c.BitsAt(off)
+		if x != y {
+			t.Fatalf("offset %s: same UUID gave %s and %s", off, x, y)
+		}
+		if x == z {
+			t.Fatalf("offset %s: different UUIDs both gave %s", off, x)
+		}
+	}
+	if a.Uint64() != b.Uint64() {
+		t.Fatalf("same UUID: Uint64 differs")
+	}
+}
+
+func Test_SequenceFromHex(t *testing.T) {
+	for _, c := range []struct {
+		hex  string
+		seed int64
+	}{
+		{"00000000000000000000000000000000", 0},
+		{"01000000000000000000000000000000", 1},
+		{"efcdab8967452301" + "0000000000000000", 0x0123456789abcdef},
+		{"EFCDAB8967452301" + "0000000000000000", 0x0123456789abcdef},
+	} {
+		s, err := NewSequenceFromHex(c.hex)
+		if err != nil {
+			t.Fatalf("%q: %v", c.hex, err)
+		}
+		plain := NewSequence(c.seed)
+		for i := 0; i < 100; i++ {
+			if got, exp := s.Uint64(), plain.Uint64(); got != exp {
+				t.Fatalf("%q: value %d: expected %#x, got %#x", c.hex, i, exp, got)
+			}
+		}
+		off := OffsetFor(SequenceZipfU, 3, 0, 17)
+		if got, exp := s.BitsAt(off), plain.BitsAt(off); got != exp {
+			t.Fatalf("%q: BitsAt(%s): expected %s, got %s", c.hex, off, exp, got)
+		}
+	}
+	for _, bad := range []string{"", "0", "0000000000000000000000000000000", "000000000000000000000000000000000", "0000000000000000000000000000000g", "0x000000000000000000000000000000"} {
+		if _, err := NewSequenceFromHex(bad); err == nil {
+			t.Errorf("%q: expected error", bad)
+		}
+	}
+}
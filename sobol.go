@@ -0,0 +1,151 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import "fmt"
+
+// sobolMaxDimension is the number of dimensions we have direction
+// numbers for.
+const sobolMaxDimension = 40
+
+// sobolParams are the parameters for generating direction numbers for
+// one dimension: the degree s and interior coefficients a of a primitive
+// polynomial over GF(2), and the initial direction numbers m.
+type sobolParams struct {
+	s, a uint
+	m    []uint64
+}
+
+// sobolDirections holds parameters for dimensions 2 through 40, from the
+// new-joe-kuo-6.21201 table of:
+//
+// "Constructing Sobol sequences with better two-dimensional projections"
+// S. Joe, F. Y. Kuo [2008]
+// https://web.maths.unsw.edu.au/~fkuo/sobol/
+//
+// Dimension 1 is the van der Corput sequence in base 2, and needs no
+// parameters.
+var sobolDirections = [sobolMaxDimension - 1]sobolParams{
+	{1, 0, []uint64{1}},
+	{2, 1, []uint64{1, 3}},
+	{3, 1, []uint64{1, 3, 1}},
+	{3, 2, []uint64{1, 1, 1}},
+	{4, 1, []uint64{1, 1, 3, 3}},
+	{4, 4, []uint64{1, 3, 5, 13}},
+	{5, 2, []uint64{1, 1, 5, 5, 17}},
+	{5, 4, []uint64{1, 1, 5, 5, 5}},
+	{5, 7, []uint64{1, 1, 7, 11, 19}},
+	{5, 11, []uint64{1, 1, 5, 1, 1}},
+	{5, 13, []uint64{1, 1, 1, 3, 11}},
+	{5, 14, []uint64{1, 3, 5, 5, 31}},
+	{6, 1, []uint64{1, 3, 3, 9, 7, 49}},
+	{6, 13, []uint64{1, 1, 1, 15, 21, 21}},
+	{6, 16, []uint64{1, 3, 1, 13, 27, 49}},
+	{6, 19, []uint64{1, 1, 1, 15, 7, 5}},
+	{6, 22, []uint64{1, 3, 1, 15, 13, 25}},
+	{6, 25, []uint64{1, 1, 5, 5, 19, 61}},
+	{7, 1, []uint64{1, 3, 7, 11, 23, 15, 103}},
+	{7, 4, []uint64{1, 3, 7, 13, 13, 15, 69}},
+	{7, 7, []uint64{1, 1, 3, 13, 7, 35, 63}},
+	{7, 8, []uint64{1, 3, 5, 9, 1, 25, 53}},
+	{7, 14, []uint64{1, 3, 1, 13, 9, 35, 107}},
+	{7, 19, []uint64{1, 3, 1, 5, 27, 61, 31}},
+	{7, 21, []uint64{1, 1, 5, 11, 19, 41, 61}},
+	{7, 28, []uint64{1, 3, 5, 3, 3, 13, 69}},
+	{7, 31, []uint64{1, 1, 7, 13, 1, 19, 1}},
+	{7, 32, []uint64{1, 3, 7, 5, 13, 19, 59}},
+	{7, 37, []uint64{1, 1, 3, 9, 25, 29, 41}},
+	{7, 41, []uint64{1, 3, 5, 13, 23, 1, 55}},
+	{7, 42, []uint64{1, 3, 7, 3, 13, 59, 17}},
+	{7, 50, []uint64{1, 3, 1, 3, 5, 53, 69}},
+	{7, 55, []uint64{1, 1, 5, 5, 23, 33, 13}},
+	{7, 56, []uint64{1, 1, 7, 7, 1, 61, 123}},
+	{7, 59, []uint64{1, 1, 7, 9, 13, 61, 49}},
+	{7, 62, []uint64{1, 3, 3, 5, 3, 55, 33}},
+	{8, 14, []uint64{1, 3, 1, 15, 31, 13, 49, 245}},
+	{8, 21, []uint64{1, 3, 5, 15, 31, 59, 63, 97}},
+	{8, 22, []uint64{1, 3, 1, 11, 11, 11, 77, 249}},
+}
+
+// Sobol produces points of a Sobol sequence, a low-discrepancy sequence
+// in [0,1)^d widely used for quasi-Monte Carlo integration, such as in
+// financial simulation. For any k, the first 2^k points, in any one
+// dimension, have exactly one value in each interval [j/2^k, (j+1)/2^k).
+//
+// A Sobol created with a Sequence is scrambled with a random digital
+// shift in each dimension, which preserves those properties while giving
+// independent randomized copies of the sequence for different seeds.
+type Sobol struct {
+	dims  [][64]uint64 // direction numbers, as 64-bit binary fractions
+	shift []uint64     // digital shift for each dimension
+}
+
+// NewSobol returns a Sobol which produces points with the given number
+// of dimensions, from 1 to 40. If src is nil, the sequence is
+// unscrambled; otherwise, seed and src determine the scrambling.
+func NewSobol(dimension int, seed uint32, src Sequence) (*Sobol, error) {
+	if dimension < 1 || dimension > sobolMaxDimension {
+		return nil, fmt.Errorf("Sobol dimension must be in [1,%d], got %d", sobolMaxDimension, dimension)
+	}
+	s := &Sobol{dims: make([][64]uint64, dimension), shift: make([]uint64, dimension)}
+	for k := range s.dims[0] {
+		s.dims[0][k] = 1 << (63 - uint(k))
+	}
+	for d := 1; d < dimension; d++ {
+		p := sobolDirections[d-1]
+		v := &s.dims[d]
+		for k := uint(0); k < 64; k++ {
+			if k < p.s {
+				v[k] = p.m[k] << (63 - k)
+				continue
+			}
+			v[k] = v[k-p.s] ^ (v[k-p.s] >> p.s)
+			for i := uint(1); i < p.s; i++ {
+				if (p.a>>(p.s-1-i))&1 != 0 {
+					v[k] ^= v[k-i]
+				}
+			}
+		}
+	}
+	if src != nil {
+		for d := range s.shift {
+			s.shift[d] = src.BitsAt(OffsetFor(SequenceSobol, seed, 0, uint64(d))).Lo
+		}
+	}
+	return s, nil
+}
+
+// Dimension returns the number of dimensions of s's points.
+func (s *Sobol) Dimension() int {
+	return len(s.dims)
+}
+
+// Nth returns the point for the given index, with each coordinate in
+// [0,1). Points are in Gray code order, which is the usual order for
+// Sobol sequences.
+func (s *Sobol) Nth(index uint64) []float64 {
+	gray := index ^ (index >> 1)
+	out := make([]float64, len(s.dims))
+	for d := range s.dims {
+		x := s.shift[d]
+		for k, g := 0, gray; g != 0; k, g = k+1, g>>1 {
+			if g&1 != 0 {
+				x ^= s.dims[d][k]
+			}
+		}
+		out[d] = float64(x>>11) / (1 << 53)
+	}
+	return out
+}
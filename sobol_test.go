@@ -0,0 +1,144 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"testing"
+)
+
+// Test_SobolPolynomials checks that each embedded polynomial is
+// primitive: x has order exactly 2^s-1 modulo the polynomial.
+func Test_SobolPolynomials(t *testing.T) {
+	for i, p := range sobolDirections {
+		dim := i + 2
+		// the polynomial x^s + a_1 x^(s-1) + ... + a_(s-1) x + 1, as bits.
+		poly := uint(1)<<p.s | p.a<<1 | 1
+		period := uint(1)<<p.s - 1
+		x := uint(1)
+		for k := uint(1); k <= period; k++ {
+			x <<= 1
+			if x&(1<<p.s) != 0 {
+				x ^= poly
+			}
+			if x == 1 && k != period {
+				t.Errorf("dimension %d: polynomial %b has order %d, not %d", dim, poly, k, period)
+				break
+			}
+			if k == period && x != 1 {
+				t.Errorf("dimension %d: polynomial %b is not primitive", dim, poly)
+			}
+		}
+		if uint(len(p.m)) != p.s {
+			t.Errorf("dimension %d: need %d initial direction numbers, have %d", dim, p.s, len(p.m))
+		}
+		for k, m := range p.m {
+			if m&1 == 0 || m >= 1<<uint(k+1) {
+				t.Errorf("dimension %d: direction number m[%d] = %d must be odd and less than %d", dim, k+1, m, 1<<uint(k+1))
+			}
+		}
+	}
+}
+
+func Test_SobolKnownValues(t *testing.T) {
+	s, err := NewSobol(2, 0, nil)
+	if err != nil {
+		t.Fatalf("creating Sobol: %v", err)
+	}
+	exp := [][2]float64{
+		{0, 0}, {0.5, 0.5}, {0.75, 0.25}, {0.25, 0.75},
+		{0.375, 0.375}, {0.875, 0.875}, {0.625, 0.125}, {0.125, 0.625},
+	}
+	for i, e := range exp {
+		got := s.Nth(uint64(i))
+		if got[0] != e[0] || got[1] != e[1] {
+			t.Errorf("index %d: expected %v, got %v", i, e, got)
+		}
+	}
+	for _, d := range []int{0, -1, 41} {
+		if _, err := NewSobol(d, 0, nil); err == nil {
+			t.Errorf("dimension %d: expected error", d)
+		}
+	}
+}
+
+func Test_SobolStratified(t *testing.T) {
+	const k = 10
+	for _, src := range []Sequence{nil, NewSequence(0)} {
+		s, err := NewSobol(sobolMaxDimension, 3, src)
+		if err != nil {
+			t.Fatalf("creating Sobol: %v", err)
+		}
+		seen := make([][]bool, s.Dimension())
+		for d := range seen {
+			seen[d] = make([]bool, 1<<k)
+		}
+		for i := uint64(0); i < 1<<k; i++ {
+			for d, x := range s.Nth(i) {
+				if x < 0 || x >= 1 {
+					t.Fatalf("index %d, dimension %d: value %g out of range", i, d+1, x)
+				}
+				cell := int(x * (1 << k))
+				if seen[d][cell] {
+					t.Fatalf("index %d, dimension %d: interval %d already covered", i, d+1, cell)
+				}
+				seen[d][cell] = true
+			}
+		}
+	}
+}
+
+// starDiscrepancy approximates the star discrepancy of 2D points: the
+// largest difference between the fraction of points in a box [0,x)x[0,y)
+// and the area of that box, checking boxes with corners on a grid.
+func starDiscrepancy(points [][]float64) float64 {
+	const grid = 64
+	worst := 0.0
+	n := float64(len(points))
+	for i := 1; i <= grid; i++ {
+		x := float64(i) / grid
+		for j := 1; j <= grid; j++ {
+			y := float64(j) / grid
+			count := 0
+			for _, p := range points {
+				if p[0] < x && p[1] < y {
+					count++
+				}
+			}
+			worst = math.Max(worst, math.Abs(float64(count)/n-x*y))
+		}
+	}
+	return worst
+}
+
+func Test_SobolDiscrepancy(t *testing.T) {
+	const n = 1000
+	s, err := NewSobol(2, 0, NewSequence(0))
+	if err != nil {
+		t.Fatalf("creating Sobol: %v", err)
+	}
+	src := NewSequence(0)
+	sobol := make([][]float64, n)
+	random := make([][]float64, n)
+	for i := range sobol {
+		sobol[i] = s.Nth(uint64(i))
+		bits := src.BitsAt(OffsetFor(SequenceUser1, 0, 0, uint64(i)))
+		random[i] = []float64{unitFloat64(bits.Lo), unitFloat64(bits.Hi)}
+	}
+	ds, dr := starDiscrepancy(sobol), starDiscrepancy(random)
+	if ds >= dr {
+		t.Errorf("Sobol discrepancy %g not lower than random %g", ds, dr)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import "fmt"
+
+// SparsePermutation is a permutation of [0,max) for cases where max is
+// very large, but only a comparatively small number of positions will
+// ever be looked up. A Permutation over 10^12 values needs around 240
+// rounds per lookup; SparsePermutation computes each value once, the
+// first time its position is requested, and remembers it. It also
+// remembers which position produced each value, so values that have
+// been seen can be mapped back to their positions.
+//
+// The underlying Permutation is a bijection, so distinct positions never
+// produce the same value. SparsePermutation verifies this for every
+// value it stores, and panics if it ever finds a collision, since that
+// would indicate a bug in Permutation.
+//
+// Memory use is proportional to the number of distinct positions
+// queried.
+type SparsePermutation struct {
+	perm      *Permutation
+	values    map[int64]int64 // position -> value
+	positions map[int64]int64 // value -> position
+}
+
+// NewSparsePermutation creates a SparsePermutation which generates values
+// in [0,max), using the same permutation NewPermutation would produce
+// for the given max, seed, and Sequence.
+func NewSparsePermutation(max int64, seed uint32, src Sequence) (*SparsePermutation, error) {
+	p, err := NewPermutation(max, seed, src)
+	if err != nil {
+		return nil, err
+	}
+	return &SparsePermutation{
+		perm:      p,
+		values:    make(map[int64]int64),
+		positions: make(map[int64]int64),
+	}, nil
+}
+
+// Nth returns the value at position n. As with Permutation.Nth, negative
+// positions count from the end.
+func (s *SparsePermutation) Nth(n int64) int64 {
+	if n < 0 {
+		n = s.perm.max + (n % s.perm.max)
+	}
+	n %= s.perm.max
+	if v, ok := s.values[n]; ok {
+		return v
+	}
+	v := s.perm.permute(n)
+	if prev, ok := s.positions[v]; ok {
+		panic(fmt.Sprintf("sparse permutation collision: positions %d and %d both yield %d", prev, n, v))
+	}
+	s.values[n] = v
+	s.positions[v] = n
+	return v
+}
+
+// Position returns the position which produced value, if that position
+// has previously been looked up.
+func (s *SparsePermutation) Position(value int64) (position int64, ok bool) {
+	position, ok = s.positions[value]
+	return position, ok
+}
+
+// Len returns the number of distinct positions looked up so far.
+func (s *SparsePermutation) Len() int {
+	return len(s.values)
+}
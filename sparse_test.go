@@ -0,0 +1,66 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"testing"
+)
+
+func Test_SparsePermutation(t *testing.T) {
+	const max = 1000000000000
+	queries := int64(1000000)
+	if testing.Short() {
+		queries = 10000
+	}
+	s, err := NewSparsePermutation(max, 0, NewSequence(0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p := PermutationOrBust(max, 0, "", t)
+	// spread the queries out across the domain.
+	stride := int64(max / queries)
+	seen := make(map[int64]struct{}, queries)
+	for i := int64(0); i < queries; i++ {
+		pos := i * stride
+		v := s.Nth(pos)
+		if v < 0 || v >= max {
+			t.Fatalf("position %d: out-of-range value %d", pos, v)
+		}
+		if _, ok := seen[v]; ok {
+			t.Fatalf("position %d: duplicate value %d", pos, v)
+		}
+		seen[v] = struct{}{}
+		// spot-check against a plain Permutation, and the reverse
+		// mapping.
+		if i%1000 == 0 {
+			if exp := p.Nth(pos); exp != v {
+				t.Fatalf("position %d: permutation gave %d, sparse permutation gave %d", pos, exp, v)
+			}
+			if back, ok := s.Position(v); !ok || back != pos {
+				t.Fatalf("value %d: expected position %d, got %d (ok %t)", v, pos, back, ok)
+			}
+		}
+	}
+	if int64(s.Len()) != queries {
+		t.Fatalf("expected %d cached values, got %d", queries, s.Len())
+	}
+	// repeat queries come from the cache, and don't grow it.
+	if v1, v2 := s.Nth(stride), s.Nth(stride); v1 != v2 || int64(s.Len()) != queries {
+		t.Fatalf("repeat query: got %d then %d, len %d", v1, v2, s.Len())
+	}
+	if _, ok := s.Position(-1); ok {
+		t.Fatalf("found position for impossible value")
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// SparseMatrix is a random sparse boolean matrix, such as the adjacency
+// matrix of a graph, in which each entry is set independently with a
+// given probability. Entries are computed on demand; row i is the same
+// as the decisions BernoulliBatch(density, seed, i*cols, cols, src)
+// would produce, so computing a row costs time proportional to the
+// number of columns, regardless of the number of rows.
+type SparseMatrix struct {
+	src        Sequence
+	seed       uint32
+	rows, cols int64
+	density    uint64 // in units of 1/bernoulliScale
+	nnz        int64  // computed on demand; -1 if not yet known
+}
+
+// NewSparseMatrix creates a rows-by-cols SparseMatrix with the given
+// density in [0,1]. There must be no more than 2^64 entries in total.
+func NewSparseMatrix(rows, cols int64, density float64, seed uint32, src Sequence) (*SparseMatrix, error) {
+	if rows < 1 || cols < 1 {
+		return nil, fmt.Errorf("need at least one row and column, got %dx%d", rows, cols)
+	}
+	if hi, _ := bits.Mul64(uint64(rows), uint64(cols)); hi != 0 {
+		return nil, fmt.Errorf("%dx%d matrix has more than 2^64 entries", rows, cols)
+	}
+	if !(density >= 0 && density <= 1) {
+		return nil, fmt.Errorf("need density in [0,1] (got %g) for sparse matrix", density)
+	}
+	if src == nil {
+		return nil, errors.New("need a usable PRNG apophenia.Sequence")
+	}
+	return &SparseMatrix{src: src, seed: seed, rows: rows, cols: cols, density: bernoulliDensity(density), nnz: -1}, nil
+}
+
+// Dims returns the number of rows and columns.
+func (m *SparseMatrix) Dims() (rows, cols int64) {
+	return m.rows, m.cols
+}
+
+// Row returns the columns of the set entries in row i, in order. It
+// panics if i is out of range.
+func (m *SparseMatrix) Row(i int64) []int64 {
+	return m.appendRow(nil, i)
+}
+
+func (m *SparseMatrix) appendRow(out []int64, i int64) []int64 {
+	if i < 0 || i >= m.rows {
+		panic(fmt.Sprintf("row %d out of range [0,%d)", i, m.rows))
+	}
+	w := Weighted{src: m.src}
+	start := uint64(i) * uint64(m.cols)
+	end := start + uint64(m.cols)
+	// Each batch of 128 decisions starts at a multiple of 128, which
+	// generally isn't the start of the row, so mask off the bits
+	// outside the row. The loop counts batches, rather than comparing
+	// batch to end, because for a row ending within 128 of 2^64, batch
+	// would wrap around to 0 and never reach end.
+	first, last := start>>7, (end-1)>>7
+	for b := first; b <= last; b++ {
+		batch := b << 7
+		set := w.Bits(OffsetFor(SequenceBernoulli, m.seed, 0, batch), m.density, bernoulliScale)
+		for k, word := range [2]uint64{set.Lo, set.Hi} {
+			for ; word != 0; word &= word - 1 {
+				pos := batch + 64*uint64(k) + uint64(bits.TrailingZeros64(word))
+				if pos >= start && pos < end {
+					out = append(out, int64(pos-start))
+				}
+			}
+		}
+	}
+	return out
+}
+
+// Nnz returns the number of set entries in the matrix. The first call
+// computes every row, so it takes time proportional to rows*cols; the
+// result is remembered.
+func (m *SparseMatrix) Nnz() int64 {
+	if m.nnz >= 0 {
+		return m.nnz
+	}
+	var nnz int64
+	var row []int64
+	for i := int64(0); i < m.rows; i++ {
+		row = m.appendRow(row[:0], i)
+		nnz += int64(len(row))
+	}
+	m.nnz = nnz
+	return nnz
+}
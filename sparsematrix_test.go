@@ -0,0 +1,93 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_SparseMatrix(t *testing.T) {
+	src := NewSequence(0)
+	for _, density := range []float64{0.01, 0.1, 0.5} {
+		m, err := NewSparseMatrix(1000, 1000, density, 2, src)
+		if err != nil {
+			t.Fatalf("creating matrix: %v", err)
+		}
+		got := float64(m.Nnz()) / 1e6
+		if math.Abs(got-density) > density*0.05 {
+			t.Errorf("density %g: got %g", density, got)
+		}
+	}
+	// rows match the corresponding Bernoulli decisions.
+	m, _ := NewSparseMatrix(50, 300, 0.2, 5, src)
+	for i := int64(0); i < 50; i++ {
+		row := m.Row(i)
+		var exp []int64
+		for j, b := range BernoulliBatch(0.2, 5, uint64(i)*300, 300, src) {
+			if b {
+				exp = append(exp, int64(j))
+			}
+		}
+		if len(row) != len(exp) {
+			t.Fatalf("row %d: expected %d entries, got %d", i, len(exp), len(row))
+		}
+		for k := range row {
+			if row[k] != exp[k] {
+				t.Fatalf("row %d: entry %d is column %d, expected %d", i, k, row[k], exp[k])
+			}
+		}
+	}
+	// with 2^40 rows, anything proportional to rows*cols would never
+	// finish.
+	big, err := NewSparseMatrix(1<<40, 1000, 0.1, 0, src)
+	if err != nil {
+		t.Fatalf("creating large matrix: %v", err)
+	}
+	if n := len(big.Row(1<<40 - 1)); n < 50 || n > 150 {
+		t.Errorf("last row of large matrix: expected about 100 entries, got %d", n)
+	}
+	// the last row ends within 128 of 2^64, where the last batch's
+	// successor wraps around.
+	edge, err := NewSparseMatrix(1<<62-1, 4, 0.1, 0, src)
+	if err != nil {
+		t.Fatalf("creating edge matrix: %v", err)
+	}
+	for _, i := range []int64{1<<62 - 2, 1<<62 - 33, 1<<62 - 32} {
+		row := edge.Row(i)
+		var exp []int64
+		for j, b := range BernoulliBatch(0.1, 0, uint64(i)*4, 4, src) {
+			if b {
+				exp = append(exp, int64(j))
+			}
+		}
+		if len(row) != len(exp) {
+			t.Fatalf("edge matrix row %d: expected %v, got %v", i, exp, row)
+		}
+		for k := range row {
+			if row[k] != exp[k] {
+				t.Fatalf("edge matrix row %d: expected %v, got %v", i, exp, row)
+			}
+		}
+	}
+	for _, c := range []struct {
+		rows, cols int64
+		density    float64
+	}{{0, 10, 0.1}, {10, 0, 0.1}, {1 << 40, 1 << 30, 0.1}, {10, 10, -0.1}, {10, 10, 1.1}} {
+		if _, err := NewSparseMatrix(c.rows, c.cols, c.density, 0, src); err == nil {
+			t.Errorf("%dx%d, density %g: expected error", c.rows, c.cols, c.density)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// StratifiedSampler divides [0,n) into a number of strata, contiguous
+// ranges of as nearly equal size as possible, and draws values uniformly
+// from within a given stratum. Stratum s covers [s*n/strata,
+// (s+1)*n/strata), rounding down, so the strata cover [0,n) exactly, and
+// differ in size by at most one.
+type StratifiedSampler struct {
+	src       Sequence
+	seed      uint32
+	n, strata uint64
+}
+
+// StratifiedMaxStrata is the most strata a StratifiedSampler can have.
+// Each stratum uses its own iteration of the SequenceStratified offsets,
+// and OffsetFor has 24 bits of iteration.
+const StratifiedMaxStrata = 1 << 24
+
+// NewStratifiedSampler returns a StratifiedSampler dividing [0,n) into
+// the given number of strata, which must be at least 1, and at most n
+// and StratifiedMaxStrata.
+func NewStratifiedSampler(n, strata uint64, seed uint32, src Sequence) (*StratifiedSampler, error) {
+	if strata < 1 || strata > n {
+		return nil, fmt.Errorf("need between 1 and n (%d) strata, got %d", n, strata)
+	}
+	if strata > StratifiedMaxStrata {
+		return nil, fmt.Errorf("%d strata too many, limit is %d", strata, StratifiedMaxStrata)
+	}
+	if src == nil {
+		return nil, fmt.Errorf("need a usable PRNG apophenia.Sequence")
+	}
+	return &StratifiedSampler{src: src, seed: seed, n: n, strata: strata}, nil
+}
+
+// Bounds returns the range [lo, hi) covered by a stratum.
+func (s *StratifiedSampler) Bounds(stratum uint64) (lo, hi uint64) {
+	return s.boundary(stratum), s.boundary(stratum + 1)
+}
+
+// boundary returns stratum*n/strata without overflowing.
+func (s *StratifiedSampler) boundary(stratum uint64) uint64 {
+	hi, lo := bits.Mul64(stratum, s.n)
+	q, _ := bits.Div64(hi, lo, s.strata)
+	return q
+}
+
+// Nth returns a value drawn uniformly from the given stratum, for the
+// given index. It panics if stratum is out of range.
+func (s *StratifiedSampler) Nth(stratum, index uint64) uint64 {
+	if stratum >= s.strata {
+		panic(fmt.Sprintf("stratum %d out of range [0,%d)", stratum, s.strata))
+	}
+	lo, hi := s.Bounds(stratum)
+	x, _ := bits.Mul64(s.src.BitsAt(OffsetFor(SequenceStratified, s.seed, uint32(stratum), index)).Lo, hi-lo)
+	return lo + x
+}
@@ -0,0 +1,78 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math/bits"
+	"testing"
+)
+
+func Test_StratifiedSampler(t *testing.T) {
+	for _, c := range []struct{ n, strata uint64 }{{100, 10}, {103, 10}, {7, 7}, {1000, 1}, {^uint64(0), 3}} {
+		s, err := NewStratifiedSampler(c.n, c.strata, 0, NewSequence(0))
+		if err != nil {
+			t.Fatalf("creating sampler: %v", err)
+		}
+		// strata are contiguous, non-empty, and cover [0,n).
+		next := uint64(0)
+		for stratum := uint64(0); stratum < c.strata; stratum++ {
+			lo, hi := s.Bounds(stratum)
+			if lo != next || hi <= lo {
+				t.Fatalf("n %d, %d strata: stratum %d covers [%d,%d), expected to start at %d",
+					c.n, c.strata, stratum, lo, hi, next)
+			}
+			next = hi
+			for i := uint64(0); i < 100; i++ {
+				if x := s.Nth(stratum, i); x < lo || x >= hi {
+					t.Fatalf("n %d, %d strata: stratum %d produced %d, outside [%d,%d)",
+						c.n, c.strata, stratum, x, lo, hi)
+				}
+			}
+		}
+		if next != c.n {
+			t.Fatalf("n %d, %d strata: strata end at %d", c.n, c.strata, next)
+		}
+	}
+	// every value in a small range gets produced.
+	s, _ := NewStratifiedSampler(20, 4, 0, NewSequence(0))
+	seen := make(map[uint64]bool)
+	for stratum := uint64(0); stratum < 4; stratum++ {
+		for i := uint64(0); i < 200; i++ {
+			seen[s.Nth(stratum, i)] = true
+		}
+	}
+	if len(seen) != 20 {
+		t.Errorf("expected all 20 values, got %d", len(seen))
+	}
+	// each stratum uses its own iteration of the SequenceStratified
+	// offsets, up to the last one allowed.
+	src := NewSequence(0)
+	big, err := NewStratifiedSampler(^uint64(0), StratifiedMaxStrata, 0, src)
+	if err != nil {
+		t.Fatalf("creating sampler with %d strata: %v", StratifiedMaxStrata, err)
+	}
+	for _, stratum := range []uint64{0, 1, StratifiedMaxStrata - 1} {
+		lo, hi := big.Bounds(stratum)
+		x, _ := bits.Mul64(src.BitsAt(OffsetFor(SequenceStratified, 0, uint32(stratum), 5)).Lo, hi-lo)
+		if got := big.Nth(stratum, 5); got != lo+x {
+			t.Errorf("stratum %d: expected %d, got %d", stratum, lo+x, got)
+		}
+	}
+	for _, c := range []struct{ n, strata uint64 }{{10, 0}, {10, 11}, {0, 0}, {1 << 30, StratifiedMaxStrata + 1}} {
+		if _, err := NewStratifiedSampler(c.n, c.strata, 0, NewSequence(0)); err == nil {
+			t.Errorf("n %d, %d strata: expected error", c.n, c.strata)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// RandomTree is a random tree on nodes [0,n), chosen uniformly from all
+// n^(n-2) labeled trees by generating a random Prüfer sequence and
+// decoding it. The tree is rooted at node n-1.
+type RandomTree struct {
+	parent     []int64
+	childStart []int64 // children of v are children[childStart[v]:childStart[v+1]]
+	children   []int64
+}
+
+// NewRandomTree returns a RandomTree with n nodes, determined by seed
+// and src.
+func NewRandomTree(n int64, seed uint32, src Sequence) (*RandomTree, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("need at least one node for random tree, got %d", n)
+	}
+	if src == nil {
+		return nil, fmt.Errorf("need a usable PRNG apophenia.Sequence")
+	}
+	prufer := make([]int64, 0, n)
+	for i := int64(0); i < n-2; i++ {
+		x, _ := bits.Mul64(src.BitsAt(OffsetFor(SequenceRandomTree, seed, 0, uint64(i))).Lo, uint64(n))
+		prufer = append(prufer, int64(x))
+	}
+	t := &RandomTree{parent: make([]int64, n)}
+	// Linear-time decoding: each step removes the smallest leaf, whose
+	// neighbor at that point is its parent, since node n-1 is never
+	// removed.
+	degree := make([]int64, n)
+	for i := range degree {
+		degree[i] = 1
+	}
+	for _, x := range prufer {
+		degree[x]++
+	}
+	ptr := int64(0)
+	for degree[ptr] != 1 {
+		ptr++
+	}
+	leaf := ptr
+	for _, x := range prufer {
+		t.parent[leaf] = x
+		degree[x]--
+		if degree[x] == 1 && x < ptr {
+			leaf = x
+		} else {
+			ptr++
+			for degree[ptr] != 1 {
+				ptr++
+			}
+			leaf = ptr
+		}
+	}
+	if n > 1 {
+		t.parent[leaf] = n - 1
+	}
+	t.parent[n-1] = -1
+
+	// Gather the children of each node, in order.
+	t.childStart = make([]int64, n+1)
+	for _, p := range t.parent {
+		if p >= 0 {
+			t.childStart[p+1]++
+		}
+	}
+	for v := int64(1); v <= n; v++ {
+		t.childStart[v] += t.childStart[v-1]
+	}
+	t.children = make([]int64, n-1)
+	next := append([]int64(nil), t.childStart[:n]...)
+	for v, p := range t.parent {
+		if p >= 0 {
+			t.children[next[p]] = int64(v)
+			next[p]++
+		}
+	}
+	return t, nil
+}
+
+// Len returns the number of nodes in the tree.
+func (t *RandomTree) Len() int64 {
+	return int64(len(t.parent))
+}
+
+// Root returns the root of the tree, which is always node n-1.
+func (t *RandomTree) Root() int64 {
+	return int64(len(t.parent)) - 1
+}
+
+// Parent returns the parent of node v, or -1 for the root.
+func (t *RandomTree) Parent(v int64) int64 {
+	return t.parent[v]
+}
+
+// Children returns the children of node v, in increasing order. The
+// slice is shared, and must not be modified.
+func (t *RandomTree) Children(v int64) []int64 {
+	return t.children[t.childStart[v]:t.childStart[v+1]]
+}
@@ -0,0 +1,96 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// checkTree verifies that t is a tree rooted at n-1, and that Parent and
+// Children agree.
+func checkTree(tb testing.TB, tr *RandomTree) {
+	tb.Helper()
+	n := tr.Len()
+	if tr.Parent(tr.Root()) != -1 {
+		tb.Fatalf("root %d has parent %d", tr.Root(), tr.Parent(tr.Root()))
+	}
+	edges := int64(0)
+	for v := int64(0); v < n; v++ {
+		for _, c := range tr.Children(v) {
+			if tr.Parent(c) != v {
+				tb.Fatalf("node %d lists child %d, whose parent is %d", v, c, tr.Parent(c))
+			}
+			edges++
+		}
+		// every node reaches the root within n steps, so there are
+		// no cycles and the tree is connected.
+		steps := int64(0)
+		for x := v; x != tr.Root(); x = tr.Parent(x) {
+			if x < 0 || x >= n || steps > n {
+				tb.Fatalf("node %d does not lead to the root", v)
+			}
+			steps++
+		}
+	}
+	if edges != n-1 {
+		tb.Fatalf("%d nodes, but %d edges", n, edges)
+	}
+}
+
+func Test_RandomTree(t *testing.T) {
+	for _, n := range []int64{1, 2, 3, 10, 1000} {
+		tr, err := NewRandomTree(n, 0, NewSequence(0))
+		if err != nil {
+			t.Fatalf("creating tree: %v", err)
+		}
+		checkTree(t, tr)
+		same, _ := NewRandomTree(n, 0, NewSequence(0))
+		for v := int64(0); v < n; v++ {
+			if same.Parent(v) != tr.Parent(v) {
+				t.Fatalf("n %d: node %d has parent %d, then %d, with the same seed", n, v, tr.Parent(v), same.Parent(v))
+			}
+		}
+	}
+	if _, err := NewRandomTree(0, 0, NewSequence(0)); err == nil {
+		t.Errorf("expected error for empty tree")
+	}
+	if _, err := NewRandomTree(1, 0, nil); err == nil {
+		t.Errorf("expected error for nil Sequence")
+	}
+}
+
+func Test_RandomTreeUniform(t *testing.T) {
+	// There are 4^2 = 16 labeled trees on 4 nodes, which should be
+	// equally likely.
+	const trials = 16000
+	src := NewSequence(0)
+	counts := make(map[string]int)
+	for seed := uint32(0); seed < trials; seed++ {
+		tr, _ := NewRandomTree(4, seed, src)
+		checkTree(t, tr)
+		counts[fmt.Sprint(tr.parent)]++
+	}
+	if len(counts) != 16 {
+		t.Fatalf("expected 16 distinct trees, got %d", len(counts))
+	}
+	exp := float64(trials) / 16
+	for tree, c := range counts {
+		if math.Abs(float64(c)-exp) > 5*math.Sqrt(exp) {
+			t.Errorf("tree %s: expected about %.0f, got %d", tree, exp, c)
+		}
+	}
+}
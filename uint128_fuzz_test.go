@@ -0,0 +1,41 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18
+
+package apophenia
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+func FuzzMulUint64(f *testing.F) {
+	seeds := []uint64{0, 1, math.MaxUint64 / 2, math.MaxUint64, 0xdeadbeef, 0x123456789abcdef, 1 << 32, 1<<63 + 12345}
+	for _, a := range seeds {
+		for _, b := range seeds {
+			f.Add(a, b)
+		}
+	}
+	f.Fuzz(func(t *testing.T, a, b uint64) {
+		got := MulUint64(a, b)
+		// The product of two uint64 always fits in 128 bits, so
+		// there's nothing to truncate.
+		exp := new(big.Int).Mul(new(big.Int).SetUint64(a), new(big.Int).SetUint64(b))
+		if bigFromUint128(got).Cmp(exp) != 0 {
+			t.Fatalf("%d * %d: expected %s, got %s (%s)", a, b, exp, bigFromUint128(got), got)
+		}
+	})
+}
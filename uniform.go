@@ -0,0 +1,61 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"errors"
+	"math/bits"
+)
+
+// Uniform produces values uniformly distributed in [0,max).
+type Uniform struct {
+	src  Sequence
+	seed uint32
+	max  uint64
+}
+
+// NewUniform returns a Uniform which yields values in [0,max) based on
+// the given seed and Sequence.
+func NewUniform(max uint64, seed uint32, src Sequence) (*Uniform, error) {
+	if max == 0 {
+		return nil, errors.New("need max > 0 for Uniform distribution")
+	}
+	if src == nil {
+		return nil, errors.New("need a usable PRNG apophenia.Sequence")
+	}
+	return &Uniform{src: src, seed: seed, max: max}, nil
+}
+
+// NewUniformFromSeed is NewUniform with seed.Uint32() as the seed.
+func NewUniformFromSeed(max uint64, seed Seed, src Sequence) (*Uniform, error) {
+	return NewUniform(max, seed.Uint32(), src)
+}
+
+// Nth returns the value for the given index.
+func (u *Uniform) Nth(index uint64) uint64 {
+	// The high word of the product is within one part in 2^64 of
+	// uniform, without modulo's bias towards low values.
+	hi, _ := bits.Mul64(u.src.BitsAt(OffsetFor(SequenceLinear, u.seed, 0, index)).Lo, u.max)
+	return hi
+}
+
+// Probability returns the probability of the given value: 1/max for
+// values in [0,max), and 0 otherwise.
+func (u *Uniform) Probability(value uint64) float64 {
+	if value >= u.max {
+		return 0
+	}
+	return 1 / float64(u.max)
+}
@@ -0,0 +1,54 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_Uniform(t *testing.T) {
+	const max, samples = 10, 100000
+	u, err := NewUniform(max, 0, NewSequence(0))
+	if err != nil {
+		t.Fatalf("creating uniform: %v", err)
+	}
+	var counts [max]int
+	for i := uint64(0); i < samples; i++ {
+		v := u.Nth(i)
+		if v >= max {
+			t.Fatalf("value %d out of range [0,%d)", v, max)
+		}
+		counts[v]++
+	}
+	exp := float64(samples) / max
+	for v, c := range counts {
+		if math.Abs(float64(c)-exp) > 5*math.Sqrt(exp) {
+			t.Errorf("value %d: expected about %.0f, got %d", v, exp, c)
+		}
+		if p := u.Probability(uint64(v)); p != 1.0/max {
+			t.Errorf("value %d: expected probability %g, got %g", v, 1.0/max, p)
+		}
+	}
+	if p := u.Probability(max); p != 0 {
+		t.Errorf("value %d: expected probability 0, got %g", max, p)
+	}
+	if _, err := NewUniform(0, 0, NewSequence(0)); err == nil {
+		t.Errorf("expected error for max 0")
+	}
+	if _, err := NewUniform(1, 0, nil); err == nil {
+		t.Errorf("expected error for nil Sequence")
+	}
+}
@@ -16,6 +16,7 @@ package apophenia
 
 import (
 	"errors"
+	"math"
 	"math/bits"
 )
 
@@ -31,8 +32,17 @@ import (
 // values, but if the offset provided to Weighted.Bits is over 2^121, there
 // will be overlap between the source values used for those bits, and
 // source values used (for different iterations) for other offsets.
+//
+// Bit, Bits, and BitsFloat take offsets from the caller, so it's up to
+// the caller to keep offsets used for different purposes apart. Since
+// each result uses up to 64 consecutive iterations starting from the
+// offset's, offsets should come from OffsetFor with iteration 0, and with
+// the seed, rather than the iteration, distinguishing different uses.
+// BitsIndexed does this, the way Zipf's Nth does, so callers need only
+// pick a seed.
 type Weighted struct {
-	src Sequence
+	src      Sequence
+	inverted bool // yield the complement of the usual bits
 	// internal result cache
 	lastValue              Uint128
 	lastOffset             Uint128
@@ -68,11 +78,60 @@ func (w *Weighted) Bit(offset Uint128, density uint64, scale uint64) uint64 {
 	return w.lastValue.Bit(bit)
 }
 
+// BitsFloat is Bit with the density given as a probability, rather than
+// as density and scale. The probability is rounded to the nearest
+// multiple of 1/2^53, so 0 (or less) never yields true, and 1 (or more)
+// always does. NaN is treated as 0.
+func (w *Weighted) BitsFloat(offset Uint128, probability float64) bool {
+	var density uint64
+	switch {
+	case probability >= 1:
+		density = 1 << 53
+	case probability > 0:
+		density = uint64(math.Round(probability * (1 << 53)))
+	}
+	return w.Bit(offset, density, 1<<53) != 0
+}
+
+// BitsIndexed returns the bit for index in the sub-sequence selected by
+// seqSeed, set with probability density/scale, without the caller
+// building an offset. It's Bit(OffsetFor(SequenceWeighted, seqSeed, 0,
+// index), density, scale) != 0. Since every index starts at iteration 0
+// of the SequenceWeighted class, different seeds never share source
+// values, however many iterations the scale needs, and different indexes
+// use different bits of them.
+func (w *Weighted) BitsIndexed(seqSeed uint32, index uint64, density uint64, scale uint64) bool {
+	return w.Bit(OffsetFor(SequenceWeighted, seqSeed, 0, index), density, scale) != 0
+}
+
 // Bits returns the 128-bit set of bits including offset. The column portion
 // of offset is right-shifted by 7 to match the offset calculations in Bit(),
 // above. Thus, you get the same values back for each sequence of 128 consecutive
 // offsets.
 func (w *Weighted) Bits(offset Uint128, density uint64, scale uint64) (out Uint128) {
+	out = w.bits(offset, density, scale)
+	if w.inverted {
+		out.Not()
+	}
+	return out
+}
+
+// Invert returns a new Weighted, using the same source, which produces
+// exactly the complement of the bits w produces: for any offset, density,
+// and scale, each bit is set in the inverted Weighted's results exactly
+// when it is clear in w's. Thus, it sets bits with probability
+// 1-(density/scale), and the two together can split a population into
+// two disjoint groups, such as the A and B sides of a test.
+func (w *Weighted) Invert() (*Weighted, error) {
+	inv, err := NewWeighted(w.src)
+	if err != nil {
+		return nil, err
+	}
+	inv.inverted = !w.inverted
+	return inv, nil
+}
+
+func (w *Weighted) bits(offset Uint128, density uint64, scale uint64) (out Uint128) {
 	// magic accommodation for choices made elsewhere.
 	offset.Lo >>= 7
 	if density == scale {
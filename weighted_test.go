@@ -16,6 +16,7 @@ package apophenia
 
 import (
 	"fmt"
+	"math"
 	"testing"
 )
 
@@ -39,3 +40,112 @@ func Benchmark_WeightedDistribution(b *testing.B) {
 	}
 
 }
+
+func Test_WeightedInvert(t *testing.T) {
+	w, err := NewWeighted(NewSequence(0))
+	if err != nil {
+		t.Fatalf("couldn't make weighted: %v", err)
+	}
+	inv, err := w.Invert()
+	if err != nil {
+		t.Fatalf("couldn't invert weighted: %v", err)
+	}
+	back, err := inv.Invert()
+	if err != nil {
+		t.Fatalf("couldn't invert inverted weighted: %v", err)
+	}
+	allOnes := Uint128{Lo: ^uint64(0), Hi: ^uint64(0)}
+	densities := [][2]uint64{{0, 8}, {1, 8}, {3, 8}, {8, 8}, {1, 1 << 20}, {12345, 1 << 16}}
+	for _, d := range densities {
+		for i := uint64(0); i < 64; i++ {
+			off := OffsetFor(SequenceWeighted, 0, 0, i<<7)
+			a, b := w.Bits(off, d[0], d[1]), inv.Bits(off, d[0], d[1])
+			if a.Xor(b); a != allOnes {
+				t.Fatalf("density %d/%d, offset %s: bits and inverted bits overlap or leave gaps: %s",
+					d[0], d[1], off, a)
+			}
+			if a, c := w.Bits(off, d[0], d[1]), back.Bits(off, d[0], d[1]); a != c {
+				t.Fatalf("density %d/%d, offset %s: double inversion gave %s, expected %s",
+					d[0], d[1], off, c, a)
+			}
+			// individual bits too, which go through a cache.
+			for j := uint64(0); j < 128; j += 17 {
+				bitOff := off
+				bitOff.Lo += j
+				if w.Bit(bitOff, d[0], d[1])+inv.Bit(bitOff, d[0], d[1]) != 1 {
+					t.Fatalf("density %d/%d, offset %s: bit and inverted bit agree",
+						d[0], d[1], bitOff)
+				}
+			}
+		}
+	}
+}
+
+func Test_WeightedBitsFloat(t *testing.T) {
+	w, err := NewWeighted(NewSequence(0))
+	if err != nil {
+		t.Fatalf("couldn't make weighted: %v", err)
+	}
+	const n = 100000
+	set := 0
+	for i := uint64(0); i < n; i++ {
+		off := OffsetFor(SequenceWeighted, 0, 0, i)
+		if w.BitsFloat(off, 0) {
+			t.Fatalf("offset %s: probability 0 gave true", off)
+		}
+		if !w.BitsFloat(off, 1) {
+			t.Fatalf("offset %s: probability 1 gave false", off)
+		}
+		if w.BitsFloat(off, 0.5) {
+			set++
+		}
+	}
+	// 5 sigma is about 790.
+	if set < n/2-800 || set > n/2+800 {
+		t.Errorf("probability 0.5: expected about %d set, got %d", n/2, set)
+	}
+	// the same as the equivalent integer density at scale 2^53.
+	for i := uint64(0); i < 1024; i++ {
+		off := OffsetFor(SequenceWeighted, 0, 0, i)
+		if got, exp := w.BitsFloat(off, 0.375), w.Bit(off, 3<<50, 1<<53) != 0; got != exp {
+			t.Fatalf("offset %s: probability 0.375 gave %t, density 3<<50 / 1<<53 gave %t", off, got, exp)
+		}
+	}
+}
+
+func Test_WeightedBitsIndexed(t *testing.T) {
+	src := NewSequence(0)
+	w, _ := NewWeighted(src)
+	manual, _ := NewWeighted(src)
+	const n = 100000
+	for _, c := range []struct {
+		seed           uint32
+		density, scale uint64
+	}{{0, 1, 2}, {3, 3, 8}, {3, 1, 1 << 20}, {7, 0, 16}, {7, 16, 16}} {
+		set := 0
+		for i := uint64(0); i < n; i++ {
+			got := w.BitsIndexed(c.seed, i, c.density, c.scale)
+			exp := manual.Bit(OffsetFor(SequenceWeighted, c.seed, 0, i), c.density, c.scale) != 0
+			if got != exp {
+				t.Fatalf("seed %d, index %d: BitsIndexed %t, manual offset %t", c.seed, i, got, exp)
+			}
+			if got {
+				set++
+			}
+		}
+		p := float64(c.density) / float64(c.scale)
+		if d := math.Abs(float64(set) - p*n); d > 5*math.Sqrt(n*p*(1-p))+0.5 {
+			t.Errorf("seed %d, density %d/%d: %d of %d bits set", c.seed, c.density, c.scale, set, n)
+		}
+	}
+	// different seeds give unrelated bits.
+	same := 0
+	for i := uint64(0); i < n; i++ {
+		if w.BitsIndexed(1, i, 1, 2) == w.BitsIndexed(2, i, 1, 2) {
+			same++
+		}
+	}
+	if d := math.Abs(float64(same) - n/2); d > 5*math.Sqrt(n/4) {
+		t.Errorf("seeds 1 and 2 agree on %d of %d bits", same, n)
+	}
+}
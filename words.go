@@ -0,0 +1,59 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"errors"
+	"strconv"
+)
+
+// WordSequence produces a series of synthetic "words", such as "word_17",
+// with word frequencies following Zipf's law: the word with rank 0 is the
+// most common, rank 1 the next most common, and so on. It's intended for
+// testing text-processing pipelines which care about the statistical
+// shape of their input more than its meaning.
+type WordSequence struct {
+	zipf *Zipf
+}
+
+// NewWordSequence returns a WordSequence drawing from a vocabulary of
+// vocabSize words, using a Zipf distribution with the given q and v (see
+// NewZipf) to select the rank of each word.
+func NewWordSequence(vocabSize uint64, q, v float64, seed uint32, src Sequence) (*WordSequence, error) {
+	if vocabSize == 0 {
+		return nil, errors.New("word sequence needs a non-empty vocabulary")
+	}
+	z, err := NewZipfOpen(q, v, vocabSize, seed, src)
+	if err != nil {
+		return nil, err
+	}
+	return &WordSequence{zipf: z}, nil
+}
+
+// word yields the word for a given rank.
+func word(rank uint64) string {
+	return "word_" + strconv.FormatUint(rank, 10)
+}
+
+// Nth returns the Nth word of the sequence. As with Zipf.Nth, this also
+// sets the position Next counts from.
+func (w *WordSequence) Nth(index uint64) string {
+	return word(w.zipf.Nth(index))
+}
+
+// Next returns the next word of the sequence.
+func (w *WordSequence) Next() string {
+	return word(w.zipf.Next())
+}
@@ -0,0 +1,59 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func Test_WordSequence(t *testing.T) {
+	const samples = 10000
+	w, err := NewWordSequence(1000, 1.5, 1, 0, NewSequence(0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	counts := make(map[string]int)
+	for i := 0; i < samples; i++ {
+		word := w.Next()
+		if !strings.HasPrefix(word, "word_") {
+			t.Fatalf("unexpected word %q", word)
+		}
+		counts[word]++
+	}
+	freqs := make([]int, 0, len(counts))
+	for _, c := range counts {
+		freqs = append(freqs, c)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(freqs)))
+	top := 0
+	for _, c := range freqs[:10] {
+		top += c
+	}
+	if top*2 <= samples {
+		t.Fatalf("top 10 of %d distinct words only account for %d of %d samples",
+			len(freqs), top, samples)
+	}
+	if counts["word_0"] != freqs[0] {
+		t.Fatalf("expected word_0 to be most common (%d), got %d", freqs[0], counts["word_0"])
+	}
+	if got, exp := w.Nth(17), w.Nth(17); got != exp {
+		t.Fatalf("Nth(17) not repeatable: %q vs %q", got, exp)
+	}
+	if _, err := NewWordSequence(0, 1.5, 1, 0, NewSequence(0)); err == nil {
+		t.Fatalf("expected error for empty vocabulary")
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import "fmt"
+
+// XORSequence is a Sequence whose bits are the XOR of the bits of two
+// other Sequences at the same offset. If the two are independent, the
+// result is at least as unpredictable as either, at the cost of
+// computing both. If they're the same, the result is all zeros.
+type XORSequence struct {
+	a, b   Sequence
+	offset Uint128
+}
+
+// NewXORSequence returns a Sequence combining a and b, which must not be
+// nil. Neither should be used by anything else while the XORSequence is
+// in use, because its Seed reseeds both of them.
+func NewXORSequence(a, b Sequence) Sequence {
+	return &XORSequence{a: a, b: b, offset: OffsetFor(SequenceRandSource, 0, 0, 0)}
+}
+
+// Seed reseeds both sequences, a with seed and b with its complement, so
+// they don't end up identical, and resets the position to the start of
+// the SequenceRandSource offsets, where NewSequence's position starts.
+func (s *XORSequence) Seed(seed int64) {
+	s.a.Seed(seed)
+	s.b.Seed(^seed)
+	s.offset = OffsetFor(SequenceRandSource, 0, 0, 0)
+}
+
+// Int63 returns a value in 0..(1<<63)-1.
+func (s *XORSequence) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+// Uint64 returns a value in 0..(1<<64)-1.
+func (s *XORSequence) Uint64() uint64 {
+	out := s.BitsAt(s.offset)
+	s.offset.Inc()
+	return out.Lo
+}
+
+// Seek sets the position used by Uint64 and Int63, returning the previous
+// position.
+func (s *XORSequence) Seek(offset Uint128) (old Uint128) {
+	old, s.offset = s.offset, offset
+	return old
+}
+
+// BitsAt yields the XOR of the two sequences' bits at offset.
+func (s *XORSequence) BitsAt(offset Uint128) Uint128 {
+	out := s.a.BitsAt(offset)
+	out.Xor(s.b.BitsAt(offset))
+	return out
+}
+
+// BitsSliceAt fills dst with the XOR of the two sequences' bits at
+// consecutive offsets from start.
+func (s *XORSequence) BitsSliceAt(start Uint128, dst []Uint128) {
+	s.a.BitsSliceAt(start, dst)
+	for i := range dst {
+		dst[i].Xor(s.b.BitsAt(start))
+		start.Inc()
+	}
+}
+
+// CombinedSequence is a Sequence whose bits are the XOR of the bits of
+// any number of other Sequences at the same offset, generalizing
+// XORSequence. Combining Sequences doesn't extend the offset space,
+// which is still 128 bits, but the result is at least as unpredictable
+// as the best of them, as long as they're independent.
+type CombinedSequence struct {
+	sequences []Sequence
+	offset    Uint128
+}
+
+// NewCombinedSequence returns a Sequence combining sequences, of which
+// there must be at least two, none of them nil. The slice is copied, but
+// the Sequences aren't, so none of them should be used by anything else
+// while the CombinedSequence is in use, because its Seed reseeds all of
+// them.
+func NewCombinedSequence(sequences []Sequence) (Sequence, error) {
+	if len(sequences) < 2 {
+		return nil, fmt.Errorf("need at least 2 sequences to combine, got %d", len(sequences))
+	}
+	for i, s := range sequences {
+		if s == nil {
+			return nil, fmt.Errorf("sequence %d is nil; need a usable PRNG apophenia.Sequence", i)
+		}
+	}
+	return &CombinedSequence{
+		sequences: append([]Sequence(nil), sequences...),
+		offset:    OffsetFor(SequenceRandSource, 0, 0, 0),
+	}, nil
+}
+
+// Seed reseeds each sequence with a different value derived from seed,
+// so they don't end up identical, and resets the position to the start
+// of the SequenceRandSource offsets. The first sequence gets seed
+// itself.
+func (s *CombinedSequence) Seed(seed int64) {
+	for i, seq := range s.sequences {
+		seq.Seed(int64(uint64(seed) + uint64(i)*0x9e3779b97f4a7c15))
+	}
+	s.offset = OffsetFor(SequenceRandSource, 0, 0, 0)
+}
+
+// Int63 returns a value in 0..(1<<63)-1.
+func (s *CombinedSequence) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+// Uint64 returns a value in 0..(1<<64)-1.
+func (s *CombinedSequence) Uint64() uint64 {
+	out := s.BitsAt(s.offset)
+	s.offset.Inc()
+	return out.Lo
+}
+
+// Seek sets the position used by Uint64 and Int63, returning the previous
+// position.
+func (s *CombinedSequence) Seek(offset Uint128) (old Uint128) {
+	old, s.offset = s.offset, offset
+	return old
+}
+
+// BitsAt yields the XOR of all the sequences' bits at offset.
+func (s *CombinedSequence) BitsAt(offset Uint128) Uint128 {
+	out := s.sequences[0].BitsAt(offset)
+	for _, seq := range s.sequences[1:] {
+		out.Xor(seq.BitsAt(offset))
+	}
+	return out
+}
+
+// BitsSliceAt fills dst with the XOR of all the sequences' bits at
+// consecutive offsets from start.
+func (s *CombinedSequence) BitsSliceAt(start Uint128, dst []Uint128) {
+	s.sequences[0].BitsSliceAt(start, dst)
+	for _, seq := range s.sequences[1:] {
+		offset := start
+		for i := range dst {
+			dst[i].Xor(seq.BitsAt(offset))
+			offset.Inc()
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"math/bits"
+	"testing"
+)
+
+// checkSequenceBits does a monobit test, checking that the number of set
+// bits is about half, and a chi-square test on the bytes, of s's output.
+func checkSequenceBits(t *testing.T, name string, s Sequence) {
+	t.Helper()
+	const n = 100000
+	ones := 0
+	var byteCounts [256]int
+	for i := uint64(0); i < n; i++ {
+		v := s.BitsAt(OffsetFor(SequenceDefault, 0, 0, i))
+		ones += bits.OnesCount64(v.Lo) + bits.OnesCount64(v.Hi)
+		for _, word := range [2]uint64{v.Lo, v.Hi} {
+			for j := 0; j < 8; j++ {
+				byteCounts[byte(word>>(8*j))]++
+			}
+		}
+	}
+	if d := math.Abs(float64(ones) - n*64); d > 5*math.Sqrt(n*128/4) {
+		t.Errorf("%s: monobit: %d ones in %d bits", name, ones, n*128)
+	}
+	exp := float64(n*16) / 256
+	chi := 0.0
+	for _, c := range byteCounts {
+		chi += (float64(c) - exp) * (float64(c) - exp) / exp
+	}
+	// 255 degrees of freedom; 350 is about p = 0.0001.
+	if chi > 350 {
+		t.Errorf("%s: byte chi-square %.1f too high", name, chi)
+	}
+}
+
+func Test_XORSequence(t *testing.T) {
+	same := NewXORSequence(NewSequence(1), NewSequence(1))
+	for i := uint64(0); i < 1000; i++ {
+		if got := same.BitsAt(OffsetFor(SequenceDefault, 0, 0, i)); got != (Uint128{}) {
+			t.Fatalf("identical sequences, offset %d: expected zero, got %s", i, got)
+		}
+	}
+	s := NewXORSequence(NewSequence(1), NewSequence(2))
+	checkSequenceBits(t, "XOR", s)
+	// Uint64 starts where NewSequence's does.
+	a, b := NewSequence(1), NewSequence(2)
+	if got, exp := s.Uint64(), a.Uint64()^b.Uint64(); got != exp {
+		t.Errorf("first Uint64: expected %#x, got %#x", exp, got)
+	}
+	// Seed keeps the two sequences distinct, and restarts Uint64.
+	s.Seed(3)
+	if s.Uint64() == 0 && s.Uint64() == 0 {
+		t.Errorf("reseeded XOR sequence produced zeros")
+	}
+	s.Seed(3)
+	a, b = NewSequence(3), NewSequence(^3)
+	if got, exp := s.Uint64(), a.Uint64()^b.Uint64(); got != exp {
+		t.Errorf("first Uint64 after reseeding: expected %#x, got %#x", exp, got)
+	}
+}
+
+func Test_CombinedSequence(t *testing.T) {
+	s1 := NewSequence(1)
+	same, err := NewCombinedSequence([]Sequence{s1, s1})
+	if err != nil {
+		t.Fatalf("creating combined sequence: %v", err)
+	}
+	for i := uint64(0); i < 1000; i++ {
+		if got := same.BitsAt(OffsetFor(SequenceDefault, 0, 0, i)); got != (Uint128{}) {
+			t.Fatalf("same sequence twice, offset %d: expected zero, got %s", i, got)
+		}
+		if got := same.Uint64(); got != 0 {
+			t.Fatalf("same sequence twice, value %d: expected zero, got %#x", i, got)
+		}
+	}
+
+	s2, s3 := NewSequence(2), NewSequence(3)
+	pair, _ := NewCombinedSequence([]Sequence{NewSequence(1), NewSequence(2)})
+	xor := NewXORSequence(NewSequence(1), NewSequence(2))
+	triple, _ := NewCombinedSequence([]Sequence{s1, s2, s3})
+	dst := make([]Uint128, 50)
+	triple.BitsSliceAt(Uint128{Lo: 7}, dst)
+	for i := uint64(0); i < 50; i++ {
+		off := Uint128{Lo: 7 + i}
+		if got, exp := pair.BitsAt(off), xor.BitsAt(off); got != exp {
+			t.Fatalf("offset %s: pair gave %s, XORSequence gave %s", off, got, exp)
+		}
+		exp := s1.BitsAt(off)
+		exp.Xor(s2.BitsAt(off))
+		exp.Xor(s3.BitsAt(off))
+		if got := triple.BitsAt(off); got != exp {
+			t.Fatalf("offset %s: expected %s, got %s", off, exp, got)
+		}
+		if dst[i] != exp {
+			t.Fatalf("BitsSliceAt offset %s: expected %s, got %s", off, exp, dst[i])
+		}
+	}
+	// each alone, and combined, should pass the same tests.
+	checkSequenceBits(t, "seed 1", NewSequence(1))
+	checkSequenceBits(t, "seed 2", NewSequence(2))
+	checkSequenceBits(t, "combined", pair)
+
+	// Uint64 starts where NewSequence's does.
+	if got, exp := pair.Uint64(), NewSequence(1).Uint64()^NewSequence(2).Uint64(); got != exp {
+		t.Errorf("first Uint64: expected %#x, got %#x", exp, got)
+	}
+
+	// Seed makes identically-seeded sequences distinct, and restarts
+	// Uint64.
+	twins, _ := NewCombinedSequence([]Sequence{NewSequence(1), NewSequence(1)})
+	twins.Seed(3)
+	if twins.Uint64() == 0 && twins.Uint64() == 0 {
+		t.Errorf("reseeded combined sequence produced zeros")
+	}
+	twins.Seed(3)
+	if got, exp := twins.Uint64(), NewSequence(3).Uint64()^NewSequence(3+0x9e3779b97f4a7c15-1<<64).Uint64(); got != exp {
+		t.Errorf("first Uint64 after reseeding: expected %#x, got %#x", exp, got)
+	}
+
+	for _, bad := range [][]Sequence{nil, {NewSequence(0)}, {NewSequence(0), nil}} {
+		if _, err := NewCombinedSequence(bad); err == nil {
+			t.Errorf("%d sequences: expected error", len(bad))
+		}
+	}
+}
+
+func BenchmarkXORSequence(b *testing.B) {
+	b.Run("Single", func(b *testing.B) {
+		s := NewSequence(1)
+		for i := 0; i < b.N; i++ {
+			s.BitsAt(Uint128{Lo: uint64(i)})
+		}
+	})
+	b.Run("XOR", func(b *testing.B) {
+		s := NewXORSequence(NewSequence(1), NewSequence(2))
+		for i := 0; i < b.N; i++ {
+			s.BitsAt(Uint128{Lo: uint64(i)})
+		}
+	})
+}
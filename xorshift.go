@@ -0,0 +1,35 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+// XorShift128Plus advances state using the xorshift128+ algorithm
+// (Vigna, "Further scramblings of Marsaglia's xorshift generators"),
+// and returns the next 64-bit output. It's not seekable, and its low
+// bits are comparatively weak, but it's extremely fast and needs no
+// allocation, for use in hot loops which just need some randomness; a
+// good way to seed it is with BitsAt from a Sequence.
+//
+// The state must not be all zeros, or it stays all zeros forever;
+// otherwise, it never becomes all zeros, and has a period of 2^128-1.
+func XorShift128Plus(state *Uint128) uint64 {
+	// state.Lo and state.Hi are s[0] and s[1] in the reference
+	// implementation.
+	s1, s0 := state.Lo, state.Hi
+	result := s0 + s1
+	state.Lo = s0
+	s1 ^= s1 << 23
+	state.Hi = s1 ^ s0 ^ (s1 >> 18) ^ (s0 >> 5)
+	return result
+}
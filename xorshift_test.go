@@ -0,0 +1,67 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"math/bits"
+	"testing"
+)
+
+func Test_XorShift128Plus(t *testing.T) {
+	n := 10000000
+	if testing.Short() {
+		n = 100000
+	}
+	state := NewSequence(0).BitsAt(Uint128{})
+	var bitCounts [64]int
+	var prev uint64
+	repeats := 0
+	for i := 0; i < n; i++ {
+		x := XorShift128Plus(&state)
+		if state == (Uint128{}) {
+			t.Fatalf("step %d: state became zero", i)
+		}
+		if x == prev {
+			repeats++
+		}
+		prev = x
+		for b := x; b != 0; b &= b - 1 {
+			bitCounts[bits.TrailingZeros64(b)]++
+		}
+	}
+	if repeats > 0 {
+		t.Errorf("%d consecutive repeated outputs", repeats)
+	}
+	// each bit position should be set about half the time; 5 sigma.
+	limit := 5 * math.Sqrt(float64(n)/4)
+	for b, c := range bitCounts {
+		if math.Abs(float64(c)-float64(n)/2) > limit {
+			t.Errorf("bit %d set %d times in %d outputs", b, c, n)
+		}
+	}
+	var zero Uint128
+	if XorShift128Plus(&zero) != 0 || zero != (Uint128{}) {
+		t.Errorf("zero state didn't stay zero")
+	}
+	// the start of the sequence from the reference implementation with
+	// s[0] = 1, s[1] = 2.
+	state = Uint128{Lo: 1, Hi: 2}
+	for i, exp := range []uint64{0x3, 0x800025, 0x2040083} {
+		if got := XorShift128Plus(&state); got != exp {
+			t.Errorf("output %d: expected %#x, got %#x", i, exp, got)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"fmt"
+	"math"
+)
+
+// yuleSimonMax is the largest value YuleSimon will produce. Past this,
+// float64 can't distinguish adjacent values of k anyway.
+const yuleSimonMax = 1 << 53
+
+// YuleSimon produces values in [1,∞) following the Yule-Simon
+// distribution with shape parameter rho, under which the probability of
+// a value k is rho * B(k, rho+1), where B is the Beta function. This is
+// a discrete power law with tail exponent rho+1, which arises from
+// preferential attachment processes; word frequencies, city populations,
+// and species per genus all roughly follow it.
+//
+// The mean is rho/(rho-1) for rho > 1, and is infinite otherwise.
+//
+// Values are produced by inverting the cumulative distribution function,
+// 1 - k * B(k, rho+1), so each value uses exactly one value from the
+// underlying Sequence. Values are capped at 2^53.
+type YuleSimon struct {
+	src       Sequence
+	seed      uint32
+	rho       float64
+	lgammaRho float64 // lgamma(rho+1)
+}
+
+// NewYuleSimon returns a YuleSimon with the given shape parameter, which
+// must be positive.
+func NewYuleSimon(rho float64, seed uint32, src Sequence) (*YuleSimon, error) {
+	if math.IsNaN(rho) || math.IsInf(rho, 0) || rho <= 0 {
+		return nil, fmt.Errorf("need finite rho > 0 (got %g) for Yule-Simon distribution", rho)
+	}
+	if src == nil {
+		return nil, fmt.Errorf("need a usable PRNG apophenia.Sequence")
+	}
+	lg, _ := math.Lgamma(rho + 1)
+	return &YuleSimon{src: src, seed: seed, rho: rho, lgammaRho: lg}, nil
+}
+
+// survival returns P(X > k) = k * B(k, rho+1), for k >= 1.
+func (y *YuleSimon) survival(k float64) float64 {
+	a, _ := math.Lgamma(k + 1)
+	b, _ := math.Lgamma(k + y.rho + 1)
+	return math.Exp(a + y.lgammaRho - b)
+}
+
+// Nth returns the value for the given index.
+func (y *YuleSimon) Nth(index uint64) uint64 {
+	bits := y.src.BitsAt(OffsetFor(SequenceYuleSimon, y.seed, 0, index))
+	// we want the smallest k such that P(X > k) < w, with w in (0,1].
+	w := 1 - unitFloat64(bits.Lo)
+	if y.survival(1) < w {
+		return 1
+	}
+	// Find an upper bound by doubling, then binary search. lo always
+	// has survival >= w, hi always has survival < w.
+	lo, hi := uint64(1), uint64(2)
+	for y.survival(float64(hi)) >= w {
+		if hi >= yuleSimonMax {
+			return yuleSimonMax
+		}
+		lo, hi = hi, hi*2
+	}
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		if y.survival(float64(mid)) < w {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return hi
+}
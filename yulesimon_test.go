@@ -0,0 +1,73 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_YuleSimonMean(t *testing.T) {
+	const samples = 100000
+	for _, rho := range []float64{2.5, 3, 5} {
+		y, err := NewYuleSimon(rho, 0, NewSequence(0))
+		if err != nil {
+			t.Fatalf("rho %g: unexpected error: %v", rho, err)
+		}
+		sum := 0.0
+		ones := 0
+		for i := uint64(0); i < samples; i++ {
+			k := y.Nth(i)
+			if k < 1 {
+				t.Fatalf("rho %g: got value %d < 1", rho, k)
+			}
+			if k == 1 {
+				ones++
+			}
+			sum += float64(k)
+		}
+		mean := sum / samples
+		exp := rho / (rho - 1)
+		if math.Abs(mean-exp)/exp > 0.02 {
+			t.Errorf("rho %g: expected mean %g, got %g", rho, exp, mean)
+		}
+		// P(1) = rho/(rho+1)
+		p1 := rho / (rho + 1)
+		if got := float64(ones) / samples; math.Abs(got-p1) > 0.01 {
+			t.Errorf("rho %g: expected P(1) = %g, got %g", rho, p1, got)
+		}
+	}
+}
+
+func Test_YuleSimonInvalid(t *testing.T) {
+	for _, rho := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		if _, err := NewYuleSimon(rho, 0, NewSequence(0)); err == nil {
+			t.Errorf("rho %g: expected error", rho)
+		}
+	}
+	if _, err := NewYuleSimon(1, 0, nil); err == nil {
+		t.Errorf("expected error for nil sequence")
+	}
+	// a heavy tail shouldn't break anything.
+	y, err := NewYuleSimon(0.01, 0, NewSequence(0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := uint64(0); i < 1000; i++ {
+		if k := y.Nth(i); k < 1 || k > yuleSimonMax {
+			t.Fatalf("index %d: value %d out of range", i, k)
+		}
+	}
+}
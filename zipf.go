@@ -15,15 +15,18 @@
 package apophenia
 
 import (
+	"encoding/binary"
 	"fmt"
+	"hash/fnv"
 	"math"
 )
 
 // Zipf produces a series of values following a Zipf distribution.
 // It is initialized with values q, v, and max, and produces values
-// in the range [0,max) such that the probability of a value k is
+// in the range [0,max] such that the probability of a value k is
 // proportional to (v+k) ** -q. The input value v must be >= 1, and
-// q must be > 1.
+// q must be > 1. Note that max itself can be produced; NewZipfOpen
+// creates a Zipf which produces values in [0,max) instead.
 //
 // This is based on the same paper used for the golang stdlib Zipf
 // distribution:
@@ -48,7 +51,9 @@ type Zipf struct {
 	hImaxOneHalf         float64
 	hX0MinusHImaxOneHalf float64 // hX0 is only ever used as hX0 - h(i[max] + 1/2)
 	s                    float64
-	idx                  uint64
+	norm                 float64 // normalizing constant for Probability, computed on demand
+	open                 bool    // created by NewZipfOpen, so max excludes the caller's max
+	idx                  uint64  // index of the value Next will return
 }
 
 // Helper functions from the original algorithm. These are slightly too
@@ -66,8 +71,64 @@ func hInv(z *Zipf, x float64) float64 {
 // max, and with its random source seeded in some way by seed.
 // The sequence of values returned is consistent for a given set
 // of inputs. The seed parameter can select one of multiple sub-sequences
-// of the given sequence.
+// of the given sequence. Values are in [0,max], inclusive, so a max of 0
+// is allowed, and yields a Zipf which always produces 0.
 func NewZipf(q float64, v float64, max uint64, seed uint32, src Sequence) (z *Zipf, err error) {
+	return newZipf(q, v, max, seed, src)
+}
+
+// NewZipfFromSeed is NewZipf with a Seed, using seed.Uint32() as the
+// secondary seed.
+func NewZipfFromSeed(q float64, v float64, max uint64, seed Seed, src Sequence) (*Zipf, error) {
+	return NewZipf(q, v, max, seed.Uint32(), src)
+}
+
+// NewZipfClosed is NewZipf, producing values in [0,max], inclusive. It
+// exists to make the choice explicit alongside NewZipfOpen.
+func NewZipfClosed(q float64, v float64, max uint64, seed uint32, src Sequence) (z *Zipf, err error) {
+	return newZipf(q, v, max, seed, src)
+}
+
+// NewZipfOpen is like NewZipf, but produces values in [0,max), so max
+// itself is never produced, and max must be at least 1. It produces
+// exactly the same values as NewZipfClosed with max-1.
+func NewZipfOpen(q float64, v float64, max uint64, seed uint32, src Sequence) (z *Zipf, err error) {
+	if max == 0 {
+		return nil, fmt.Errorf("need max > 0 for open Zipf distribution")
+	}
+	z, err = newZipf(q, v, max-1, seed, src)
+	if err != nil {
+		return nil, err
+	}
+	z.open = true
+	return z, nil
+}
+
+// NewZipfF is NewZipf with max given as a float64, for when it's
+// computed from something like 0.1 * tableSize. The max is truncated
+// towards zero, so 99.9 becomes 99, and values are then in [0,max],
+// inclusive, exactly as for NewZipf with the truncated max. It must be
+// finite, at least 1, and less than 2^64.
+func NewZipfF(q float64, v float64, max float64, seed uint32, src Sequence) (z *Zipf, err error) {
+	if !(max >= 1 && max < 1<<64) {
+		return nil, fmt.Errorf("need finite max in [1,2^64) (got %g) for Zipf distribution", max)
+	}
+	return newZipf(q, v, uint64(max), seed, src)
+}
+
+// NewZipfString is NewZipf with the seed given as a string, such as the
+// name of the thing the values are for, which is hashed to a uint32 seed
+// with 32-bit FNV-1a. Different strings can hash to the same seed, and
+// would then produce the same values, but for any particular handful of
+// distinct strings that's unlikely. Any string, including "", is a valid
+// seed.
+func NewZipfString(q float64, v float64, max uint64, seedStr string, src Sequence) (z *Zipf, err error) {
+	h := fnv.New32a()
+	h.Write([]byte(seedStr))
+	return newZipf(q, v, max, h.Sum32(), src)
+}
+
+func newZipf(q float64, v float64, max uint64, seed uint32, src Sequence) (z *Zipf, err error) {
 	if math.IsNaN(q) || math.IsNaN(v) {
 		return nil, fmt.Errorf("q (%g) and v (%g) must not be NaN for Zipf distribution", q, v)
 	}
@@ -82,38 +143,82 @@ func NewZipf(q float64, v float64, max uint64, seed uint32, src Sequence) (z *Zi
 	z = &Zipf{
 		q:                q,
 		v:                v,
-		max:              float64(max),
 		seed:             seed,
 		oneMinusQ:        oneMinusQ,
 		oneOverOneMinusQ: oneOverOneMinusQ,
 		idx:              0,
 	}
-	hX0 := h(z, 0.5) - math.Exp(math.Log(v)*-q)
-	z.hImaxOneHalf = h(z, z.max+0.5)
-	z.hX0MinusHImaxOneHalf = hX0 - z.hImaxOneHalf
+	z.setMax(max)
 	z.s = 1 - hInv(z, h(z, 1.5)-math.Exp(math.Log(v+1)*-q))
 	z.src = src
 	return z, nil
 }
 
+// setMax sets max, and the derived values which depend on it.
+func (z *Zipf) setMax(max uint64) {
+	z.max = float64(max)
+	z.norm = 0
+	hX0 := h(z, 0.5) - math.Exp(math.Log(z.v)*-z.q)
+	z.hImaxOneHalf = h(z, z.max+0.5)
+	z.hX0MinusHImaxOneHalf = hX0 - z.hImaxOneHalf
+}
+
+// WithMax returns a new Zipf with the same q, v, seed, and Sequence as z,
+// but with a different max. This is useful for applying parameters
+// fitted to one data set (see FitZipf) to a differently-sized one. The
+// new Zipf starts with Next returning Nth(0), regardless of z's position.
+// If z was created by NewZipfOpen, newMax is likewise exclusive. Either
+// way, newMax must be at least 1.
+func (z *Zipf) WithMax(newMax uint64) (*Zipf, error) {
+	if newMax == 0 {
+		return nil, fmt.Errorf("need max > 0 for resized Zipf distribution")
+	}
+	resized := *z
+	resized.idx = 0
+	if z.open {
+		newMax--
+	}
+	resized.setMax(newMax)
+	return &resized, nil
+}
+
+// Clone returns a copy of z, at the same position, so Next on either
+// produces the values z's Next would have, independently of the other.
+// The copy shares z's Sequence, so the two still can't be used
+// concurrently unless the Sequence allows that.
+func (z *Zipf) Clone() *Zipf {
+	c := *z
+	return &c
+}
+
 // Nth returns the Nth value from the sequence associated with the
 // given Zipf. The value is fully determined by the input values
-// (q, v, max, and seed) and the index.
+// (q, v, max, and seed) and the index. As with Permutation, seeking
+// using Nth changes the index that Next counts from; after calling
+// Nth(x), Next returns the same value as Nth(x+1).
 func (z *Zipf) Nth(index uint64) uint64 {
-	z.idx = index
+	z.idx = index + 1
+	return z.NthStateless(index)
+}
+
+// NthStateless returns the same value Nth(index) would, but without
+// changing the index Next counts from. The Zipf itself isn't modified
+// (unless Probability is also being called), so NthStateless can be called
+// concurrently if the underlying Sequence's BitsAt is safe for concurrent
+// use, as it is for the Sequence from NewSequence.
+func (z *Zipf) NthStateless(index uint64) uint64 {
 	offset := OffsetFor(SequenceZipfU, z.seed, 0, index)
 	for {
-		bits := z.src.BitsAt(offset)
-		uInt := bits.Lo
-		u := float64(uInt&(1<<53-1)) / (1 << 53)
+		u := z.src.BitsAt(offset).ToFloat64()
 		u = z.hImaxOneHalf + u*z.hX0MinusHImaxOneHalf
 		x := hInv(z, u)
-		k := math.Floor(x + 0.5)
+		// x can round up to max + 0.5, which mustn't become max + 1.
+		k := math.Min(math.Floor(x+0.5), z.max)
 		if k-x <= z.s {
-			return uint64(k)
+			return saturateUint64(k)
 		}
 		if u >= h(z, k+0.5)-math.Exp(-math.Log(z.v+k)*z.q) {
-			return uint64(k)
+			return saturateUint64(k)
 		}
 		// the low-order 24 bits of the high-order 64-bit word
 		// are the "iteration", which started as zero. Assuming we
@@ -123,8 +228,163 @@ func (z *Zipf) Nth(index uint64) uint64 {
 	}
 }
 
+// Probability returns the probability that a given value is produced.
+// Values run from 0 to max inclusive; anything else has probability 0.
+func (z *Zipf) Probability(value uint64) float64 {
+	if float64(value) > z.max {
+		return 0
+	}
+	if z.norm == 0 {
+		z.norm = zipfNormalizer(z.q, z.v, z.maxValue())
+	}
+	return math.Exp(-z.q*math.Log(z.v+float64(value))) / z.norm
+}
+
+// Bucket maps a value to one of numBuckets buckets, for making
+// histograms. The possible values, 0 through max inclusive, are divided
+// into numBuckets contiguous ranges, given by BucketRange, whose widths
+// follow the Zipf distribution's own shape: bucket b's width is
+// proportional to the integral of (v+x)^-q from b to b+1, so bucket 0 is
+// the widest and each bucket after it is narrower. It panics if
+// numBuckets is 0 or value is more than max.
+func (z *Zipf) Bucket(value uint64, numBuckets uint64) uint64 {
+	n := z.values()
+	if n == 0 {
+		if numBuckets == 0 {
+			panic(fmt.Sprintf("can't bucket value %d of 2^64 into 0 buckets", value))
+		}
+	} else if numBuckets == 0 || value >= n {
+		panic(fmt.Sprintf("can't bucket value %d of %d into %d buckets", value, n, numBuckets))
+	}
+	// find the first bucket after value's, which exists because the
+	// end of the last bucket is past every value.
+	lo, hi := uint64(1), numBuckets
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		if z.bucketStart(mid, numBuckets) > value {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo - 1
+}
+
+// BucketRange returns the values in the given bucket, for Bucket with
+// numBuckets buckets: those in [lo,hi). If there are more buckets than
+// values, some buckets are empty. If max is math.MaxUint64, the last
+// bucket's hi would be 2^64, so it wraps around to 0. It panics if bucket
+// isn't less than numBuckets.
+func (z *Zipf) BucketRange(bucket uint64, numBuckets uint64) (lo, hi uint64) {
+	if bucket >= numBuckets {
+		panic(fmt.Sprintf("bucket %d out of range [0,%d)", bucket, numBuckets))
+	}
+	return z.bucketStart(bucket, numBuckets), z.bucketStart(bucket+1, numBuckets)
+}
+
+// bucketStart returns the first value in the given bucket, or, for
+// bucket numBuckets, the number of values. Bucket b starts at
+// ceil(values * G(b) / G(numBuckets)), where G(x) is the integral of
+// (v+t)^-q for t from 0 to x, which is proportional to
+// 1-(1+x/v)^(1-q). Rounding up keeps bucket 0 at least as wide as
+// any other.
+func (z *Zipf) bucketStart(bucket uint64, numBuckets uint64) uint64 {
+	n := z.values()
+	if bucket == 0 {
+		return 0
+	}
+	if bucket == numBuckets {
+		// 2^64 wraps around to 0, which n already is.
+		return n
+	}
+	g := func(x uint64) float64 {
+		return -math.Expm1(z.oneMinusQ * math.Log1p(float64(x)/z.v))
+	}
+	values := float64(n)
+	if n == 0 {
+		values = 1 << 64
+	}
+	start := saturateUint64(math.Ceil(values * g(bucket) / g(numBuckets)))
+	if n != 0 && start > n {
+		return n
+	}
+	return start
+}
+
+// values returns the number of possible values, max+1, which is 0 if max
+// is math.MaxUint64, since 2^64 doesn't fit.
+func (z *Zipf) values() uint64 {
+	return z.maxValue() + 1
+}
+
+// maxValue returns max as a uint64. It's stored as a float64, in which
+// math.MaxUint64 rounds up to 2^64, which doesn't convert back.
+func (z *Zipf) maxValue() uint64 {
+	return saturateUint64(z.max)
+}
+
+// saturateUint64 converts a non-negative f to a uint64, giving
+// math.MaxUint64 for anything too big, rather than whatever the
+// conversion would produce.
+func saturateUint64(f float64) uint64 {
+	if f >= 1<<64 {
+		return math.MaxUint64
+	}
+	return uint64(f)
+}
+
+// String describes z's parameters and position, for debugging, in the
+// form "Zipf(q=1.5, v=1, max=1000, seed=0, idx=42)". The max is the one
+// z was created with; for NewZipfOpen, it's followed by " (open)".
+func (z *Zipf) String() string {
+	max := fmt.Sprint(z.maxValue())
+	if z.open {
+		max = fmt.Sprintf("%d (open)", z.maxValue()+1)
+	}
+	return fmt.Sprintf("Zipf(q=%g, v=%g, max=%s, seed=%d, idx=%d)", z.q, z.v, max, z.seed, z.idx)
+}
+
 // Next returns the "next" value -- the one after the last one requested, or
-// value 1 if none have been requested before.
+// value 0 if none have been requested before. Thus, for a new Zipf, the
+// first call to Next returns the same value as Nth(0).
 func (z *Zipf) Next() uint64 {
-	return z.Nth(z.idx + 1)
+	return z.Nth(z.idx)
+}
+
+// ZipfEnsemble returns numStreams Zipf generators with the same q, v, and
+// max, but independent outputs, for generating multiple columns with the
+// same distribution. Each one's seed is derived from baseSeed and its
+// position in the ensemble by hashing with src, rather than by just
+// counting up from baseSeed, so ensembles with nearby base seeds don't
+// share streams. The derived seeds are all distinct.
+func ZipfEnsemble(q, v float64, max uint64, numStreams int, baseSeed uint32, src Sequence) ([]*Zipf, error) {
+	if numStreams < 0 {
+		return nil, fmt.Errorf("need a non-negative number of streams, got %d", numStreams)
+	}
+	if src == nil {
+		return nil, fmt.Errorf("need a usable PRNG apophenia.Sequence")
+	}
+	out := make([]*Zipf, numStreams)
+	used := make(map[uint32]struct{}, numStreams)
+	var key [16]byte
+	for i := range out {
+		binary.LittleEndian.PutUint64(key[:8], uint64(i))
+		var seed uint32
+		// on the rare collision, rehash with a different attempt
+		// number.
+		for attempt := uint64(0); ; attempt++ {
+			binary.LittleEndian.PutUint64(key[8:], attempt)
+			seed = uint32(Hash64(key[:], baseSeed, src))
+			if _, ok := used[seed]; !ok {
+				break
+			}
+		}
+		used[seed] = struct{}{}
+		z, err := NewZipf(q, v, max, seed, src)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = z
+	}
+	return out, nil
 }
@@ -0,0 +1,243 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Default bounds used by FitZipf. The lower bound on q is just above 1,
+// because NewZipf rejects q <= 1.
+const (
+	fitZipfQMin = 1.0001
+	fitZipfQMax = 50
+	fitZipfVMin = 1
+	fitZipfVMax = 1e6
+)
+
+// zipfExactTerms is the number of terms of the normalizing sum we compute
+// directly before switching to the Euler-Maclaurin approximation for the
+// tail.
+const zipfExactTerms = 4096
+
+// zipfNormalizer returns the sum over k in [0,max] of (v+k)^-q, which is
+// the normalizing constant for the probabilities of a Zipf distribution
+// with those parameters. Large values of max are handled by summing the
+// first zipfExactTerms terms directly, and approximating the rest with the
+// Euler-Maclaurin formula, which is very accurate that far out on a curve
+// this smooth.
+func zipfNormalizer(q, v float64, max uint64) float64 {
+	f := func(x float64) float64 {
+		return math.Exp(-q * math.Log(v+x))
+	}
+	n := max
+	if n >= zipfExactTerms {
+		n = zipfExactTerms - 1
+	}
+	sum := 0.0
+	for k := uint64(0); k <= n; k++ {
+		sum += f(float64(k))
+	}
+	if n == max {
+		return sum
+	}
+	// Tail runs from a to b, inclusive.
+	a, b := float64(zipfExactTerms), float64(max)
+	var integral float64
+	if q == 1 {
+		integral = math.Log(v+b) - math.Log(v+a)
+	} else {
+		integral = (math.Exp((1-q)*math.Log(v+b)) - math.Exp((1-q)*math.Log(v+a))) / (1 - q)
+	}
+	// first and third derivatives of f
+	d1 := func(x float64) float64 {
+		return -q * math.Exp((-q-1)*math.Log(v+x))
+	}
+	d3 := func(x float64) float64 {
+		return -q * (q + 1) * (q + 2) * math.Exp((-q-3)*math.Log(v+x))
+	}
+	sum += integral + (f(a)+f(b))/2 + (d1(b)-d1(a))/12 - (d3(b)-d3(a))/720
+	return sum
+}
+
+// zipfNegLogLikelihood computes the negative log-likelihood of the observed
+// values (given as distinct values and their counts) for a Zipf with the
+// given parameters and max.
+func zipfNegLogLikelihood(q, v float64, max uint64, values []uint64, counts []float64, n float64) float64 {
+	sum := 0.0
+	for i, k := range values {
+		sum += counts[i] * math.Log(v+float64(k))
+	}
+	return q*sum + n*math.Log(zipfNormalizer(q, v, max))
+}
+
+// FitZipf estimates the q and v parameters of a Zipf distribution which
+// would produce the given data, using maximum likelihood estimation. The
+// max of the distribution is taken to be the largest value in the data.
+// The search is constrained to q in [1.0001, 50] and v in [1, 1e6]; use
+// FitZipfBounded to search a different range.
+func FitZipf(data []uint64) (q, v float64, err error) {
+	return FitZipfBounded(data, fitZipfQMin, fitZipfQMax, fitZipfVMin, fitZipfVMax)
+}
+
+// FitZipfBounded is FitZipf, but with the search for q constrained to
+// [qMin, qMax] and the search for v constrained to [vMin, vMax]. As with
+// NewZipf, q must be > 1 and v must be >= 1.
+func FitZipfBounded(data []uint64, qMin, qMax, vMin, vMax float64) (q, v float64, err error) {
+	for _, x := range []float64{qMin, qMax, vMin, vMax} {
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return 0, 0, fmt.Errorf("bounds must be finite, got q in [%g, %g], v in [%g, %g]", qMin, qMax, vMin, vMax)
+		}
+	}
+	if qMin <= 1 || vMin < 1 {
+		return 0, 0, fmt.Errorf("need qMin > 1 (got %g) and vMin >= 1 (got %g) for Zipf distribution", qMin, vMin)
+	}
+	if qMin > qMax || vMin > vMax {
+		return 0, 0, fmt.Errorf("empty bounds: q in [%g, %g], v in [%g, %g]", qMin, qMax, vMin, vMax)
+	}
+	if len(data) == 0 {
+		return 0, 0, errors.New("can't fit a Zipf distribution to empty data")
+	}
+	// Collapse the data into distinct values and counts, so each
+	// evaluation of the likelihood is proportional to the number of
+	// distinct values, not the number of samples.
+	hist := make(map[uint64]float64)
+	var max uint64
+	for _, x := range data {
+		hist[x]++
+		if x > max {
+			max = x
+		}
+	}
+	values := make([]uint64, 0, len(hist))
+	for k := range hist {
+		values = append(values, k)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	counts := make([]float64, len(values))
+	for i, k := range values {
+		counts[i] = hist[k]
+	}
+	n := float64(len(data))
+
+	// We search over (log(q-1), log(v)), which keeps the parameters in
+	// their valid ranges and puts the very different scales of q and v
+	// on a more even footing.
+	lo := [2]float64{math.Log(qMin - 1), math.Log(vMin)}
+	hi := [2]float64{math.Log(qMax - 1), math.Log(vMax)}
+	f := func(x [2]float64) float64 {
+		if x[0] < lo[0] || x[0] > hi[0] || x[1] < lo[1] || x[1] > hi[1] {
+			return math.Inf(1)
+		}
+		return zipfNegLogLikelihood(1+math.Exp(x[0]), math.Exp(x[1]), max, values, counts, n)
+	}
+	// Start at q=2, v=2, or as near as the bounds allow.
+	start := [2]float64{0, math.Log(2)}
+	for i := range start {
+		start[i] = math.Max(lo[i], math.Min(hi[i], start[i]))
+	}
+	best := nelderMead2(f, start, lo, hi)
+	return 1 + math.Exp(best[0]), math.Exp(best[1]), nil
+}
+
+// nelderMead2 minimizes f over two dimensions, starting near start, and
+// staying within [lo, hi]. It's a plain Nelder-Mead simplex search,
+// which is plenty for a smooth function of two variables.
+func nelderMead2(f func([2]float64) float64, start, lo, hi [2]float64) [2]float64 {
+	const (
+		maxIter = 2000
+		tol     = 1e-10
+	)
+	var simplex [3][2]float64
+	var values [3]float64
+	simplex[0] = start
+	for i := 0; i < 2; i++ {
+		p := start
+		// step down instead of up if we're near the upper bound, so the
+		// initial simplex is feasible.
+		step := 0.5
+		if p[i]+step > hi[i] {
+			step = -step
+		}
+		p[i] = math.Max(lo[i], math.Min(hi[i], p[i]+step))
+		simplex[i+1] = p
+	}
+	for i := range simplex {
+		values[i] = f(simplex[i])
+	}
+	for iter := 0; iter < maxIter; iter++ {
+		// order: best first, worst last.
+		for i := 1; i < 3; i++ {
+			for j := i; j > 0 && values[j] < values[j-1]; j-- {
+				values[j], values[j-1] = values[j-1], values[j]
+				simplex[j], simplex[j-1] = simplex[j-1], simplex[j]
+			}
+		}
+		size := math.Abs(simplex[2][0]-simplex[0][0]) + math.Abs(simplex[2][1]-simplex[0][1]) +
+			math.Abs(simplex[1][0]-simplex[0][0]) + math.Abs(simplex[1][1]-simplex[0][1])
+		if math.Abs(values[2]-values[0]) <= tol*(math.Abs(values[0])+tol) && size < 1e-8 {
+			break
+		}
+		var centroid [2]float64
+		for i := 0; i < 2; i++ {
+			centroid[i] = (simplex[0][i] + simplex[1][i]) / 2
+		}
+		along := func(t float64) [2]float64 {
+			return [2]float64{
+				centroid[0] + t*(simplex[2][0]-centroid[0]),
+				centroid[1] + t*(simplex[2][1]-centroid[1]),
+			}
+		}
+		reflected := along(-1)
+		fr := f(reflected)
+		switch {
+		case fr < values[0]:
+			expanded := along(-2)
+			if fe := f(expanded); fe < fr {
+				simplex[2], values[2] = expanded, fe
+			} else {
+				simplex[2], values[2] = reflected, fr
+			}
+		case fr < values[1]:
+			simplex[2], values[2] = reflected, fr
+		default:
+			contracted := along(0.5)
+			if fr < values[2] {
+				contracted = along(-0.5)
+			}
+			if fc := f(contracted); fc < math.Min(fr, values[2]) {
+				simplex[2], values[2] = contracted, fc
+				continue
+			}
+			// shrink towards the best point
+			for i := 1; i < 3; i++ {
+				for j := 0; j < 2; j++ {
+					simplex[i][j] = simplex[0][j] + (simplex[i][j]-simplex[0][j])/2
+				}
+				values[i] = f(simplex[i])
+			}
+		}
+	}
+	best := 0
+	for i := 1; i < 3; i++ {
+		if values[i] < values[best] {
+			best = i
+		}
+	}
+	return simplex[best]
+}
@@ -0,0 +1,105 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_ZipfNormalizer(t *testing.T) {
+	// compare the approximated tail against a brute-force sum.
+	cases := []struct {
+		q, v float64
+		max  uint64
+	}{
+		{q: 1.01, v: 1, max: 100},
+		{q: 1.5, v: 3, max: 50000},
+		{q: 2, v: 100, max: 200000},
+		{q: 1.1, v: 1, max: 1000000},
+	}
+	for _, c := range cases {
+		exact := 0.0
+		for k := c.max; ; k-- {
+			exact += math.Exp(-c.q * math.Log(c.v+float64(k)))
+			if k == 0 {
+				break
+			}
+		}
+		got := zipfNormalizer(c.q, c.v, c.max)
+		if math.Abs(got-exact)/exact > 1e-9 {
+			t.Errorf("q %g, v %g, max %d: expected %.12g, got %.12g", c.q, c.v, c.max, exact, got)
+		}
+	}
+}
+
+func Test_FitZipf(t *testing.T) {
+	cases := []struct {
+		q, v float64
+		max  uint64
+	}{
+		{q: 1.5, v: 3, max: 1000},
+		{q: 2, v: 10, max: 10000},
+		{q: 1.2, v: 1, max: 500},
+	}
+	const samples = 200000
+	for i, c := range cases {
+		z, err := NewZipf(c.q, c.v, c.max, 0, NewSequence(int64(i)))
+		if err != nil {
+			t.Fatalf("making zipf: %v", err)
+		}
+		data := make([]uint64, samples)
+		for j := range data {
+			data[j] = z.Next()
+		}
+		q, v, err := FitZipf(data)
+		if err != nil {
+			t.Fatalf("fitting zipf: %v", err)
+		}
+		if math.Abs(q-c.q)/c.q > 0.05 || math.Abs(v-c.v)/c.v > 0.05 {
+			t.Errorf("q %g, v %g: fit gave q %g, v %g", c.q, c.v, q, v)
+		} else {
+			t.Logf("q %g, v %g: fit gave q %g, v %g", c.q, c.v, q, v)
+		}
+		// a bounded search which excludes the real answer should end
+		// up on the boundary.
+		q, _, err = FitZipfBounded(data, c.q+0.5, c.q+1, 1, 100)
+		if err != nil {
+			t.Fatalf("fitting zipf: %v", err)
+		}
+		if math.Abs(q-(c.q+0.5)) > 0.01 {
+			t.Errorf("q %g, v %g: bounded fit with q >= %g gave q %g", c.q, c.v, c.q+0.5, q)
+		}
+	}
+}
+
+func Test_FitZipfInvalid(t *testing.T) {
+	if _, _, err := FitZipf(nil); err == nil {
+		t.Errorf("expected error fitting empty data")
+	}
+	data := []uint64{0, 0, 1, 2}
+	bounds := [][4]float64{
+		{1, 2, 1, 2},
+		{1.5, 2, 0.5, 2},
+		{2, 1.5, 1, 2},
+		{1.5, 2, 3, 2},
+		{1.5, math.NaN(), 1, 2},
+	}
+	for _, b := range bounds {
+		if _, _, err := FitZipfBounded(data, b[0], b[1], b[2], b[3]); err == nil {
+			t.Errorf("bounds %v: expected error", b)
+		}
+	}
+}
@@ -141,3 +141,428 @@ func Benchmark_Zipf(b *testing.B) {
 		_ = z.Next()
 	}
 }
+
+func Test_ZipfNextStartsAtZero(t *testing.T) {
+	z, err := NewZipf(1.3, 1.5, 100, 0, NewSequence(0))
+	if err != nil {
+		t.Fatalf("making zipf: %v", err)
+	}
+	z2, err := NewZipf(1.3, 1.5, 100, 0, NewSequence(0))
+	if err != nil {
+		t.Fatalf("making zipf: %v", err)
+	}
+	first := z.Next()
+	if nth := z2.Nth(0); first != nth {
+		t.Fatalf("first Next() returned %d, Nth(0) returned %d", first, nth)
+	}
+	if first != 5 {
+		t.Fatalf("first Next() returned %d, expected 5", first)
+	}
+	for i := uint64(1); i < 10; i++ {
+		got, exp := z.Next(), z2.Nth(i)
+		if got != exp {
+			t.Fatalf("Next() call %d returned %d, Nth(%d) returned %d", i, got, i, exp)
+		}
+	}
+	// seeking with Nth moves Next along with it
+	_ = z.Nth(50)
+	if got, exp := z.Next(), z2.Nth(51); got != exp {
+		t.Fatalf("Next() after Nth(50) returned %d, Nth(51) returned %d", got, exp)
+	}
+}
+
+func Test_ZipfWithMax(t *testing.T) {
+	z, err := NewZipf(1.3, 1.5, 100, 3, NewSequence(0))
+	if err != nil {
+		t.Fatalf("making zipf: %v", err)
+	}
+	same, err := z.WithMax(100)
+	if err != nil {
+		t.Fatalf("resizing zipf: %v", err)
+	}
+	bigger, err := z.WithMax(1000)
+	if err != nil {
+		t.Fatalf("resizing zipf: %v", err)
+	}
+	direct, err := NewZipf(1.3, 1.5, 1000, 3, NewSequence(0))
+	if err != nil {
+		t.Fatalf("making zipf: %v", err)
+	}
+	sawBig := false
+	for i := uint64(0); i < 10000; i++ {
+		if a, b := z.Nth(i), same.Nth(i); a != b {
+			t.Fatalf("index %d: original gave %d, WithMax(same) gave %d", i, a, b)
+		}
+		a, b := bigger.Nth(i), direct.Nth(i)
+		if a != b {
+			t.Fatalf("index %d: WithMax(1000) gave %d, NewZipf gave %d", i, a, b)
+		}
+		if a > 1000 {
+			t.Fatalf("index %d: WithMax(1000) gave out-of-range %d", i, a)
+		}
+		if a > 100 {
+			sawBig = true
+		}
+	}
+	if !sawBig {
+		t.Fatalf("WithMax(1000) never produced a value over the original max")
+	}
+	if _, err := z.WithMax(0); err == nil {
+		t.Fatalf("expected error resizing to max 0")
+	}
+}
+
+func Test_ZipfOpenClosed(t *testing.T) {
+	const max = 10
+	open, err := NewZipfOpen(1.1, 1, max, 0, NewSequence(0))
+	if err != nil {
+		t.Fatalf("creating open zipf: %v", err)
+	}
+	closed, err := NewZipfClosed(1.1, 1, max, 0, NewSequence(0))
+	if err != nil {
+		t.Fatalf("creating closed zipf: %v", err)
+	}
+	closedLess, _ := NewZipfClosed(1.1, 1, max-1, 0, NewSequence(0))
+	sawMax := false
+	samples := uint64(1000000)
+	if testing.Short() {
+		samples = 10000
+	}
+	for i := uint64(0); i < samples; i++ {
+		o := open.Nth(i)
+		if o >= max {
+			t.Fatalf("index %d: open zipf produced %d, expected < %d", i, o, max)
+		}
+		if c := closedLess.Nth(i); o != c {
+			t.Fatalf("index %d: open zipf produced %d, closed with max-1 produced %d", i, o, c)
+		}
+		c := closed.Nth(i)
+		if c > max {
+			t.Fatalf("index %d: closed zipf produced %d, expected <= %d", i, c, max)
+		}
+		sawMax = sawMax || c == max
+	}
+	if !sawMax {
+		t.Errorf("closed zipf never produced its max, %d", max)
+	}
+	if p := open.Probability(max); p != 0 {
+		t.Errorf("open zipf: expected probability 0 for max, got %g", p)
+	}
+	resized, err := open.WithMax(5)
+	if err != nil {
+		t.Fatalf("resizing open zipf: %v", err)
+	}
+	for i := uint64(0); i < 10000; i++ {
+		if x := resized.Nth(i); x >= 5 {
+			t.Fatalf("index %d: resized open zipf produced %d, expected < 5", i, x)
+		}
+	}
+	if _, err := NewZipfOpen(1.1, 1, 0, 0, NewSequence(0)); err == nil {
+		t.Errorf("expected error for open zipf with max 0")
+	}
+	if _, err := open.WithMax(0); err == nil {
+		t.Errorf("expected error resizing open zipf to max 0")
+	}
+}
+
+func Test_ZipfEnsemble(t *testing.T) {
+	const streams, samples = 4, 50000
+	zs, err := ZipfEnsemble(1.3, 1.5, 100, streams, 0, NewSequence(0))
+	if err != nil {
+		t.Fatalf("creating ensemble: %v", err)
+	}
+	if len(zs) != streams {
+		t.Fatalf("expected %d generators, got %d", streams, len(zs))
+	}
+	values := make([][]uint64, streams)
+	for s, z := range zs {
+		counts := make(map[uint64]int)
+		values[s] = make([]uint64, samples)
+		for i := range values[s] {
+			v := z.Nth(uint64(i))
+			values[s][i] = v
+			counts[v]++
+		}
+		for v := uint64(0); v < 5; v++ {
+			exp := z.Probability(v) * samples
+			if math.Abs(float64(counts[v])-exp) > 5*math.Sqrt(exp) {
+				t.Errorf("stream %d, value %d: expected about %.0f, got %d", s, v, exp, counts[v])
+			}
+		}
+	}
+	for a := 0; a < streams; a++ {
+		for b := a + 1; b < streams; b++ {
+			same := 0
+			for i := range values[a] {
+				if values[a][i] == values[b][i] {
+					same++
+				}
+			}
+			// independent streams agree about as often as two draws
+			// from the distribution would, well under a third of
+			// the time for these parameters.
+			if same > samples/3 {
+				t.Errorf("streams %d and %d agree on %d of %d values", a, b, same, samples)
+			}
+		}
+	}
+	if _, err := ZipfEnsemble(0.5, 1, 100, 2, 0, NewSequence(0)); err == nil {
+		t.Errorf("expected error for invalid q")
+	}
+}
+
+func Test_ZipfF(t *testing.T) {
+	src := NewSequence(0)
+	for _, max := range []float64{1, 99.9, 100, 1e6 + 0.5} {
+		zf, err := NewZipfF(1.5, 2, max, 3, src)
+		if err != nil {
+			t.Fatalf("max %g: %v", max, err)
+		}
+		z, _ := NewZipf(1.5, 2, uint64(max), 3, src)
+		for i := uint64(0); i < 1000; i++ {
+			if got, exp := zf.Nth(i), z.Nth(i); got != exp {
+				t.Fatalf("max %g, index %d: expected %d, got %d", max, i, exp, got)
+			}
+		}
+	}
+	for _, max := range []float64{0.99, 0, -1, math.Inf(1), math.NaN(), 1 << 64} {
+		if _, err := NewZipfF(1.5, 2, max, 3, src); err == nil {
+			t.Errorf("max %g: expected error", max)
+		}
+	}
+}
+
+func Test_ZipfString(t *testing.T) {
+	src := NewSequence(0)
+	users, err := NewZipfString(1.5, 2, 1000, "users", src)
+	if err != nil {
+		t.Fatalf("creating Zipf: %v", err)
+	}
+	pages, _ := NewZipfString(1.5, 2, 1000, "pages", src)
+	again, _ := NewZipfString(1.5, 2, 1000, "users", src)
+	differ := 0
+	for i := uint64(0); i < 1000; i++ {
+		u := users.Nth(i)
+		if u != pages.Nth(i) {
+			differ++
+		}
+		if got := again.Nth(i); got != u {
+			t.Fatalf("index %d: same string gave %d and %d", i, u, got)
+		}
+	}
+	// small values are common, so some coincide, but most shouldn't.
+	if differ < 500 {
+		t.Errorf("\"users\" and \"pages\" differ at only %d of 1000 indexes", differ)
+	}
+	empty, err := NewZipfString(1.5, 2, 1000, "", src)
+	if err != nil {
+		t.Fatalf("empty seed string: %v", err)
+	}
+	// the FNV-1a hash of no bytes is its offset basis.
+	z, _ := NewZipf(1.5, 2, 1000, 0x811c9dc5, src)
+	for i := uint64(0); i < 100; i++ {
+		if got, exp := empty.Nth(i), z.Nth(i); got != exp {
+			t.Fatalf("empty seed string, index %d: expected %d, got %d", i, exp, got)
+		}
+	}
+	if _, err := NewZipfString(0.5, 2, 1000, "users", src); err == nil {
+		t.Errorf("expected error for q < 1")
+	}
+}
+
+func Test_ZipfClone(t *testing.T) {
+	z, err := NewZipf(1.3, 2, 1000, 1, NewSequence(0))
+	if err != nil {
+		t.Fatalf("creating Zipf: %v", err)
+	}
+	z.Nth(41)
+	c := z.Clone()
+	for i := 0; i < 100; i++ {
+		if a, b := z.Next(), c.Next(); a != b {
+			t.Fatalf("step %d: original gave %d, clone gave %d", i, a, b)
+		}
+	}
+	// advancing the clone leaves the original alone.
+	for i := 0; i < 50; i++ {
+		c.Next()
+	}
+	if got, exp := z.Next(), z.Clone().Nth(142); got != exp {
+		t.Fatalf("original after advancing clone: expected %d, got %d", exp, got)
+	}
+	if got, exp := c.Next(), z.Clone().Nth(192); got != exp {
+		t.Fatalf("clone after advancing: expected %d, got %d", exp, got)
+	}
+}
+
+func Test_ZipfBucket(t *testing.T) {
+	src := NewSequence(0)
+	for _, c := range []struct{ max, buckets uint64 }{{99, 10}, {99, 7}, {9, 10}, {4, 13}, {1000, 1}} {
+		z, err := NewZipf(1.2, 3, c.max, 0, src)
+		if err != nil {
+			t.Fatalf("creating Zipf: %v", err)
+		}
+		total := uint64(0)
+		lo0, hi0 := z.BucketRange(0, c.buckets)
+		for b := uint64(0); b < c.buckets; b++ {
+			lo, hi := z.BucketRange(b, c.buckets)
+			if hi-lo > hi0-lo0 {
+				t.Fatalf("max %d, %d buckets: bucket %d is wider than bucket 0", c.max, c.buckets, b)
+			}
+			if lo != total {
+				t.Fatalf("max %d, %d buckets: bucket %d starts at %d, expected %d", c.max, c.buckets, b, lo, total)
+			}
+			for v := lo; v < hi; v++ {
+				if got := z.Bucket(v, c.buckets); got != b {
+					t.Fatalf("max %d, %d buckets: value %d in bucket %d, expected %d", c.max, c.buckets, v, got, b)
+				}
+			}
+			total = hi
+		}
+		if total != c.max+1 {
+			t.Fatalf("max %d, %d buckets: bucket sizes sum to %d", c.max, c.buckets, total)
+		}
+	}
+	// counts follow the probability of each bucket's range.
+	const n, buckets = 100000, 10
+	z, _ := NewZipf(1.2, 3, 999, 1, src)
+	counts := make([]int, buckets)
+	for i := 0; i < n; i++ {
+		counts[z.Bucket(z.Next(), buckets)]++
+	}
+	chi := 0.0
+	for b, c := range counts {
+		lo, hi := z.BucketRange(uint64(b), buckets)
+		p := 0.0
+		for v := lo; v < hi; v++ {
+			p += z.Probability(v)
+		}
+		exp := p * n
+		chi += (float64(c) - exp) * (float64(c) - exp) / exp
+		if b > 0 && counts[b] > counts[0] {
+			t.Errorf("bucket %d has more values than bucket 0", b)
+		}
+	}
+	// 9 degrees of freedom; 33 is about p = 0.0001.
+	if chi > 33 {
+		t.Errorf("bucket counts %v: chi-square %.1f too high", counts, chi)
+	}
+}
+
+func Test_ZipfEdgeMax(t *testing.T) {
+	src := NewSequence(0)
+	// max 0: the only value is 0.
+	z, err := NewZipf(1.5, 2, 0, 0, src)
+	if err != nil {
+		t.Fatalf("max 0: %v", err)
+	}
+	for i := uint64(0); i < 1000; i++ {
+		if got := z.Nth(i); got != 0 {
+			t.Fatalf("max 0: index %d gave %d", i, got)
+		}
+	}
+	if p := z.Probability(0); p != 1 {
+		t.Errorf("max 0: Probability(0) = %g, expected 1", p)
+	}
+	if lo, hi := z.BucketRange(0, 1); lo != 0 || hi != 1 {
+		t.Errorf("max 0: BucketRange(0, 1) = [%d,%d), expected [0,1)", lo, hi)
+	}
+
+	// max 1: 0 and 1, in proportion v^-q to (v+1)^-q.
+	const n = 100000
+	z, err = NewZipf(1.5, 2, 1, 0, src)
+	if err != nil {
+		t.Fatalf("max 1: %v", err)
+	}
+	ones := 0
+	for i := uint64(0); i < n; i++ {
+		switch z.Nth(i) {
+		case 0:
+		case 1:
+			ones++
+		default:
+			t.Fatalf("max 1: index %d gave %d", i, z.Nth(i))
+		}
+	}
+	p1 := math.Pow(3, -1.5) / (math.Pow(2, -1.5) + math.Pow(3, -1.5))
+	if got := z.Probability(1); math.Abs(got-p1) > 1e-12 {
+		t.Errorf("max 1: Probability(1) = %g, expected %g", got, p1)
+	}
+	exp := p1 * n
+	if math.Abs(float64(ones)-exp) > 5*math.Sqrt(exp*(1-p1)) {
+		t.Errorf("max 1: expected about %.0f ones, got %d", exp, ones)
+	}
+
+	// max math.MaxUint64, with q close to 1 so values are often huge.
+	z, err = NewZipf(1.0001, 1, math.MaxUint64, 0, src)
+	if err != nil {
+		t.Fatalf("max MaxUint64: %v", err)
+	}
+	huge := 0
+	for i := uint64(0); i < n; i++ {
+		v := z.Nth(i)
+		if v > 1<<63 {
+			huge++
+		}
+		b := z.Bucket(v, 4)
+		if lo, hi := z.BucketRange(b, 4); v < lo || (hi != 0 && v >= hi) {
+			t.Fatalf("max MaxUint64: value %#x in bucket %d, [%#x,%#x)", v, b, lo, hi)
+		}
+	}
+	if huge == 0 {
+		t.Errorf("max MaxUint64: no values over 2^63")
+	}
+	if p := z.Probability(math.MaxUint64); !(p > 0 && p < 1e-15) {
+		t.Errorf("max MaxUint64: Probability(MaxUint64) = %g, expected tiny but positive", p)
+	}
+	if p := z.Probability(0); !(p > 0 && p < 1) {
+		t.Errorf("max MaxUint64: Probability(0) = %g", p)
+	}
+	end := uint64(0)
+	for b := uint64(0); b < 4; b++ {
+		lo, hi := z.BucketRange(b, 4)
+		if lo != end || (b < 3) != (hi > lo) {
+			t.Errorf("max MaxUint64: BucketRange(%d, 4) = [%#x,%#x)", b, lo, hi)
+		}
+		end = hi
+	}
+	if end != 0 {
+		t.Errorf("max MaxUint64: last bucket ends at %#x, expected 2^64 wrapped to 0", end)
+	}
+	if b := z.Bucket(math.MaxUint64, 3); b != 2 {
+		t.Errorf("max MaxUint64: Bucket(MaxUint64, 3) = %d, expected 2", b)
+	}
+	// a k of max, stored as 2^64, must convert to MaxUint64 rather
+	// than overflowing.
+	if got := saturateUint64(z.max); got != math.MaxUint64 {
+		t.Errorf("max MaxUint64: saturated max %#x", got)
+	}
+	data, err := z.GobEncode()
+	if err != nil {
+		t.Fatalf("max MaxUint64: encoding: %v", err)
+	}
+	var decoded Zipf
+	if err := decoded.GobDecode(data); err != nil {
+		t.Fatalf("max MaxUint64: decoding: %v", err)
+	}
+	for i := uint64(0); i < 100; i++ {
+		if got, exp := decoded.Nth(i), z.Nth(i); got != exp {
+			t.Fatalf("max MaxUint64: decoded index %d gave %d, expected %d", i, got, exp)
+		}
+	}
+}
+
+func Test_ZipfStringer(t *testing.T) {
+	z, _ := NewZipf(1.5, 1, 1000, 3, NewSequence(0))
+	z.Nth(41)
+	if got, exp := z.String(), "Zipf(q=1.5, v=1, max=1000, seed=3, idx=42)"; got != exp {
+		t.Errorf("expected %q, got %q", exp, got)
+	}
+	open, _ := NewZipfOpen(1.25, 2.5, 1000, 0, NewSequence(0))
+	if got, exp := fmt.Sprint(open), "Zipf(q=1.25, v=2.5, max=1000 (open), seed=0, idx=0)"; got != exp {
+		t.Errorf("expected %q, got %q", exp, got)
+	}
+	huge, _ := NewZipf(1.5, 1, math.MaxUint64, 0, NewSequence(0))
+	if got, exp := huge.String(), "Zipf(q=1.5, v=1, max=18446744073709551615, seed=0, idx=0)"; got != exp {
+		t.Errorf("expected %q, got %q", exp, got)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"fmt"
+	"math"
+)
+
+// ZipfTableMax is the largest max NewZipfTable accepts.
+const ZipfTableMax = 65535
+
+// ZipfTable produces values with the same distribution as a Zipf, but
+// using a precomputed alias table, so each value takes a single 128-bit
+// value from the Sequence and a table lookup, rather than the
+// rejection-inversion loop and its logarithms and exponentials. The
+// table has one entry per possible value, so this is only available
+// for max up to ZipfTableMax.
+//
+// The values have the same distribution as those of a Zipf with the same
+// parameters, but are not the same values.
+type ZipfTable struct {
+	src   Sequence
+	seed  uint32
+	table *aliasTable
+	probs []float64
+	idx   uint64
+}
+
+// NewZipfTable returns a ZipfTable with the specified q, v, and max,
+// producing values in [0,max], inclusive, as NewZipf does.
+func NewZipfTable(q, v float64, max uint64, seed uint32, src Sequence) (*ZipfTable, error) {
+	if max > ZipfTableMax {
+		return nil, fmt.Errorf("max %d too large for Zipf table, limit is %d", max, ZipfTableMax)
+	}
+	// for the parameter validation.
+	if _, err := newZipf(q, v, max, seed, src); err != nil {
+		return nil, err
+	}
+	weights := make([]float64, max+1)
+	total := 0.0
+	for k := range weights {
+		weights[k] = math.Exp(-q * math.Log(v+float64(k)))
+		total += weights[k]
+	}
+	probs := make([]float64, len(weights))
+	for k, w := range weights {
+		probs[k] = w / total
+	}
+	return &ZipfTable{src: src, seed: seed, table: newAliasTable(weights), probs: probs}, nil
+}
+
+// NewZipfTableFromSeed is NewZipfTable with seed.Uint32() as the seed.
+func NewZipfTableFromSeed(q, v float64, max uint64, seed Seed, src Sequence) (*ZipfTable, error) {
+	return NewZipfTable(q, v, max, seed.Uint32(), src)
+}
+
+// Nth returns the value for the given index. After calling Nth(x), Next
+// returns the same value as Nth(x+1).
+func (z *ZipfTable) Nth(index uint64) uint64 {
+	z.idx = index + 1
+	return uint64(z.table.sample(z.src.BitsAt(OffsetFor(SequenceZipfTable, z.seed, 0, index))))
+}
+
+// Next returns the value after the last one requested, or the value for
+// index 0 if none have been requested.
+func (z *ZipfTable) Next() uint64 {
+	return z.Nth(z.idx)
+}
+
+// Probability returns the probability of the given value.
+func (z *ZipfTable) Probability(value uint64) float64 {
+	if value >= uint64(len(z.probs)) {
+		return 0
+	}
+	return z.probs[value]
+}
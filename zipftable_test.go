@@ -0,0 +1,82 @@
+// Copyright 2019 Pilosa Corp.
+//
+// Licensed under the BSD 3-Clause license (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     https://opensource.org/licenses/BSD-3-Clause
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apophenia
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_ZipfTable(t *testing.T) {
+	const max, n = 50, 200000
+	src := NewSequence(0)
+	table, err := NewZipfTable(1.3, 2, max, 1, src)
+	if err != nil {
+		t.Fatalf("creating Zipf table: %v", err)
+	}
+	z, _ := NewZipf(1.3, 2, max, 1, src)
+	tableCounts := make([]float64, max+1)
+	zipfCounts := make([]float64, max+1)
+	for i := 0; i < n; i++ {
+		v := table.Next()
+		if v > max {
+			t.Fatalf("value %d out of range", v)
+		}
+		tableCounts[v]++
+		zipfCounts[z.Next()]++
+	}
+	// the probabilities agree with Zipf's, and so do the samples:
+	// a two-sample chi-square test on the counts.
+	chi := 0.0
+	for v := uint64(0); v <= max; v++ {
+		if p, exp := table.Probability(v), z.Probability(v); math.Abs(p-exp) > 1e-9 {
+			t.Errorf("value %d: table probability %g, Zipf gives %g", v, p, exp)
+		}
+		a, b := tableCounts[v], zipfCounts[v]
+		if a+b > 0 {
+			chi += (a - b) * (a - b) / (a + b)
+		}
+	}
+	// 50 degrees of freedom; 100 is about p = 0.00003.
+	if chi > 100 {
+		t.Errorf("table and Zipf samples differ: chi-square %.1f", chi)
+	}
+	if got, exp := table.Nth(7), table.Nth(7); got != exp {
+		t.Errorf("Nth(7) gave %d, then %d", exp, got)
+	}
+	if _, err := NewZipfTable(1.3, 2, ZipfTableMax+1, 0, src); err == nil {
+		t.Errorf("expected error for max over limit")
+	}
+	if _, err := NewZipfTable(1, 2, 10, 0, src); err == nil {
+		t.Errorf("expected error for q of 1")
+	}
+}
+
+func BenchmarkZipfTable(b *testing.B) {
+	src := NewSequence(0)
+	b.Run("Zipf", func(b *testing.B) {
+		z, _ := NewZipf(1.3, 2, ZipfTableMax, 0, src)
+		for i := 0; i < b.N; i++ {
+			z.Next()
+		}
+	})
+	b.Run("Table", func(b *testing.B) {
+		z, _ := NewZipfTable(1.3, 2, ZipfTableMax, 0, src)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			z.Next()
+		}
+	})
+}